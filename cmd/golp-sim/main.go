@@ -0,0 +1,63 @@
+// Command golp-sim runs a Launchpad Mini simulator in the terminal.
+//
+// It publishes virtual MIDI ports named like a Launchpad, so any golp app
+// (or other software) started afterwards finds it with Open and runs against
+// it unchanged. Virtual ports need ALSA (Linux) or CoreMIDI (macOS).
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/inegm/golp/pkg/simulator"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+	_ "gitlab.com/gomidi/midi/v2/drivers/rtmididrv" // auto-register rtmidi driver
+)
+
+// virtualOpener is implemented by drivers that can create virtual ports
+type virtualOpener interface {
+	OpenVirtualIn(name string) (drivers.In, error)
+	OpenVirtualOut(name string) (drivers.Out, error)
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "golp-sim: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	defer midi.CloseDriver()
+
+	drv, ok := drivers.Get().(virtualOpener)
+	if !ok {
+		return fmt.Errorf("the MIDI driver does not support virtual ports")
+	}
+
+	// The app reads presses from our output and sends LEDs to our input
+	out, err := drv.OpenVirtualOut(simulator.PortName)
+	if err != nil {
+		return fmt.Errorf("failed to create virtual output: %w", err)
+	}
+	in, err := drv.OpenVirtualIn(simulator.PortName)
+	if err != nil {
+		return fmt.Errorf("failed to create virtual input: %w", err)
+	}
+
+	sim := simulator.New()
+	sim.OnSend(func(msg []byte) {
+		out.Send(msg)
+	})
+
+	stop, err := midi.ListenTo(in, func(msg midi.Message, timestampms int32) {
+		sim.Receive(msg)
+	}, midi.UseSysEx()) // Device inquiries
+	if err != nil {
+		return fmt.Errorf("failed to listen to virtual input: %w", err)
+	}
+	defer stop()
+
+	return simulator.NewTerminal(sim).Run()
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/layout"
+	"github.com/inegm/golp/pkg/script"
+	"github.com/inegm/golp/pkg/text"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// runList lists MIDI ports
+func runList(args []string) error {
+	fmt.Println("Inputs:")
+	for _, port := range midi.GetInPorts() {
+		fmt.Printf("  %s %d: %s%s\n", marker(port.String()), port.Number(), port.String(), model(port.String()))
+	}
+	fmt.Println("Outputs:")
+	for _, port := range midi.GetOutPorts() {
+		fmt.Printf("  %s %d: %s%s\n", marker(port.String()), port.Number(), port.String(), model(port.String()))
+	}
+	return nil
+}
+
+// marker flags ports that Open would pick
+func marker(name string) string {
+	if strings.Contains(strings.ToLower(name), "launchpad") {
+		return "*"
+	}
+	return " "
+}
+
+// model names the Launchpad model detected from a port name
+func model(name string) string {
+	if p := launchpad.ProfileForPort(name); p != nil {
+		return " (" + p.Name + ")"
+	}
+	return ""
+}
+
+// runMonitor prints button events until interrupted
+func runMonitor(args []string) error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	fmt.Println("Listening for button events, Ctrl+C to stop")
+	for {
+		select {
+		case event := <-lp.ButtonEvents():
+			fmt.Printf("%s %v\n", time.Now().Format("15:04:05.000"), event)
+		case <-sigChan:
+			return nil
+		}
+	}
+}
+
+// runTest lights all LEDs in test mode
+func runTest(args []string) error {
+	brightness := launchpad.BrightnessFull
+	if len(args) == 1 {
+		b, err := parseBrightness(args[0])
+		if err != nil || b == launchpad.BrightnessOff {
+			return errUsage
+		}
+		brightness = b
+	}
+	return lp.TestLEDs(brightness)
+}
+
+// runSet sets one LED
+func runSet(args []string) error {
+	x, errX := strconv.Atoi(args[0])
+	y, errY := strconv.Atoi(args[1])
+	if errX != nil || errY != nil {
+		return errUsage
+	}
+
+	state, err := parseState(args[2], args[3])
+	if err != nil {
+		return err
+	}
+
+	btn := launchpad.ButtonAt(x, y)
+	if !btn.Valid() {
+		return fmt.Errorf("invalid button position: (%d, %d)", x, y)
+	}
+	return lp.SetButtonLEDState(btn, state)
+}
+
+// runFill sets all LEDs
+func runFill(args []string) error {
+	state, err := parseState(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	var frame launchpad.Frame
+	frame.Fill(state)
+	return lp.SetFrame(&frame)
+}
+
+// runText scrolls a message
+func runText(args []string) error {
+	flags := flag.NewFlagSet("text", flag.ContinueOnError)
+	flags.Usage = func() {}
+	color := flags.String("color", "green", "text color")
+	brightness := flags.String("brightness", "full", "text brightness")
+	delay := flags.Duration("delay", 80*time.Millisecond, "time between scroll steps")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		return errUsage
+	}
+
+	state, err := parseState(*color, *brightness)
+	if err != nil {
+		return err
+	}
+	return text.Scroll(lp, strings.Join(flags.Args(), " "), state, *delay)
+}
+
+// runImage shows an image
+func runImage(args []string) error {
+	frame, err := loadImage(args[0])
+	if err != nil {
+		return err
+	}
+	return lp.SetFrame(frame)
+}
+
+// runLayout runs a layout file, reloading it when it changes, until interrupted
+func runLayout(args []string) error {
+	cfg, err := layout.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	// The output port is opened once; changing it requires a restart
+	var out drivers.Out
+	if cfg.Output != "" {
+		out, err = midi.FindOutPort(cfg.Output)
+		if err != nil {
+			return fmt.Errorf("no MIDI output matching %q", cfg.Output)
+		}
+		if err := out.Open(); err != nil {
+			return fmt.Errorf("failed to open MIDI output %q: %w", cfg.Output, err)
+		}
+		defer out.Close()
+	}
+
+	c := layout.New(lp, out)
+	if err := c.Apply(cfg); err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	defer c.Stop()
+
+	stop := c.Watch(args[0], layout.DefaultWatchInterval, func(err error) {
+		fmt.Fprintf(os.Stderr, "golp layout: %v\n", err)
+	})
+	defer stop()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	fmt.Printf("Running %s, Ctrl+C to stop\n", args[0])
+	<-sigChan
+	return nil
+}
+
+// runScript runs a Lua script, reloading it when it changes, until interrupted
+func runScript(args []string) error {
+	flags := flag.NewFlagSet("script", flag.ContinueOnError)
+	flags.Usage = func() {}
+	output := flags.String("out", "", "MIDI output port for the script's midi functions")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+	path := flags.Arg(0)
+
+	var out drivers.Out
+	if *output != "" {
+		var err error
+		out, err = midi.FindOutPort(*output)
+		if err != nil {
+			return fmt.Errorf("no MIDI output matching %q", *output)
+		}
+		if err := out.Open(); err != nil {
+			return fmt.Errorf("failed to open MIDI output %q: %w", *output, err)
+		}
+		defer out.Close()
+	}
+
+	h := script.New(lp, out)
+	if err := h.Load(path); err != nil {
+		return err
+	}
+	defer h.Close()
+
+	stop := h.Watch(path, script.DefaultWatchInterval)
+	defer stop()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	fmt.Printf("Running %s, Ctrl+C to stop\n", path)
+	<-sigChan
+	return nil
+}
+
+// runInfo prints the model being driven and the device's identity
+func runInfo(args []string) error {
+	fmt.Println("Model:   ", lp.Profile())
+	info, ok := lp.DeviceInfo()
+	if !ok {
+		fmt.Println("Identity: no reply to device inquiry")
+		return nil
+	}
+	fmt.Printf("Family:   %04X\n", info.Family)
+	fmt.Printf("Model ID: %04X\n", info.Model)
+	fmt.Printf("Firmware: %s\n", info.Firmware())
+	return nil
+}
+
+// runReset resets the device
+func runReset(args []string) error {
+	return lp.Reset()
+}
+
+// runSend sends raw bytes given in hex, as separate arguments or one string
+func runSend(args []string) error {
+	digits := strings.ReplaceAll(strings.Join(args, ""), " ", "")
+	msg, err := hex.DecodeString(digits)
+	if err != nil {
+		return fmt.Errorf("invalid hex message: %w", err)
+	}
+	return lp.Send(msg)
+}
+
+// parseState parses a color and brightness
+func parseState(color, brightness string) (launchpad.LEDState, error) {
+	var c launchpad.Color
+	switch strings.ToLower(color) {
+	case "off":
+		c = launchpad.ColorOff
+	case "red":
+		c = launchpad.ColorRed
+	case "green":
+		c = launchpad.ColorGreen
+	case "amber":
+		c = launchpad.ColorAmber
+	case "yellow":
+		c = launchpad.ColorYellow
+	default:
+		return launchpad.LEDState{}, fmt.Errorf("invalid color: %q", color)
+	}
+
+	b, err := parseBrightness(brightness)
+	if err != nil {
+		return launchpad.LEDState{}, err
+	}
+	return launchpad.NewLEDState(c, b), nil
+}
+
+// parseBrightness parses a brightness name or level
+func parseBrightness(s string) (launchpad.Brightness, error) {
+	switch strings.ToLower(s) {
+	case "off", "0":
+		return launchpad.BrightnessOff, nil
+	case "low", "1":
+		return launchpad.BrightnessLow, nil
+	case "medium", "2":
+		return launchpad.BrightnessMedium, nil
+	case "full", "3":
+		return launchpad.BrightnessFull, nil
+	default:
+		return 0, fmt.Errorf("invalid brightness: %q", s)
+	}
+}
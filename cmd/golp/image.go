@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif" // Register decoders
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// loadImage decodes an image and scales it to the 8x8 grid
+// Each LED shows the average color of its area, with red and green mapped to
+// the four brightness levels (blue is ignored)
+func loadImage(path string) (*launchpad.Frame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+
+	var frame launchpad.Frame
+	for gy := 0; gy < launchpad.GridHeight; gy++ {
+		for gx := 0; gx < launchpad.GridWidth; gx++ {
+			area := image.Rect(
+				bounds.Min.X+gx*bounds.Dx()/launchpad.GridWidth,
+				bounds.Min.Y+gy*bounds.Dy()/launchpad.GridHeight,
+				bounds.Min.X+(gx+1)*bounds.Dx()/launchpad.GridWidth,
+				bounds.Min.Y+(gy+1)*bounds.Dy()/launchpad.GridHeight,
+			)
+			r, g := average(img, area)
+			frame.Set(launchpad.NewGridButton(gx, gy), launchpad.LEDState{
+				Red:   level(r),
+				Green: level(g),
+			})
+		}
+	}
+	return &frame, nil
+}
+
+// average returns the mean red and green of an area, weighted by alpha
+func average(img image.Image, area image.Rectangle) (r, g uint64) {
+	if area.Empty() {
+		area = image.Rect(area.Min.X, area.Min.Y, area.Min.X+1, area.Min.Y+1)
+	}
+
+	var n uint64
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			pr, pg, _, _ := img.At(x, y).RGBA() // Premultiplied by alpha
+			r += uint64(pr)
+			g += uint64(pg)
+			n++
+		}
+	}
+	return r / n, g / n
+}
+
+// level maps a 16-bit color channel to a brightness
+func level(v uint64) launchpad.Brightness {
+	return launchpad.Brightness((v*3 + 0x7FFF) / 0xFFFF)
+}
@@ -0,0 +1,157 @@
+// Command golp inspects and drives a Launchpad Mini from the shell.
+//
+// Usage:
+//
+//	golp [-port name] <command> [arguments]
+//
+// Commands:
+//
+//	list                        list MIDI ports, marking Launchpads
+//	info                        show the model and firmware
+//	monitor                     print button events with timestamps
+//	test [low|medium|full]      light all LEDs in test mode
+//	set x y color brightness    set one LED (x=8 for scene buttons, y=-1 for top buttons)
+//	fill color brightness       set all 80 LEDs
+//	text [flags] message        scroll text across the grid
+//	image file                  show a PNG, JPEG or GIF scaled to the grid
+//	layout file                 run a layout file, reloading it when it changes
+//	script [-out port] file     run a Lua script, reloading it when it changes
+//	reset                       reset the device
+//	send hex...                 send a raw MIDI message, e.g. "b0 00 7f"
+//
+// Colors are off, red, green, amber and yellow; brightness is 0-3 or off,
+// low, medium and full. LEDs set by set, fill and image stay lit after exit.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// errUsage reports invalid arguments; the usage text is printed instead of the error
+var errUsage = errors.New("usage")
+
+// command is a subcommand
+type command struct {
+	name  string
+	args  string
+	help  string
+	run   func(args []string) error
+	min   int  // Minimum number of arguments
+	max   int  // Maximum number of arguments, -1 for no limit
+	keep  bool // Leave the LEDs lit on exit
+	noDev bool // Does not open the device
+}
+
+var (
+	portName = flag.String("port", "", "open the MIDI ports whose names contain this instead of searching for a Launchpad")
+
+	commands = []command{
+		{name: "list", help: "list MIDI ports, marking Launchpads", run: runList, noDev: true},
+		{name: "info", help: "show the model and firmware", run: runInfo},
+		{name: "monitor", help: "print button events with timestamps", run: runMonitor},
+		{name: "test", args: "[low|medium|full]", help: "light all LEDs in test mode", run: runTest, max: 1, keep: true},
+		{name: "set", args: "x y color brightness", help: "set one LED (x=8 scene, y=-1 top)", run: runSet, min: 4, max: 4, keep: true},
+		{name: "fill", args: "color brightness", help: "set all 80 LEDs", run: runFill, min: 2, max: 2, keep: true},
+		{name: "text", args: "[-color c] [-brightness b] [-delay d] message", help: "scroll text across the grid", run: runText, min: 1, max: -1},
+		{name: "image", args: "file", help: "show an image scaled to the grid", run: runImage, min: 1, max: 1, keep: true},
+		{name: "layout", args: "file", help: "run a layout file, reloading it on change", run: runLayout, min: 1, max: 1},
+		{name: "script", args: "[-out port] file", help: "run a Lua script, reloading it on change", run: runScript, min: 1, max: 3},
+		{name: "reset", help: "reset the device", run: runReset},
+		{name: "send", args: "hex...", help: "send a raw MIDI message", run: runSend, min: 1, max: -1, keep: true},
+	}
+
+	// lp is the device opened for the running command
+	lp *launchpad.Launchpad
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		err := run(cmd, flag.Args()[1:])
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: golp %s %s\n", cmd.name, cmd.args)
+			os.Exit(2)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "golp %s: %v\n", cmd.name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "golp: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// run opens the device if needed and runs a command
+func run(cmd command, args []string) error {
+	if len(args) < cmd.min || (cmd.max >= 0 && len(args) > cmd.max) {
+		return errUsage
+	}
+
+	if cmd.noDev {
+		return cmd.run(args)
+	}
+
+	lp = launchpad.New()
+	if err := open(lp); err != nil {
+		return err
+	}
+	defer lp.Close()
+
+	lp.SetResetOnClose(!cmd.keep)
+	return cmd.run(args)
+}
+
+// open opens the Launchpad, or the ports selected with -port
+func open(lp *launchpad.Launchpad) error {
+	if *portName == "" {
+		return lp.Open()
+	}
+
+	in, err := midi.FindInPort(*portName)
+	if err != nil {
+		return fmt.Errorf("no MIDI input matching %q", *portName)
+	}
+	out, err := midi.FindOutPort(*portName)
+	if err != nil {
+		return fmt.Errorf("no MIDI output matching %q", *portName)
+	}
+	return lp.OpenPorts(in, out)
+}
+
+// usage prints the command list
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "usage: golp [-port name] <command> [arguments]\n\nCommands:\n")
+
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(cmd.name)+len(cmd.args)+1)
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, strings.TrimSpace(cmd.name+" "+cmd.args), cmd.help)
+	}
+	fmt.Fprintf(w, "\nFlags:\n")
+	flag.PrintDefaults()
+}
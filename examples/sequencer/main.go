@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/sequencer"
+)
+
+func main() {
+	// Create a new Launchpad instance
+	lp := launchpad.New()
+
+	// Open connection to the device
+	fmt.Println("Opening Launchpad...")
+	err := lp.Open()
+	if err != nil {
+		log.Fatalf("Failed to open Launchpad: %v", err)
+	}
+	defer lp.Close()
+
+	fmt.Println("Launchpad connected!")
+	fmt.Println("Pads toggle steps, scene buttons select tracks, top button 0 starts/stops")
+	fmt.Println("Press Ctrl+C to exit")
+
+	// Create a sequencer with a basic drum kit (General MIDI drum notes)
+	seq := sequencer.New(120)
+	seq.Clock().SetSwing(0.15)
+
+	drums := []struct {
+		name string
+		note uint8
+	}{
+		{"kick", 36},
+		{"snare", 38},
+		{"closed hat", 42},
+		{"open hat", 46},
+	}
+	for _, drum := range drums {
+		track, err := sequencer.NewTrack(drum.name, drum.note, 16)
+		if err != nil {
+			log.Fatalf("Failed to create track: %v", err)
+		}
+		seq.AddTrack(track)
+	}
+
+	// Start with a simple four-on-the-floor kick
+	kick := seq.Tracks()[0]
+	for step := 0; step < 16; step += 4 {
+		kick.SetStep(step, true)
+	}
+
+	// Print triggered steps
+	seq.OnStep(func(event sequencer.StepEvent) {
+		fmt.Printf("%-10s step %2d\n", event.Track.Name(), event.Step)
+	})
+
+	// Show the sequencer on the Launchpad
+	err = seq.Attach(lp)
+	if err != nil {
+		log.Fatalf("Failed to attach sequencer: %v", err)
+	}
+
+	err = seq.Start()
+	if err != nil {
+		log.Fatalf("Failed to start sequencer: %v", err)
+	}
+	defer seq.Stop()
+
+	// Set up signal handling for graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	// Wait for interrupt signal
+	<-sigChan
+	fmt.Println("\nReceived interrupt signal, cleaning up...")
+}
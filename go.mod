@@ -2,4 +2,7 @@ module github.com/inegm/golp
 
 go 1.24.4
 
-require gitlab.com/gomidi/midi/v2 v2.3.16
+require (
+	github.com/yuin/gopher-lua v1.1.2
+	gitlab.com/gomidi/midi/v2 v2.3.16
+)
@@ -0,0 +1,141 @@
+// Package playback is the timing core shared by the recording, show and macro players.
+//
+// A Control fires a list of timestamps in order, once or in a loop, scaled by
+// a speed, and can be stopped from another goroutine. Players keep their own
+// data and only tell the Control when each item is due and what to do when it is.
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStopped is returned by Play when playback was stopped
+var ErrStopped = errors.New("playback stopped")
+
+// Control schedules playback at an adjustable speed
+// The zero value plays at the original speed
+type Control struct {
+	mu    sync.Mutex
+	speed float64
+	stop  chan struct{}
+}
+
+// SetSpeed scales playback speed: 2 plays twice as fast, 0.5 at half speed
+// Values of 0 or less are ignored
+func (c *Control) SetSpeed(speed float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if speed > 0 {
+		c.speed = speed
+	}
+}
+
+// Scale returns a time at the current speed
+func (c *Control) Scale(t time.Duration) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return scale(t, c.speed)
+}
+
+// Play calls fire with the index of each time once it is due, and blocks until
+// every time has fired, fire returns an error or Stop is called
+// Times must be in order; the speed is fixed when Play starts
+func (c *Control) Play(times []time.Duration, fire func(i int) error) error {
+	return c.run(times, 0, fire)
+}
+
+// Loop plays times over and over, starting again every length, and blocks until
+// fire returns an error or Stop is called
+// Times must be in order and no later than length
+func (c *Control) Loop(times []time.Duration, length time.Duration, fire func(i int) error) error {
+	if length <= 0 {
+		return fmt.Errorf("invalid loop length: %v", length)
+	}
+	return c.run(times, length, fire)
+}
+
+// run plays times once, or every length if it is positive
+func (c *Control) run(times []time.Duration, length time.Duration, fire func(i int) error) error {
+	c.mu.Lock()
+	if c.stop != nil {
+		c.mu.Unlock()
+		return errors.New("already playing")
+	}
+	stop := make(chan struct{})
+	c.stop = stop
+	speed := c.speed
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		if c.stop == stop {
+			c.stop = nil
+		}
+		c.mu.Unlock()
+	}()
+
+	start := time.Now()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	// wait sleeps until a time after start, returning false if playback is stopped
+	wait := func(t time.Duration) bool {
+		if d := scale(t, speed) - time.Since(start); d > 0 {
+			timer.Reset(d)
+			select {
+			case <-timer.C:
+			case <-stop:
+				return false
+			}
+		}
+		select {
+		case <-stop:
+			return false
+		default:
+			return true
+		}
+	}
+
+	for {
+		for i, t := range times {
+			if !wait(t) {
+				return ErrStopped
+			}
+			if err := fire(i); err != nil {
+				return err
+			}
+		}
+		if length <= 0 {
+			return nil
+		}
+
+		// Each pass starts a whole length after the previous one, so slow
+		// fire calls do not make the loop drift
+		if !wait(length) {
+			return ErrStopped
+		}
+		start = start.Add(scale(length, speed))
+	}
+}
+
+// Stop ends playback early
+func (c *Control) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+// scale divides a time by a speed, treating 0 as the original speed
+func scale(t time.Duration, speed float64) time.Duration {
+	if speed <= 0 {
+		return t
+	}
+	return time.Duration(float64(t) / speed)
+}
@@ -0,0 +1,110 @@
+package playback
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlay(t *testing.T) {
+	var c Control
+	c.SetSpeed(2)
+
+	var fired []int
+	start := time.Now()
+	err := c.Play([]time.Duration{0, 20 * time.Millisecond, 40 * time.Millisecond}, func(i int) error {
+		fired = append(fired, i)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(fired, want) {
+		t.Fatalf("fired %v, want %v", fired, want)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("played 40ms at double speed in %v", elapsed)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	var c Control
+	errDone := errors.New("done")
+
+	var fired []int
+	start := time.Now()
+	err := c.Loop([]time.Duration{0, 5 * time.Millisecond}, 10*time.Millisecond, func(i int) error {
+		fired = append(fired, i)
+		if len(fired) == 6 {
+			return errDone
+		}
+		return nil
+	})
+	if err != errDone {
+		t.Fatalf("Loop returned %v, want the fire error", err)
+	}
+	if want := []int{0, 1, 0, 1, 0, 1}; !reflect.DeepEqual(fired, want) {
+		t.Fatalf("fired %v, want %v", fired, want)
+	}
+
+	// The third pass starts two lengths after the first
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Fatalf("three passes took %v", elapsed)
+	}
+}
+
+func TestLoopStop(t *testing.T) {
+	var c Control
+
+	done := make(chan error)
+	go func() {
+		done <- c.Loop(nil, time.Millisecond, func(int) error { return nil })
+	}()
+
+	// Stop has no effect until Loop starts
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.Stop()
+		select {
+		case err := <-done:
+			if err != ErrStopped {
+				t.Fatalf("Loop returned %v, want ErrStopped", err)
+			}
+			return
+		case <-time.After(5 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Loop did not stop")
+		}
+	}
+}
+
+func TestLoopInvalidLength(t *testing.T) {
+	var c Control
+	if err := c.Loop([]time.Duration{0}, 0, func(int) error { return nil }); err == nil {
+		t.Fatal("Loop accepted a zero length")
+	}
+}
+
+func TestAlreadyPlaying(t *testing.T) {
+	var c Control
+
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- c.Loop([]time.Duration{0}, time.Hour, func(int) error {
+			close(started)
+			return nil
+		})
+	}()
+	<-started
+
+	if err := c.Play(nil, func(int) error { return nil }); err == nil {
+		t.Fatal("Play started while looping")
+	}
+	c.Stop()
+	if err := <-done; err != ErrStopped {
+		t.Fatalf("Loop returned %v, want ErrStopped", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package watch polls files for changes, for the hot reloading of layouts and scripts.
+package watch
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// File checks a file at a fixed interval and calls changed whenever its
+// modification time or size changes, until stop is called
+// Changes are detected against the file as it was when File was called
+func File(path string, interval time.Duration, changed func()) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	last, _ := os.Stat(path)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil {
+					continue // Editors may briefly remove the file while saving
+				}
+				if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+					continue
+				}
+				last = info
+				changed()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
@@ -0,0 +1,306 @@
+// Package bridge forwards Launchpad button presses to a MIDI output.
+//
+// Grid pads play notes through a configurable Layout (chromatic, in-key scale or
+// drum rack), scene buttons send program changes and top row buttons send control
+// changes. Pads light up while their note is sounding:
+//
+//	b, err := bridge.OpenVirtual(lp, "golp")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer b.Close()
+//
+//	b.SetLayout(bridge.ScaleLayout{Root: 48, Scale: music.NaturalMinor, RowOffset: 3})
+//	b.Start()
+package bridge
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// DefaultTopController is the controller number sent by the first top row button
+// Controllers 20-27 are undefined in the MIDI specification
+const DefaultTopController = 20
+
+// Colors used for LED feedback
+var (
+	colorPlayable = launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessLow)
+	colorSounding = launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull)
+	colorProgram  = launchpad.NewLEDState(launchpad.ColorAmber, launchpad.BrightnessFull)
+	colorHeld     = launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessFull)
+)
+
+// virtualOutOpener is implemented by drivers that can create virtual output ports
+type virtualOutOpener interface {
+	OpenVirtualOut(name string) (drivers.Out, error)
+}
+
+// Bridge translates button events into MIDI messages on an output port
+type Bridge struct {
+	mu            sync.Mutex
+	lp            *launchpad.Launchpad
+	out           drivers.Out
+	ownsOut       bool // True if Close should close the output port
+	layout        Layout
+	channel       uint8
+	velocity      uint8
+	topController uint8
+	running       bool
+	registered    bool // True once the button handler has been registered
+
+	sounding map[uint8]int              // Number of held pads per sounding note
+	held     map[launchpad.Button]uint8 // Note started by each held pad
+	program  int                        // Last program sent, -1 if none
+	topHeld  [launchpad.TopButtons]bool
+
+	screen *launchpad.FrameWriter // Draws the layout on the Launchpad
+}
+
+// New creates a bridge sending to an already open MIDI output
+// The output is not closed by Close
+func New(lp *launchpad.Launchpad, out drivers.Out) *Bridge {
+	return &Bridge{
+		lp:            lp,
+		out:           out,
+		layout:        ChromaticLayout{Root: 36, RowOffset: 5},
+		velocity:      100,
+		topController: DefaultTopController,
+		sounding:      make(map[uint8]int),
+		held:          make(map[launchpad.Button]uint8),
+		program:       -1,
+		screen:        launchpad.NewFrameWriter(lp),
+	}
+}
+
+// OpenVirtual creates a bridge on a new virtual MIDI output port with the given name
+// If the MIDI driver does not support virtual ports, the first existing output port
+// whose name contains name is used instead. The port is closed by Close.
+func OpenVirtual(lp *launchpad.Launchpad, name string) (*Bridge, error) {
+	var out drivers.Out
+	var err error
+
+	if opener, ok := drivers.Get().(virtualOutOpener); ok {
+		out, err = opener.OpenVirtualOut(name)
+	} else {
+		out, err = midi.FindOutPort(name)
+		if err == nil {
+			err = out.Open()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MIDI output %q: %w", name, err)
+	}
+
+	b := New(lp, out)
+	b.ownsOut = true
+	return b, nil
+}
+
+// SetLayout sets how grid pads map to notes
+// Sounding notes are stopped first so none are left hanging
+func (b *Bridge) SetLayout(layout Layout) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.allNotesOffLocked()
+	b.layout = layout
+	return b.drawLocked()
+}
+
+// SetChannel sets the MIDI channel (0-15) notes and controllers are sent on
+func (b *Bridge) SetChannel(channel uint8) error {
+	if channel > 15 {
+		return fmt.Errorf("invalid MIDI channel: %d", channel)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.allNotesOffLocked()
+	b.channel = channel
+	return nil
+}
+
+// SetVelocity sets the note-on velocity (1-127)
+func (b *Bridge) SetVelocity(velocity uint8) error {
+	if velocity < 1 || velocity > 127 {
+		return fmt.Errorf("invalid velocity: %d", velocity)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.velocity = velocity
+	return nil
+}
+
+// SetTopController sets the controller number of the first top row button
+// The other buttons use the following controller numbers
+func (b *Bridge) SetTopController(controller uint8) error {
+	if int(controller)+launchpad.TopButtons > 128 {
+		return fmt.Errorf("invalid controller: %d", controller)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topController = controller
+	return nil
+}
+
+// Start begins forwarding button events and draws the layout
+func (b *Bridge) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running {
+		return nil
+	}
+	if !b.registered {
+		b.lp.OnButton(b.handleButton)
+		b.registered = true
+	}
+	b.running = true
+	b.screen.Invalidate()
+	return b.drawLocked()
+}
+
+// Stop stops forwarding button events and releases sounding notes
+func (b *Bridge) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.allNotesOffLocked()
+	b.running = false
+}
+
+// Close stops the bridge and closes the output port if it was opened by OpenVirtual
+func (b *Bridge) Close() error {
+	b.Stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ownsOut {
+		b.ownsOut = false
+		return b.out.Close()
+	}
+	return nil
+}
+
+// Sounding returns the notes currently held down
+func (b *Bridge) Sounding() []uint8 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	notes := make([]uint8, 0, len(b.sounding))
+	for note := range b.sounding {
+		notes = append(notes, note)
+	}
+	return notes
+}
+
+// handleButton translates a button event into MIDI
+func (b *Bridge) handleButton(event launchpad.ButtonEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.running {
+		return
+	}
+
+	btn := event.Button
+	switch {
+	case btn.IsScene:
+		if event.Pressed {
+			b.out.Send(midi.ProgramChange(b.channel, uint8(btn.Y)))
+			b.program = btn.Y
+		}
+
+	case btn.IsTop:
+		value := uint8(0)
+		if event.Pressed {
+			value = 127
+		}
+		b.out.Send(midi.ControlChange(b.channel, b.topController+uint8(btn.X), value))
+		b.topHeld[btn.X] = event.Pressed
+
+	default:
+		if event.Pressed {
+			b.noteOnLocked(btn)
+		} else {
+			b.noteOffLocked(btn)
+		}
+	}
+
+	b.drawLocked()
+}
+
+// noteOnLocked starts the note of a pad (caller must hold the lock)
+func (b *Bridge) noteOnLocked(btn launchpad.Button) {
+	note, ok := b.layout.Note(btn)
+	if !ok {
+		return
+	}
+	if _, held := b.held[btn]; held {
+		return
+	}
+	b.held[btn] = note
+
+	// Several pads can share a note; only the first press sounds it
+	b.sounding[note]++
+	if b.sounding[note] == 1 {
+		b.out.Send(midi.NoteOn(b.channel, note, b.velocity))
+	}
+}
+
+// noteOffLocked stops the note started by a pad (caller must hold the lock)
+func (b *Bridge) noteOffLocked(btn launchpad.Button) {
+	note, ok := b.held[btn]
+	if !ok {
+		return
+	}
+	delete(b.held, btn)
+
+	b.sounding[note]--
+	if b.sounding[note] <= 0 {
+		delete(b.sounding, note)
+		b.out.Send(midi.NoteOff(b.channel, note))
+	}
+}
+
+// allNotesOffLocked stops every sounding note (caller must hold the lock)
+func (b *Bridge) allNotesOffLocked() {
+	for note := range b.sounding {
+		b.out.Send(midi.NoteOff(b.channel, note))
+	}
+	b.sounding = make(map[uint8]int)
+	b.held = make(map[launchpad.Button]uint8)
+}
+
+// drawLocked shows playable pads, sounding notes, the last program and held
+// top buttons (caller must hold the lock)
+func (b *Bridge) drawLocked() error {
+	if !b.running {
+		return nil
+	}
+
+	var frame launchpad.Frame
+	for y := 0; y < launchpad.GridHeight; y++ {
+		for x := 0; x < launchpad.GridWidth; x++ {
+			btn := launchpad.NewGridButton(x, y)
+			note, ok := b.layout.Note(btn)
+			switch {
+			case !ok:
+			case b.sounding[note] > 0:
+				frame.Set(btn, colorSounding)
+			default:
+				frame.Set(btn, colorPlayable)
+			}
+		}
+	}
+	if b.program >= 0 {
+		frame.Set(launchpad.NewSceneButton(b.program), colorProgram)
+	}
+	for x, held := range b.topHeld {
+		if held {
+			frame.Set(launchpad.NewTopButton(x), colorHeld)
+		}
+	}
+	return b.screen.Draw(&frame)
+}
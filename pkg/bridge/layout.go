@@ -0,0 +1,71 @@
+package bridge
+
+import (
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/music"
+)
+
+// Layout maps grid buttons to MIDI notes
+type Layout interface {
+	// Note returns the MIDI note played by a button, or false if the button plays nothing
+	Note(btn launchpad.Button) (note uint8, ok bool)
+}
+
+// ChromaticLayout lays out consecutive semitones left to right, starting
+// at Root in the bottom-left pad, with each row RowOffset semitones above the one below
+type ChromaticLayout struct {
+	Root      int // MIDI note of the bottom-left pad
+	RowOffset int // Semitones between rows (5 for fourths)
+}
+
+// Note returns the note of a grid pad
+func (l ChromaticLayout) Note(btn launchpad.Button) (uint8, bool) {
+	if btn.IsScene || btn.IsTop || !btn.Valid() {
+		return 0, false
+	}
+	row := launchpad.GridHeight - 1 - btn.Y
+	return checkNote(l.Root + btn.X + (row * l.RowOffset))
+}
+
+// ScaleLayout lays out consecutive scale degrees left to right, starting
+// at Root in the bottom-left pad, with each row RowOffset degrees above the one below
+// Only notes in the scale can be played
+type ScaleLayout struct {
+	Root      int         // MIDI note of the bottom-left pad
+	Scale     music.Scale // Scale the pads follow
+	RowOffset int         // Scale degrees between rows (3 for fourths in a 7-note scale)
+}
+
+// Note returns the note of a grid pad
+func (l ScaleLayout) Note(btn launchpad.Button) (uint8, bool) {
+	if btn.IsScene || btn.IsTop || !btn.Valid() {
+		return 0, false
+	}
+	row := launchpad.GridHeight - 1 - btn.Y
+	return checkNote(l.Scale.Note(l.Root, btn.X+(row*l.RowOffset)))
+}
+
+// DrumRackLayout matches the Launchpad's drum rack mapping (MappingDrum):
+// the left and right halves of the grid each hold four notes per row,
+// rising from the bottom, from note 36 and note 68 respectively
+type DrumRackLayout struct{}
+
+// Note returns the note of a grid pad
+func (DrumRackLayout) Note(btn launchpad.Button) (uint8, bool) {
+	if btn.IsScene || btn.IsTop || !btn.Valid() {
+		return 0, false
+	}
+	row := launchpad.GridHeight - 1 - btn.Y
+	if btn.X < 4 {
+		return checkNote(36 + (4 * row) + btn.X)
+	}
+	return checkNote(68 + (4 * row) + (btn.X - 4))
+}
+
+// checkNote converts a note to a byte if it is in the MIDI range
+func checkNote(note int) (uint8, bool) {
+	if !music.ValidNote(note) {
+		return 0, false
+	}
+	return uint8(note), true
+}
@@ -0,0 +1,152 @@
+// Package feedback reflects MIDI sent by a DAW or other software on the Launchpad LEDs.
+//
+// A Mapper listens on any MIDI input port and translates incoming notes and
+// control changes into LED updates following a declarative table of rules,
+// so clip or note state can be shown without the sender speaking the Launchpad protocol:
+//
+//	rules := feedback.GridRules(feedback.SourceNote, 0, 36, feedback.LevelColors)
+//	m := feedback.New(lp, rules)
+//	err := m.ListenTo("IAC Driver Bus 1")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer m.Stop()
+package feedback
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// Mapper updates LEDs from incoming MIDI messages
+type Mapper struct {
+	mu         sync.Mutex
+	lp         *launchpad.Launchpad
+	rules      []Rule
+	in         drivers.In
+	ownsIn     bool // True if Stop should close the input port
+	stopListen func()
+}
+
+// New creates a mapper using a table of rules
+// When several rules match a message, each of them is applied
+func New(lp *launchpad.Launchpad, rules []Rule) *Mapper {
+	return &Mapper{
+		lp:    lp,
+		rules: rules,
+	}
+}
+
+// SetRules replaces the mapping table
+func (m *Mapper) SetRules(rules []Rule) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = rules
+}
+
+// ListenTo opens the first MIDI input port whose name contains name and listens to it
+// The port is closed by Stop
+func (m *Mapper) ListenTo(name string) error {
+	in, err := midi.FindInPort(name)
+	if err != nil {
+		return fmt.Errorf("failed to find MIDI input %q: %w", name, err)
+	}
+	err = in.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open MIDI input %q: %w", name, err)
+	}
+
+	err = m.Listen(in)
+	if err != nil {
+		in.Close()
+		return err
+	}
+
+	m.mu.Lock()
+	m.ownsIn = true
+	m.mu.Unlock()
+	return nil
+}
+
+// Listen listens to an open MIDI input port
+// The port is left open by Stop
+func (m *Mapper) Listen(in drivers.In) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopListen != nil {
+		return fmt.Errorf("mapper already listening")
+	}
+
+	stop, err := midi.ListenTo(in, func(msg midi.Message, timestampms int32) {
+		m.Handle(msg)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start MIDI listener: %w", err)
+	}
+
+	m.in = in
+	m.stopListen = stop
+	return nil
+}
+
+// Stop stops listening and closes the input port if it was opened by ListenTo
+func (m *Mapper) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopListen == nil {
+		return nil
+	}
+	m.stopListen()
+	m.stopListen = nil
+
+	var err error
+	if m.ownsIn {
+		err = m.in.Close()
+		m.ownsIn = false
+	}
+	m.in = nil
+	return err
+}
+
+// Handle applies the rules to a raw MIDI message
+// It is called for every message received by Listen, and can also be fed directly
+func (m *Mapper) Handle(msg []byte) error {
+	var channel, number, value uint8
+	var source Source
+
+	message := midi.Message(msg)
+	switch {
+	case message.GetNoteOn(&channel, &number, &value):
+		source = SourceNote
+	case message.GetNoteOff(&channel, &number, &value):
+		source = SourceNote
+		value = 0
+	case message.GetControlChange(&channel, &number, &value):
+		source = SourceControl
+	default:
+		return nil // Not a mapped message type
+	}
+
+	m.mu.Lock()
+	var updates []Rule
+	for _, rule := range m.rules {
+		if rule.matches(source, channel, number) {
+			updates = append(updates, rule)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, rule := range updates {
+		err := m.lp.SetButtonLEDState(rule.Button, rule.state(value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
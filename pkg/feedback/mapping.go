@@ -0,0 +1,120 @@
+package feedback
+
+import (
+	"fmt"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Source is the kind of incoming MIDI message a Rule matches
+type Source int
+
+const (
+	SourceNote    Source = iota // Note on and note off messages
+	SourceControl               // Control change messages
+)
+
+// String returns the string representation of a Source
+func (s Source) String() string {
+	switch s {
+	case SourceNote:
+		return "Note"
+	case SourceControl:
+		return "Control"
+	default:
+		return fmt.Sprintf("Source(%d)", s)
+	}
+}
+
+// AnyChannel makes a Rule match messages on every MIDI channel
+const AnyChannel = -1
+
+// ColorStep sets the LED state for values from Min up to the next step's Min
+type ColorStep struct {
+	Min   uint8              // Smallest value using this state
+	State launchpad.LEDState // LED state shown
+}
+
+// ColorMap translates a velocity or controller value into an LED state
+// Steps must be sorted by ascending Min; values below the first step turn the LED off
+type ColorMap []ColorStep
+
+// State returns the LED state for a value
+func (c ColorMap) State(value uint8) launchpad.LEDState {
+	state := launchpad.LEDState{}
+	for _, step := range c {
+		if value < step.Min {
+			break
+		}
+		state = step.State
+	}
+	return state
+}
+
+// DefaultColors shows any non-zero value as full green
+var DefaultColors = ColorMap{
+	{Min: 1, State: launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull)},
+}
+
+// LevelColors shows a value as a green, amber then red level meter
+var LevelColors = ColorMap{
+	{Min: 1, State: launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessLow)},
+	{Min: 32, State: launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull)},
+	{Min: 64, State: launchpad.NewLEDState(launchpad.ColorAmber, launchpad.BrightnessFull)},
+	{Min: 96, State: launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessFull)},
+}
+
+// Rule maps one incoming note or controller to a button LED
+type Rule struct {
+	Source  Source           // Message kind matched
+	Channel int              // MIDI channel (0-15) or AnyChannel
+	Number  uint8            // Note or controller number
+	Button  launchpad.Button // LED updated by the message
+	Colors  ColorMap         // Value to LED state translation; nil uses DefaultColors
+}
+
+// matches returns true if the rule applies to a message
+func (r Rule) matches(source Source, channel, number uint8) bool {
+	return r.Source == source &&
+		r.Number == number &&
+		(r.Channel == AnyChannel || r.Channel == int(channel))
+}
+
+// state returns the LED state for a message value
+func (r Rule) state(value uint8) launchpad.LEDState {
+	if r.Colors == nil {
+		return DefaultColors.State(value)
+	}
+	return r.Colors.State(value)
+}
+
+// GridRules maps 64 consecutive numbers starting at first to the grid,
+// left-to-right and top-to-bottom
+func GridRules(source Source, channel int, first uint8, colors ColorMap) []Rule {
+	var rules []Rule
+	for i := 0; i < launchpad.GridWidth*launchpad.GridHeight && int(first)+i < 128; i++ {
+		rules = append(rules, Rule{
+			Source:  source,
+			Channel: channel,
+			Number:  first + uint8(i),
+			Button:  launchpad.NewGridButton(i%launchpad.GridWidth, i/launchpad.GridWidth),
+			Colors:  colors,
+		})
+	}
+	return rules
+}
+
+// SceneRules maps 8 consecutive numbers starting at first to the scene buttons, top to bottom
+func SceneRules(source Source, channel int, first uint8, colors ColorMap) []Rule {
+	var rules []Rule
+	for y := 0; y < launchpad.SceneButtons && int(first)+y < 128; y++ {
+		rules = append(rules, Rule{
+			Source:  source,
+			Channel: channel,
+			Number:  first + uint8(y),
+			Button:  launchpad.NewSceneButton(y),
+			Colors:  colors,
+		})
+	}
+	return rules
+}
@@ -0,0 +1,611 @@
+// Package httpapi serves a Launchpad over HTTP with a live browser mirror.
+//
+// REST endpoints set LEDs, frames and the mapping mode, a WebSocket streams
+// button events and frame changes, and the embedded page at / renders all 80
+// LEDs and turns clicks on the virtual pads into injected presses:
+//
+//	srv := httpapi.NewServer(lp)
+//	err := srv.Listen("127.0.0.1:8080")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer srv.Close()
+//
+// Endpoints:
+//
+//	GET  /              browser mirror
+//	GET  /api/frame     current LEDs as {"leds":[{"x":0,"y":0,"red":3,"green":0,"flash":false}, ...]}
+//	PUT  /api/frame     replace all LEDs (same body; unlisted LEDs are turned off)
+//	POST /api/led       set one LED: {"x":0,"y":0,"red":3,"green":0,"flash":false}
+//	POST /api/clear     turn all LEDs off
+//	POST /api/reset     reset the device
+//	GET  /api/mapping   {"mode":"xy"} or {"mode":"drum"}
+//	PUT  /api/mapping   set the mapping mode
+//	POST /api/press     inject a press: {"x":0,"y":0,"pressed":true}
+//	GET  /ws            WebSocket stream
+//
+// Coordinates follow Button.X and Button.Y (x=8 for scene buttons, y=-1 for
+// top buttons). The WebSocket sends {"type":"frame","leds":[...]} when the LEDs
+// change and {"type":"button","x":0,"y":0,"pressed":true} for every event, and
+// accepts {"type":"press",...} messages from the client.
+//
+// Requests that change the device must be sent as application/json, even
+// /api/clear and /api/reset, which take no body. Browsers let any page send
+// form and plain text POSTs to another site, but not JSON, so other pages the
+// performer has open cannot drive the device. For the same reason the
+// WebSocket only accepts browsers whose Origin matches the server's host, or
+// an origin allowed with AllowOrigins.
+package httpapi
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// DefaultPollInterval is how often the LEDs are checked for changes to stream
+const DefaultPollInterval = 50 * time.Millisecond
+
+// clientQueueSize is how many messages a WebSocket client may fall behind
+// before it is dropped
+const clientQueueSize = 64
+
+// Device is the part of the Launchpad API the server uses
+// *launchpad.Launchpad implements it; a fake can be used to test without hardware
+type Device interface {
+	CurrentFrame() launchpad.Frame
+	SetButtonLEDState(btn launchpad.Button, state launchpad.LEDState) error
+	SetFrame(frame *launchpad.Frame) error
+	Clear() error
+	Reset() error
+	SetMappingMode(mode launchpad.MappingMode) error
+	GetMappingMode() launchpad.MappingMode
+	OnButton(handler launchpad.ButtonHandler)
+	InjectButtonEvent(event launchpad.ButtonEvent)
+}
+
+// LED is the JSON representation of one LED
+type LED struct {
+	X     int  `json:"x"`
+	Y     int  `json:"y"`
+	Red   int  `json:"red"`
+	Green int  `json:"green"`
+	Flash bool `json:"flash"`
+}
+
+// Press is the JSON representation of a button press or release
+type Press struct {
+	X       int  `json:"x"`
+	Y       int  `json:"y"`
+	Pressed bool `json:"pressed"`
+}
+
+// frameJSON is the body of the frame endpoints and frame stream messages
+type frameJSON struct {
+	Type string `json:"type,omitempty"`
+	LEDs []LED  `json:"leds"`
+}
+
+// mappingJSON is the body of the mapping endpoints
+type mappingJSON struct {
+	Mode string `json:"mode"`
+}
+
+// clientMessage is a message received over the WebSocket
+type clientMessage struct {
+	Type string `json:"type"`
+	Press
+}
+
+// buttonMessage is a button event sent over the WebSocket
+type buttonMessage struct {
+	Type string `json:"type"`
+	Press
+}
+
+// Server exposes a Launchpad over HTTP and WebSocket
+type Server struct {
+	dev      Device
+	mux      *http.ServeMux
+	interval time.Duration
+
+	mu       sync.Mutex
+	clients  map[*client]struct{}
+	origins  []string
+	last     launchpad.Frame
+	watching bool
+	stop     chan struct{}
+	http     *http.Server
+	listener net.Listener
+}
+
+// NewServer creates a server for a device
+// The server is an http.Handler, so it can also be mounted on an existing mux
+func NewServer(dev Device) *Server {
+	s := &Server{
+		dev:      dev,
+		mux:      http.NewServeMux(),
+		interval: DefaultPollInterval,
+		clients:  make(map[*client]struct{}),
+		stop:     make(chan struct{}),
+	}
+
+	static, _ := fs.Sub(staticFiles, "static")
+	s.mux.Handle("GET /", http.FileServerFS(static))
+	s.mux.HandleFunc("GET /api/frame", s.getFrame)
+	s.mux.HandleFunc("PUT /api/frame", jsonOnly(s.putFrame))
+	s.mux.HandleFunc("POST /api/led", jsonOnly(s.postLED))
+	s.mux.HandleFunc("POST /api/clear", jsonOnly(s.postClear))
+	s.mux.HandleFunc("POST /api/reset", jsonOnly(s.postReset))
+	s.mux.HandleFunc("GET /api/mapping", s.getMapping)
+	s.mux.HandleFunc("PUT /api/mapping", jsonOnly(s.putMapping))
+	s.mux.HandleFunc("POST /api/press", jsonOnly(s.postPress))
+	s.mux.HandleFunc("GET /ws", s.serveWebSocket)
+
+	dev.OnButton(s.handleButton)
+	return s
+}
+
+// SetPollInterval sets how often the LEDs are checked for changes
+// Takes effect when the first WebSocket client connects
+func (s *Server) SetPollInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if interval > 0 {
+		s.interval = interval
+	}
+}
+
+// AllowOrigins lets browser pages from other origins, such as
+// "http://localhost:3000", connect to the WebSocket
+// Pages served by this server and clients that send no Origin are always allowed
+func (s *Server) AllowOrigins(origins ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.origins = append(s.origins, origins...)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Listen starts serving on a TCP address such as "127.0.0.1:8080"
+// Use port 0 to pick a free port and read it back with Addr
+func (s *Server) Listen(addr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.http != nil {
+		return errors.New("HTTP server already listening")
+	}
+	select {
+	case <-s.stop:
+		return errors.New("HTTP server closed")
+	default:
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s.listener = listener
+	s.http = &http.Server{Handler: s}
+	go s.http.Serve(listener)
+	return nil
+}
+
+// Addr returns the address the server is listening on, or nil
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close stops the server and disconnects all WebSocket clients
+// A closed server cannot be restarted
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+
+	for c := range s.clients {
+		c.close()
+		delete(s.clients, c)
+	}
+
+	if s.http == nil {
+		return nil
+	}
+	err := s.http.Close()
+	s.http = nil
+	s.listener = nil
+	return err
+}
+
+// getFrame handles GET /api/frame
+func (s *Server) getFrame(w http.ResponseWriter, r *http.Request) {
+	frame := s.dev.CurrentFrame()
+	writeJSON(w, frameJSON{LEDs: encodeFrame(&frame)})
+}
+
+// putFrame handles PUT /api/frame
+func (s *Server) putFrame(w http.ResponseWriter, r *http.Request) {
+	var body frameJSON
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	var frame launchpad.Frame
+	for _, led := range body.LEDs {
+		btn, state, err := decodeLED(led)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		frame.Set(btn, state)
+	}
+
+	s.respond(w, s.dev.SetFrame(&frame))
+}
+
+// postLED handles POST /api/led
+func (s *Server) postLED(w http.ResponseWriter, r *http.Request) {
+	var led LED
+	if !readJSON(w, r, &led) {
+		return
+	}
+
+	btn, state, err := decodeLED(led)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.respond(w, s.dev.SetButtonLEDState(btn, state))
+}
+
+// postClear handles POST /api/clear
+func (s *Server) postClear(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, s.dev.Clear())
+}
+
+// postReset handles POST /api/reset
+func (s *Server) postReset(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, s.dev.Reset())
+}
+
+// getMapping handles GET /api/mapping
+func (s *Server) getMapping(w http.ResponseWriter, r *http.Request) {
+	mode := "xy"
+	if s.dev.GetMappingMode() == launchpad.MappingDrum {
+		mode = "drum"
+	}
+	writeJSON(w, mappingJSON{Mode: mode})
+}
+
+// putMapping handles PUT /api/mapping
+func (s *Server) putMapping(w http.ResponseWriter, r *http.Request) {
+	var body mappingJSON
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	var mode launchpad.MappingMode
+	switch strings.ToLower(body.Mode) {
+	case "xy", "x-y":
+		mode = launchpad.MappingXY
+	case "drum":
+		mode = launchpad.MappingDrum
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid mapping mode: %q", body.Mode))
+		return
+	}
+
+	s.respond(w, s.dev.SetMappingMode(mode))
+}
+
+// postPress handles POST /api/press
+func (s *Server) postPress(w http.ResponseWriter, r *http.Request) {
+	var press Press
+	if !readJSON(w, r, &press) {
+		return
+	}
+
+	if err := s.inject(press); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveWebSocket handles GET /ws
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.originAllowed(r) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("origin not allowed: %s", r.Header.Get("Origin")))
+		return
+	}
+
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	// Every client starts with the full frame
+	c := newClient(conn)
+	frame := s.dev.CurrentFrame()
+	data, _ := json.Marshal(frameJSON{Type: "frame", LEDs: encodeFrame(&frame)})
+	c.queue(data)
+	go c.writeLoop()
+
+	s.mu.Lock()
+	s.clients[c] = struct{}{}
+	if !s.watching {
+		s.watching = true
+		s.last = frame
+		go s.watch(s.interval)
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+		c.close()
+	}()
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg clientMessage
+		if json.Unmarshal(data, &msg) != nil || msg.Type != "press" {
+			continue // Ignore anything the mirror does not understand
+		}
+		s.inject(msg.Press)
+	}
+}
+
+// watch broadcasts the frame whenever it changes
+func (s *Server) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		frame := s.dev.CurrentFrame()
+		s.mu.Lock()
+		changed := frame != s.last
+		s.last = frame
+		s.mu.Unlock()
+
+		if changed {
+			s.broadcast(frameJSON{Type: "frame", LEDs: encodeFrame(&frame)})
+		}
+	}
+}
+
+// handleButton streams a button event to WebSocket clients
+func (s *Server) handleButton(event launchpad.ButtonEvent) {
+	s.broadcast(buttonMessage{
+		Type: "button",
+		Press: Press{
+			X:       event.Button.X,
+			Y:       event.Button.Y,
+			Pressed: event.Pressed,
+		},
+	})
+}
+
+// broadcast queues a JSON message for every WebSocket client
+// It never writes to sockets, so it is safe on the MIDI input goroutine
+func (s *Server) broadcast(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		c.queue(data)
+	}
+}
+
+// originAllowed reports whether a WebSocket request may connect
+// Browsers always send Origin, which must match the host or an allowed origin
+func (s *Server) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true // Not a browser
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, allowed := range s.origins {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// client is a WebSocket connection with its own writer goroutine, so a slow
+// browser holds up neither button events nor the other clients
+type client struct {
+	conn *wsConn
+	send chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func newClient(conn *wsConn) *client {
+	return &client{
+		conn: conn,
+		send: make(chan []byte, clientQueueSize),
+		done: make(chan struct{}),
+	}
+}
+
+// queue adds a message to the client's queue, dropping the client if it has
+// fallen too far behind
+func (c *client) queue(data []byte) {
+	select {
+	case c.send <- data:
+	default:
+		c.close()
+	}
+}
+
+// writeLoop writes queued messages until the client is closed
+func (c *client) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.send:
+			if err := c.conn.WriteMessage(data); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// close disconnects the client; the read loop then unregisters it
+func (c *client) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// inject delivers a press from the API as a device event
+func (s *Server) inject(press Press) error {
+	btn := launchpad.ButtonAt(press.X, press.Y)
+	if !btn.Valid() {
+		return &launchpad.InvalidButtonError{Button: btn}
+	}
+	s.dev.InjectButtonEvent(launchpad.ButtonEvent{Button: btn, Pressed: press.Pressed})
+	return nil
+}
+
+// respond writes 204 on success or the device error
+// Invalid arguments are the client's fault; other failures mean the device is unavailable
+func (s *Server) respond(w http.ResponseWriter, err error) {
+	var badButton *launchpad.InvalidButtonError
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.As(err, &badButton), errors.Is(err, launchpad.ErrInvalid):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, launchpad.ErrUnsupported):
+		writeError(w, http.StatusNotImplemented, err)
+	default:
+		writeError(w, http.StatusServiceUnavailable, err)
+	}
+}
+
+// encodeFrame converts a frame to JSON LEDs in frame order
+func encodeFrame(frame *launchpad.Frame) []LED {
+	leds := make([]LED, launchpad.FrameSize)
+	for i, state := range frame {
+		btn := launchpad.FrameButton(i)
+		leds[i] = LED{
+			X:     btn.X,
+			Y:     btn.Y,
+			Red:   int(state.Red),
+			Green: int(state.Green),
+			Flash: state.Flash,
+		}
+	}
+	return leds
+}
+
+// decodeLED converts a JSON LED to a button and LED state
+func decodeLED(led LED) (launchpad.Button, launchpad.LEDState, error) {
+	btn := launchpad.ButtonAt(led.X, led.Y)
+	if !btn.Valid() {
+		return btn, launchpad.LEDState{}, &launchpad.InvalidButtonError{Button: btn}
+	}
+
+	state := launchpad.LEDState{
+		Red:   launchpad.Brightness(led.Red),
+		Green: launchpad.Brightness(led.Green),
+		Flash: led.Flash,
+	}
+	if !state.Red.Valid() || !state.Green.Valid() {
+		return btn, state, fmt.Errorf("brightness out of range: red=%d green=%d", led.Red, led.Green)
+	}
+	return btn, state, nil
+}
+
+// jsonOnly rejects requests whose body is not declared as application/json
+func jsonOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("content type must be application/json"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// readJSON decodes a request body, writing a 400 response on failure
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return false
+	}
+	return true
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,214 @@
+package httpapi
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// fakeDevice records LED writes and injected presses
+type fakeDevice struct {
+	mu       sync.Mutex
+	frame    launchpad.Frame
+	mapping  launchpad.MappingMode
+	handlers []launchpad.ButtonHandler
+	injected []launchpad.ButtonEvent
+}
+
+func (d *fakeDevice) CurrentFrame() launchpad.Frame {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.frame
+}
+
+func (d *fakeDevice) SetButtonLEDState(btn launchpad.Button, state launchpad.LEDState) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.frame.Set(btn, state)
+	return nil
+}
+
+func (d *fakeDevice) SetFrame(frame *launchpad.Frame) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.frame = *frame
+	return nil
+}
+
+func (d *fakeDevice) Clear() error { return d.SetFrame(&launchpad.Frame{}) }
+func (d *fakeDevice) Reset() error { return d.SetFrame(&launchpad.Frame{}) }
+
+func (d *fakeDevice) SetMappingMode(mode launchpad.MappingMode) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mapping = mode
+	return nil
+}
+
+func (d *fakeDevice) GetMappingMode() launchpad.MappingMode {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.mapping
+}
+
+func (d *fakeDevice) OnButton(handler launchpad.ButtonHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers = append(d.handlers, handler)
+}
+
+func (d *fakeDevice) InjectButtonEvent(event launchpad.ButtonEvent) {
+	d.mu.Lock()
+	d.injected = append(d.injected, event)
+	handlers := append([]launchpad.ButtonHandler(nil), d.handlers...)
+	d.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+func TestStateChangesRequireJSON(t *testing.T) {
+	tests := []struct {
+		method, path, contentType, body string
+		want                            int
+	}{
+		{"POST", "/api/led", "application/json", `{"x":3,"y":4,"red":3}`, http.StatusNoContent},
+		{"POST", "/api/led", "application/json; charset=utf-8", `{"x":3,"y":4,"red":3}`, http.StatusNoContent},
+		{"POST", "/api/led", "text/plain", `{"x":3,"y":4,"red":3}`, http.StatusUnsupportedMediaType},
+		{"POST", "/api/led", "", `{"x":3,"y":4,"red":3}`, http.StatusUnsupportedMediaType},
+		{"POST", "/api/press", "text/plain", `{"x":0,"y":0,"pressed":true}`, http.StatusUnsupportedMediaType},
+		{"POST", "/api/reset", "application/x-www-form-urlencoded", "", http.StatusUnsupportedMediaType},
+		{"POST", "/api/reset", "application/json", "", http.StatusNoContent},
+		{"POST", "/api/clear", "text/plain", "", http.StatusUnsupportedMediaType},
+		{"PUT", "/api/frame", "text/plain", `{"leds":[]}`, http.StatusUnsupportedMediaType},
+		{"PUT", "/api/mapping", "text/plain", `{"mode":"drum"}`, http.StatusUnsupportedMediaType},
+		{"GET", "/api/frame", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path+" "+tt.contentType, func(t *testing.T) {
+			dev := &fakeDevice{}
+			srv := NewServer(dev)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusUnsupportedMediaType && len(dev.injected) > 0 {
+				t.Fatalf("rejected request still injected %v", dev.injected)
+			}
+		})
+	}
+}
+
+// dial opens a WebSocket to the test server and returns the handshake status
+func dial(t *testing.T, ts *httptest.Server, origin string) (net.Conn, int) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	fmt.Fprintf(conn, "GET /ws HTTP/1.1\r\nHost: %s\r\n", ts.Listener.Addr())
+	fmt.Fprintf(conn, "Connection: Upgrade\r\nUpgrade: websocket\r\n")
+	fmt.Fprintf(conn, "Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n")
+	if origin != "" {
+		fmt.Fprintf(conn, "Origin: %s\r\n", origin)
+	}
+	fmt.Fprintf(conn, "\r\n")
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	return conn, resp.StatusCode
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	srv := NewServer(&fakeDevice{})
+	srv.AllowOrigins("http://localhost:3000")
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	defer srv.Close()
+
+	tests := []struct {
+		name, origin string
+		want         int
+	}{
+		{"no origin", "", http.StatusSwitchingProtocols},
+		{"same host", "http://" + ts.Listener.Addr().String(), http.StatusSwitchingProtocols},
+		{"allowed", "http://localhost:3000", http.StatusSwitchingProtocols},
+		{"other site", "https://example.com", http.StatusForbidden},
+		{"other port", "http://localhost:3001", http.StatusForbidden},
+		{"opaque", "null", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := dial(t, ts, tt.origin); got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStalledClientDoesNotBlockInput(t *testing.T) {
+	dev := &fakeDevice{}
+	srv := NewServer(dev)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	defer srv.Close()
+
+	// The client never reads, so its socket buffers fill up
+	if _, status := dial(t, ts, ""); status != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d", status)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100000; i++ {
+			dev.InjectButtonEvent(launchpad.ButtonEvent{Button: launchpad.NewGridButton(i%8, 0), Pressed: i%2 == 0})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("button events blocked on a stalled WebSocket client")
+	}
+
+	// The stalled client is eventually dropped
+	deadline := time.Now().Add(2 * writeTimeout)
+	for {
+		srv.mu.Lock()
+		n := len(srv.clients)
+		srv.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stalled client was never dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
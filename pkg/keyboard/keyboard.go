@@ -0,0 +1,382 @@
+// Package keyboard turns the Launchpad grid into an isomorphic melodic keyboard.
+//
+// Pads are laid out left to right in semitones (chromatic) or scale degrees
+// (in key), with each row a fixed interval above the one below. Root notes and
+// in-scale notes are shown in different colors, the scene buttons select the
+// octave (top is highest) and pressed pads are reported as note events and,
+// if a MIDI output is set, sent as notes:
+//
+//	kb := keyboard.New()
+//	kb.SetScale(2, music.Dorian) // D dorian
+//	kb.SetRowInterval(keyboard.Fourths)
+//	kb.OnNote(func(e keyboard.NoteEvent) { fmt.Println(music.NoteName(int(e.Note)), e.On) })
+//	kb.Attach(lp)
+package keyboard
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/music"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// RowInterval is the interval between one row and the row below it
+type RowInterval int
+
+const (
+	Fourths RowInterval = iota // Perfect fourth (5 semitones), like a guitar
+	Thirds                     // Major third (4 semitones)
+	Octaves                    // Octave (12 semitones)
+)
+
+// String returns the string representation of a RowInterval
+func (r RowInterval) String() string {
+	switch r {
+	case Fourths:
+		return "Fourths"
+	case Thirds:
+		return "Thirds"
+	case Octaves:
+		return "Octaves"
+	default:
+		return fmt.Sprintf("RowInterval(%d)", r)
+	}
+}
+
+// semitones returns the size of the interval in semitones
+func (r RowInterval) semitones() int {
+	switch r {
+	case Thirds:
+		return 4
+	case Octaves:
+		return 12
+	default:
+		return 5
+	}
+}
+
+// degrees returns the number of scale degrees spanning the interval
+func (r RowInterval) degrees(scale music.Scale) int {
+	if r == Octaves {
+		return scale.Len()
+	}
+	n := 0
+	for _, interval := range scale.Intervals {
+		if interval < r.semitones() {
+			n++
+		}
+	}
+	return n
+}
+
+// Octaves selectable with the scene buttons
+const (
+	MinOctave     = 0 // Bottom-left pad is the root in MIDI octave 0 (notes 0-11)
+	MaxOctave     = launchpad.SceneButtons - 1
+	DefaultOctave = 3 // Bottom-left pad is the root from note 36
+)
+
+// Style holds the LED states the keyboard draws with
+type Style struct {
+	Root       launchpad.LEDState // Pads playing the root note
+	InScale    launchpad.LEDState // Pads playing other scale notes
+	OutOfScale launchpad.LEDState // Pads playing notes outside the scale (chromatic layout)
+	Pressed    launchpad.LEDState // Pads whose note is sounding
+	Octave     launchpad.LEDState // Scene button of the selected octave
+}
+
+// DefaultStyle is the style given to new keyboards
+var DefaultStyle = Style{
+	Root:       launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessFull),
+	InScale:    launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessLow),
+	OutOfScale: launchpad.LEDState{},
+	Pressed:    launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull),
+	Octave:     launchpad.NewLEDState(launchpad.ColorAmber, launchpad.BrightnessFull),
+}
+
+// NoteEvent reports a pad starting or stopping a note
+type NoteEvent struct {
+	Button   launchpad.Button // The pad that was pressed or released
+	Note     uint8            // MIDI note
+	Velocity uint8            // Note-on velocity (0 for note off)
+	On       bool             // True when the note starts
+}
+
+// NoteHandler is a function that handles note events
+type NoteHandler func(NoteEvent)
+
+// Keyboard is a melodic layout of the grid
+type Keyboard struct {
+	mu       sync.Mutex
+	root     int // Pitch class of the root (0-11)
+	scale    music.Scale
+	inKey    bool
+	interval RowInterval
+	octave   int
+	style    Style
+	velocity uint8
+	handlers []NoteHandler
+	held     map[launchpad.Button]uint8 // Note started by each held pad
+	sounding map[uint8]int              // Number of held pads per sounding note
+
+	// MIDI output
+	outMu   sync.Mutex
+	out     drivers.Out
+	channel uint8
+
+	// Display
+	screen *launchpad.FrameWriter // Draws on the attached Launchpad, nil until Attach
+}
+
+// New creates a chromatic keyboard in C major, rows a fourth apart
+func New() *Keyboard {
+	return &Keyboard{
+		scale:    music.Major,
+		interval: Fourths,
+		octave:   DefaultOctave,
+		style:    DefaultStyle,
+		velocity: 100,
+		held:     make(map[launchpad.Button]uint8),
+		sounding: make(map[uint8]int),
+	}
+}
+
+// SetScale sets the root pitch class (0 for C to 11 for B) and scale
+func (k *Keyboard) SetScale(root int, scale music.Scale) error {
+	if root < 0 || root > 11 {
+		return fmt.Errorf("invalid root: %d", root)
+	}
+	if scale.Len() == 0 {
+		return fmt.Errorf("empty scale: %v", scale)
+	}
+	k.mu.Lock()
+	k.root = root
+	k.scale = scale
+	k.mu.Unlock()
+	return k.Draw()
+}
+
+// SetInKey lays out only scale notes (true) or every semitone (false)
+func (k *Keyboard) SetInKey(inKey bool) error {
+	k.mu.Lock()
+	k.inKey = inKey
+	k.mu.Unlock()
+	return k.Draw()
+}
+
+// SetRowInterval sets the interval between rows
+func (k *Keyboard) SetRowInterval(interval RowInterval) error {
+	if interval < Fourths || interval > Octaves {
+		return fmt.Errorf("invalid row interval: %v", interval)
+	}
+	k.mu.Lock()
+	k.interval = interval
+	k.mu.Unlock()
+	return k.Draw()
+}
+
+// SetOctave sets the octave of the bottom-left pad
+func (k *Keyboard) SetOctave(octave int) error {
+	if octave < MinOctave || octave > MaxOctave {
+		return fmt.Errorf("invalid octave: %d", octave)
+	}
+	k.mu.Lock()
+	k.octave = octave
+	k.mu.Unlock()
+	return k.Draw()
+}
+
+// Octave returns the octave of the bottom-left pad
+func (k *Keyboard) Octave() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.octave
+}
+
+// SetStyle sets the LED states the keyboard draws with
+func (k *Keyboard) SetStyle(style Style) error {
+	k.mu.Lock()
+	k.style = style
+	k.mu.Unlock()
+	return k.Draw()
+}
+
+// SetVelocity sets the note-on velocity (1-127)
+func (k *Keyboard) SetVelocity(velocity uint8) error {
+	if velocity < 1 || velocity > 127 {
+		return fmt.Errorf("invalid velocity: %d", velocity)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.velocity = velocity
+	return nil
+}
+
+// OnNote registers a handler called when a pad starts or stops a note
+func (k *Keyboard) OnNote(handler NoteHandler) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.handlers = append(k.handlers, handler)
+}
+
+// SetMIDIOut sends notes to an open MIDI output on a channel (0-15); nil disables it
+func (k *Keyboard) SetMIDIOut(out drivers.Out, channel uint8) error {
+	if channel > 15 {
+		return fmt.Errorf("invalid MIDI channel: %d", channel)
+	}
+	k.outMu.Lock()
+	defer k.outMu.Unlock()
+	k.out = out
+	k.channel = channel
+	return nil
+}
+
+// Note returns the MIDI note of a grid pad with the current settings
+// Keyboard therefore also satisfies bridge.Layout
+func (k *Keyboard) Note(btn launchpad.Button) (uint8, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	note := k.noteLocked(btn)
+	if !music.ValidNote(note) {
+		return 0, false
+	}
+	return uint8(note), true
+}
+
+// noteLocked returns the note of a pad, or -1 if it plays nothing (caller must hold the lock)
+func (k *Keyboard) noteLocked(btn launchpad.Button) int {
+	if btn.IsScene || btn.IsTop || !btn.Valid() {
+		return -1
+	}
+	base := (12 * k.octave) + k.root
+	row := launchpad.GridHeight - 1 - btn.Y
+	if k.inKey {
+		return k.scale.Note(base, btn.X+(row*k.interval.degrees(k.scale)))
+	}
+	return base + btn.X + (row * k.interval.semitones())
+}
+
+// Attach registers the keyboard for a Launchpad's button events and draws it
+func (k *Keyboard) Attach(lp *launchpad.Launchpad) error {
+	k.mu.Lock()
+	k.screen = launchpad.NewFrameWriter(lp)
+	k.mu.Unlock()
+
+	lp.OnButton(k.Handle)
+	return k.Draw()
+}
+
+// Handle plays notes on pad presses and selects octaves on scene buttons
+// It can be registered directly with Launchpad.OnButton or a page's OnButton
+func (k *Keyboard) Handle(event launchpad.ButtonEvent) {
+	btn := event.Button
+	if btn.IsTop {
+		return
+	}
+	if btn.IsScene {
+		if event.Pressed {
+			k.SetOctave(MaxOctave - btn.Y)
+		}
+		return
+	}
+
+	k.mu.Lock()
+	var e NoteEvent
+	var sound bool
+	if event.Pressed {
+		note := k.noteLocked(btn)
+		if _, held := k.held[btn]; held || !music.ValidNote(note) {
+			k.mu.Unlock()
+			return
+		}
+		k.held[btn] = uint8(note)
+		k.sounding[uint8(note)]++
+		sound = k.sounding[uint8(note)] == 1
+		e = NoteEvent{Button: btn, Note: uint8(note), Velocity: k.velocity, On: true}
+	} else {
+		note, held := k.held[btn]
+		if !held {
+			k.mu.Unlock()
+			return
+		}
+		delete(k.held, btn)
+		k.sounding[note]--
+		if k.sounding[note] <= 0 {
+			delete(k.sounding, note)
+			sound = true
+		}
+		e = NoteEvent{Button: btn, Note: note}
+	}
+	handlers := make([]NoteHandler, len(k.handlers))
+	copy(handlers, k.handlers)
+	k.mu.Unlock()
+
+	// Several pads can share a note; only the first press and last release are sent
+	if sound {
+		k.sendNote(e)
+	}
+	for _, handler := range handlers {
+		handler(e)
+	}
+	k.Draw()
+}
+
+// sendNote sends a note event to the MIDI output, if one is set
+func (k *Keyboard) sendNote(e NoteEvent) {
+	k.outMu.Lock()
+	defer k.outMu.Unlock()
+
+	if k.out == nil {
+		return
+	}
+	if e.On {
+		k.out.Send(midi.NoteOn(k.channel, e.Note, e.Velocity))
+	} else {
+		k.out.Send(midi.NoteOff(k.channel, e.Note))
+	}
+}
+
+// Render draws the keyboard and octave selector into a frame
+func (k *Keyboard) Render(frame *launchpad.Frame) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for y := 0; y < launchpad.GridHeight; y++ {
+		for x := 0; x < launchpad.GridWidth; x++ {
+			btn := launchpad.NewGridButton(x, y)
+			note := k.noteLocked(btn)
+			switch {
+			case !music.ValidNote(note):
+				frame.Set(btn, launchpad.LEDState{})
+			case k.sounding[uint8(note)] > 0:
+				frame.Set(btn, k.style.Pressed)
+			case music.PitchClass(note) == k.root:
+				frame.Set(btn, k.style.Root)
+			case k.scale.Contains(k.root, note):
+				frame.Set(btn, k.style.InScale)
+			default:
+				frame.Set(btn, k.style.OutOfScale)
+			}
+		}
+	}
+
+	frame.Set(launchpad.NewSceneButton(MaxOctave-k.octave), k.style.Octave)
+}
+
+// Draw sends the keyboard to the attached Launchpad, if any
+// Only LEDs that changed since the previous draw are sent
+func (k *Keyboard) Draw() error {
+	var frame launchpad.Frame
+	k.Render(&frame)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.screen == nil {
+		return nil
+	}
+	return k.screen.Draw(&frame)
+}
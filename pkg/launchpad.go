@@ -27,7 +27,7 @@
 //   - Simple LED control with intuitive color and brightness API
 //   - Event-driven button input with callbacks or channels
 //   - Double-buffering support for smooth animations
-//   - Automatic MIDI message rate limiting (400 msg/sec)
+//   - Frame updates that send only the LEDs that changed
 //   - Type-safe button coordinates and colors
 //   - Support for all 80 buttons (64 grid + 8 scene + 8 top)
 //
@@ -9,11 +9,15 @@ func (lp *Launchpad) SetDisplayBuffer(buffer BufferID) error {
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
+	}
+
+	if err := lp.requireBuffers("double-buffering"); err != nil {
+		return err
 	}
 
 	if !buffer.Valid() {
-		return fmt.Errorf("invalid buffer ID: %v", buffer)
+		return fmt.Errorf("%w buffer ID: %v", ErrInvalid, buffer)
 	}
 
 	// Calculate buffer command data byte
@@ -43,11 +47,15 @@ func (lp *Launchpad) SetUpdateBuffer(buffer BufferID) error {
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
+	}
+
+	if err := lp.requireBuffers("double-buffering"); err != nil {
+		return err
 	}
 
 	if !buffer.Valid() {
-		return fmt.Errorf("invalid buffer ID: %v", buffer)
+		return fmt.Errorf("%w buffer ID: %v", ErrInvalid, buffer)
 	}
 
 	// Calculate buffer command data byte
@@ -77,7 +85,11 @@ func (lp *Launchpad) SwapBuffers() error {
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
+	}
+
+	if err := lp.requireBuffers("double-buffering"); err != nil {
+		return err
 	}
 
 	// Swap the buffers
@@ -104,14 +116,18 @@ func (lp *Launchpad) SwapBuffers() error {
 	return nil
 }
 
-// CopyBuffer copies the update buffer to the display buffer
+// CopyBuffer copies the displayed buffer into the update buffer
 // This makes both buffers show the same content
 func (lp *Launchpad) CopyBuffer() error {
 	lp.mu.Lock()
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
+	}
+
+	if err := lp.requireBuffers("double-buffering"); err != nil {
+		return err
 	}
 
 	// Calculate command with copy flag
@@ -129,17 +145,24 @@ func (lp *Launchpad) CopyBuffer() error {
 		return fmt.Errorf("failed to copy buffer: %w", err)
 	}
 
+	lp.leds[lp.updateBuffer] = lp.leds[lp.displayBuffer]
 	return nil
 }
 
 // EnableFlash enables automatic LED flashing
 // LEDs marked with the flash flag will automatically flash
+// Models without buffers always flash such LEDs, so only the setting is recorded
 func (lp *Launchpad) EnableFlash(enabled bool) error {
 	lp.mu.Lock()
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
+	}
+
+	if !lp.profile.Buffers {
+		lp.flashEnabled = enabled
+		return nil
 	}
 
 	// Calculate command with flash flag
@@ -161,6 +184,25 @@ func (lp *Launchpad) EnableFlash(enabled bool) error {
 	return nil
 }
 
+// IsDoubleBuffering returns whether the display and update buffers differ
+// While double-buffering, LED writes in WriteNormal mode only touch the update buffer
+func (lp *Launchpad) IsDoubleBuffering() bool {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.displayBuffer != lp.updateBuffer
+}
+
+// resolveWriteMode returns the LED state with WriteNormal resolved for the
+// current buffer configuration (caller must hold the lock)
+// The reference requires Copy and Clear to be cleared while double-buffering,
+// otherwise writes would also land on the displayed buffer
+func (lp *Launchpad) resolveWriteMode(state LEDState) LEDState {
+	if state.Mode == WriteNormal && !state.Flash && lp.displayBuffer != lp.updateBuffer {
+		state.Mode = WriteUpdateOnly
+	}
+	return state
+}
+
 // GetDisplayBuffer returns the current display buffer ID
 func (lp *Launchpad) GetDisplayBuffer() BufferID {
 	lp.mu.Lock()
@@ -25,6 +25,12 @@ const (
 	controllerSystem = 0
 )
 
+// Duty cycle controllers; data = 16 × (numerator - base) + (denominator - 3)
+const (
+	controllerDutyCycleLow  = 0x1E // Numerators 1-8
+	controllerDutyCycleHigh = 0x1F // Numerators 9-16
+)
+
 // System command data values
 const (
 	systemReset         = 0    // 0x00 - Reset to defaults
@@ -46,6 +52,7 @@ const (
 const (
 	velocityFlagsClear = 0x08 // Clear bit (bit 3)
 	velocityFlagsCopy  = 0x04 // Copy bit (bit 2)
+	velocityFlagsNone   = 0x00 // 0 - double-buffering (update buffer only)
 	velocityFlagsNormal = velocityFlagsCopy | velocityFlagsClear // 0x0C = 12
 	velocityFlagsFlash  = velocityFlagsClear // 0x08 = 8
 )
@@ -64,9 +71,10 @@ const (
 	velocityReleased = 0   // 0x00 - Button released
 )
 
-// MIDI rate limiting
+// MIDI message rate
+// golp sends messages as they are written and does not enforce this limit
 const (
-	MaxMessagesPerSecond = 400 // Maximum MIDI messages per second
+	MaxMessagesPerSecond = 400 // Messages per second the Launchpad Mini handles
 )
 
 // Pre-calculated velocity values for common colors (normal mode, flags = 12)
@@ -111,6 +119,14 @@ const (
 	ColorYellow              // Yellow (red + green, more green)
 )
 
+// Write mode constants controlling how LED writes affect the two buffers
+const (
+	WriteNormal     WriteMode = iota // Copy and Clear set (flags = 12); update-only while double-buffering
+	WriteUpdateOnly                  // No flags (flags = 0): write only to the update buffer
+	WriteCopy                        // Copy set (flags = 4): write to both buffers
+	WriteClear                       // Clear set (flags = 8): write the update buffer, clear the other copy
+)
+
 // Brightness constants for LED brightness levels (0-3)
 const (
 	BrightnessOff    Brightness = 0 // LED off
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
 )
 
 // Launchpad represents a connection to a Launchpad Mini device
@@ -13,92 +14,139 @@ type Launchpad struct {
 	midi *midiConnection
 	mu   sync.Mutex
 
+	// Model
+	profile       *Profile   // Model being driven
+	chosenProfile *Profile   // Set by SetProfile, nil to detect it
+	info          DeviceInfo // Reply to the last device inquiry
+	hasInfo       bool       // True if the device answered an inquiry
+
 	// State
 	mappingMode   MappingMode
 	displayBuffer BufferID
 	updateBuffer  BufferID
 	flashEnabled  bool
-
-	// Message rate limiting
-	msgQueue      chan message
-	stopQueue     chan struct{}
-	queueRunning  bool
+	dutyCycle     DutyCycle
+	leds          [2]Frame // Last LED state written to each buffer
+	keepOnClose   bool     // Skip the reset in Close
+
+	// Traffic tap (separate lock: handlers run while mu is held)
+	trafficMu       sync.Mutex
+	trafficHandlers []TrafficHandler
+	trafficProfile  *Profile // Model reported with traffic
+
+	// SysEx (separate locks: inquiries wait for replies without holding mu)
+	sysexMu       sync.Mutex
+	sysexHandlers []SysExHandler
+	inquiryMu     sync.Mutex // One inquiry at a time
+	identity      chan DeviceInfo
+	opening       bool // OpenPorts is identifying the device
+
+	// Health (separate lock: failures are reported while mu is held)
+	healthMu      sync.Mutex
+	health        Health
+	errorHandlers []ErrorHandler
+	errChan       chan error
 
 	// Event handling
 	buttonHandlers []ButtonHandler
 	eventChan      chan ButtonEvent
-	stopListener   chan struct{}
 	listenerStop   func() // Function to stop MIDI listener
 }
 
-// message represents a queued MIDI message
-type message struct {
-	status byte
-	data1  byte
-	data2  byte
-}
-
 // ButtonHandler is a function that handles button events
 type ButtonHandler func(ButtonEvent)
 
 // New creates a new Launchpad instance but does not connect to the device
 func New() *Launchpad {
 	return &Launchpad{
-		mappingMode:   MappingXY,
-		displayBuffer: Buffer0,
-		updateBuffer:  Buffer0,
-		flashEnabled:  false,
-		msgQueue:      make(chan message, 100), // Buffer up to 100 messages
-		stopQueue:     make(chan struct{}),
-		stopListener:  make(chan struct{}),
-		eventChan:     make(chan ButtonEvent, 50), // Buffer up to 50 events
+		profile:        ProfileMini,
+		trafficProfile: ProfileMini,
+		mappingMode:    MappingXY,
+		displayBuffer:  Buffer0,
+		updateBuffer:   Buffer0,
+		flashEnabled:   false,
+		dutyCycle:      DefaultDutyCycle,
+		eventChan:      make(chan ButtonEvent, 50), // Buffer up to 50 events
+		identity:       make(chan DeviceInfo, 1),
+		errChan:        make(chan error, 16), // Buffer up to 16 failures
 	}
 }
 
 // Open opens a connection to the Launchpad device
 func (lp *Launchpad) Open() error {
-	lp.mu.Lock()
-	defer lp.mu.Unlock()
+	inPort, outPort, err := findLaunchpad()
+	if err != nil {
+		return err
+	}
+	return lp.OpenPorts(inPort, outPort)
+}
 
-	if lp.midi != nil {
-		return fmt.Errorf("launchpad already open")
+// OpenPorts opens a connection using the given MIDI ports instead of searching
+// for a device, e.g. for a renamed port or a simulator
+func (lp *Launchpad) OpenPorts(inPort drivers.In, outPort drivers.Out) error {
+	lp.mu.Lock()
+	if lp.midi != nil || lp.opening {
+		lp.mu.Unlock()
+		return ErrAlreadyOpen
 	}
+	lp.opening = true
+	lp.mu.Unlock()
+
+	defer func() {
+		lp.mu.Lock()
+		lp.opening = false
+		lp.mu.Unlock()
+	}()
 
 	// Open MIDI connection
-	midi, err := openMIDI()
+	midi, err := openMIDI(inPort, outPort)
 	if err != nil {
 		return err
 	}
+	midi.onTraffic = lp.observeTraffic
 
-	lp.midi = midi
-
-	// Start message queue processor
-	go lp.processMessageQueue()
-	lp.queueRunning = true
-
-	// Start input listener
-	stopFunc, err := lp.midi.startListening(lp.handleIncomingMessage)
+	// Start input listener; button messages are ignored until the device is open
+	stopFunc, err := midi.startListening(lp.handleIncomingMessage)
 	if err != nil {
-		lp.midi.close()
-		lp.midi = nil
+		midi.close()
 		return fmt.Errorf("failed to start listener: %w", err)
 	}
+
+	// Identify the device before taking the lock, so the listener is free to
+	// deliver the reply; devices that do not answer are detected from the port name
+	info, inquiryErr := lp.inquire(midi.send)
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	lp.midi = midi
 	lp.listenerStop = stopFunc
 
+	lp.info = info
+	lp.hasInfo = inquiryErr == nil
+	lp.profile = lp.chosenProfile
+	if lp.profile == nil && inquiryErr == nil {
+		lp.profile = info.Profile()
+	}
+	if lp.profile == nil {
+		lp.profile = ProfileForPort(outPort.String())
+	}
+	if lp.profile == nil {
+		lp.profile = ProfileMini
+	}
+	lp.setTrafficProfile(lp.profile)
+
 	// Reset the device to a known state (without locking - we already have the lock)
-	err = lp.sendControlChange(controllerSystem, systemReset)
+	err = lp.reset()
 	if err != nil {
+		// Undo everything above so that OpenPorts can be called again
+		stopFunc()
+		lp.listenerStop = nil
 		lp.midi.close()
 		lp.midi = nil
 		return fmt.Errorf("failed to reset device: %w", err)
 	}
 
-	// Update internal state to match reset
-	lp.mappingMode = MappingXY
-	lp.displayBuffer = Buffer0
-	lp.updateBuffer = Buffer0
-	lp.flashEnabled = false
-
 	return nil
 }
 
@@ -112,11 +160,21 @@ func (lp *Launchpad) Close() error {
 		return nil // Already closed
 	}
 
-	// Reset the device to turn off all LEDs (ignore errors - we're closing anyway)
-	lp.sendControlChange(controllerSystem, systemReset)
+	if !lp.keepOnClose {
+		// Reset the device to turn off all LEDs; failures are reported rather
+		// than returned since the connection is closed anyway
+		if err := lp.reset(); err != nil {
+			lp.reportError("reset on close", err)
+		}
+		for _, msg := range lp.profile.enc.teardown() {
+			if err := lp.midi.send(msg); err != nil {
+				lp.reportError("reset on close", err)
+			}
+		}
 
-	// Give the reset command time to be sent
-	time.Sleep(50 * time.Millisecond)
+		// Give the reset command time to be sent
+		time.Sleep(50 * time.Millisecond)
+	}
 
 	// Stop MIDI listener
 	if lp.listenerStop != nil {
@@ -124,15 +182,6 @@ func (lp *Launchpad) Close() error {
 		lp.listenerStop = nil
 	}
 
-	// Stop message queue
-	if lp.queueRunning {
-		close(lp.stopQueue)
-		lp.queueRunning = false
-	}
-
-	// Stop listener channel
-	close(lp.stopListener)
-
 	// Close MIDI connection
 	err := lp.midi.close()
 	lp.midi = nil
@@ -143,6 +192,30 @@ func (lp *Launchpad) Close() error {
 	return err
 }
 
+// SetResetOnClose sets whether Close resets the device (the default)
+// Disable it to leave the LEDs lit after the program exits
+func (lp *Launchpad) SetResetOnClose(reset bool) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.keepOnClose = !reset
+}
+
+// Send sends a raw MIDI message to the device
+// The message is not validated and does not update the tracked LED state
+func (lp *Launchpad) Send(msg []byte) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.midi == nil {
+		return ErrNotOpen
+	}
+	if len(msg) == 0 {
+		return fmt.Errorf("%w MIDI message: empty", ErrInvalid)
+	}
+
+	return lp.midi.send(msg)
+}
+
 // Reset resets the Launchpad to default state
 // Turns off all LEDs, resets mapping mode, buffers, and duty cycle
 func (lp *Launchpad) Reset() error {
@@ -150,21 +223,33 @@ func (lp *Launchpad) Reset() error {
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
 	}
 
 	// Send reset command
-	err := lp.sendControlChange(controllerSystem, systemReset)
+	err := lp.reset()
 	if err != nil {
 		return fmt.Errorf("failed to reset: %w", err)
 	}
 
-	// Update internal state
+	return nil
+}
+
+// reset sends the model's reset messages and updates the internal state to
+// match (caller must hold the lock)
+func (lp *Launchpad) reset() error {
+	for _, msg := range lp.profile.enc.reset() {
+		if err := lp.midi.send(msg); err != nil {
+			return err
+		}
+	}
+
 	lp.mappingMode = MappingXY
 	lp.displayBuffer = Buffer0
 	lp.updateBuffer = Buffer0
 	lp.flashEnabled = false
-
+	lp.dutyCycle = DefaultDutyCycle
+	lp.leds = [2]Frame{}
 	return nil
 }
 
@@ -174,7 +259,7 @@ func (lp *Launchpad) SetMappingMode(mode MappingMode) error {
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
 	}
 
 	var data byte
@@ -184,7 +269,16 @@ func (lp *Launchpad) SetMappingMode(mode MappingMode) error {
 	case MappingDrum:
 		data = systemLayoutDrum
 	default:
-		return fmt.Errorf("invalid mapping mode: %v", mode)
+		return fmt.Errorf("%w mapping mode: %v", ErrInvalid, mode)
+	}
+
+	// Other models always use the X-Y coordinates
+	if !lp.profile.Buffers {
+		if mode != MappingXY {
+			return lp.requireBuffers("drum layout")
+		}
+		lp.mappingMode = mode
+		return nil
 	}
 
 	err := lp.sendControlChange(controllerSystem, data)
@@ -203,6 +297,51 @@ func (lp *Launchpad) GetMappingMode() MappingMode {
 	return lp.mappingMode
 }
 
+// SetDutyCycle sets the fraction of time low-brightness LEDs are lit
+// Lower duty cycles increase contrast between brightness levels but also flicker
+func (lp *Launchpad) SetDutyCycle(duty DutyCycle) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.midi == nil {
+		return ErrNotOpen
+	}
+
+	if err := lp.requireBuffers("duty cycle"); err != nil {
+		return err
+	}
+
+	if !duty.Valid() {
+		return fmt.Errorf("%w duty cycle: %v", ErrInvalid, duty)
+	}
+
+	err := lp.sendDutyCycle(duty)
+	if err != nil {
+		return fmt.Errorf("failed to set duty cycle: %w", err)
+	}
+
+	lp.dutyCycle = duty
+	return nil
+}
+
+// GetDutyCycle returns the current duty cycle
+func (lp *Launchpad) GetDutyCycle() DutyCycle {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.dutyCycle
+}
+
+// sendDutyCycle sends a valid duty cycle (caller must hold the lock)
+// Formula: data = 16 × (numerator - base) + (denominator - 3)
+func (lp *Launchpad) sendDutyCycle(duty DutyCycle) error {
+	if duty.Numerator < 9 {
+		data := byte(16*(duty.Numerator-1) + duty.Denominator - 3)
+		return lp.sendControlChange(controllerDutyCycleLow, data)
+	}
+	data := byte(16*(duty.Numerator-9) + duty.Denominator - 3)
+	return lp.sendControlChange(controllerDutyCycleHigh, data)
+}
+
 // TestLEDs turns on all LEDs at the specified brightness for testing
 // This also resets all other device state
 func (lp *Launchpad) TestLEDs(brightness Brightness) error {
@@ -210,7 +349,7 @@ func (lp *Launchpad) TestLEDs(brightness Brightness) error {
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
 	}
 
 	var data byte
@@ -222,107 +361,91 @@ func (lp *Launchpad) TestLEDs(brightness Brightness) error {
 	case BrightnessFull:
 		data = systemTestFull
 	default:
-		return fmt.Errorf("invalid brightness for test mode: %v", brightness)
+		return fmt.Errorf("%w brightness for test mode: %v", ErrInvalid, brightness)
 	}
 
-	return lp.sendControlChange(controllerSystem, data)
-}
+	// Other models have no test mode, so light every LED instead
+	if !lp.profile.Buffers {
+		var frame Frame
+		frame.Fill(LEDState{Red: brightness, Green: brightness})
+		return lp.sendFrame(&frame)
+	}
 
-// processMessageQueue processes queued messages with rate limiting
-func (lp *Launchpad) processMessageQueue() {
-	ticker := time.NewTicker(time.Second / MaxMessagesPerSecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-lp.stopQueue:
-			return
-		case msg := <-lp.msgQueue:
-			<-ticker.C // Wait for rate limit
-			if lp.midi != nil {
-				lp.midi.sendMessage(msg.status, msg.data1, msg.data2)
-			}
-		}
+	err := lp.sendControlChange(controllerSystem, data)
+	if err != nil {
+		return err
 	}
-}
 
-// queueMessage adds a message to the send queue
-func (lp *Launchpad) queueMessage(status, data1, data2 byte) {
-	lp.msgQueue <- message{status, data1, data2}
+	// Test mode lights every LED amber in both buffers
+	for i := range lp.leds {
+		lp.leds[i].Fill(LEDState{Red: brightness, Green: brightness})
+	}
+	return nil
 }
 
-// sendNoteOn sends a note-on message (bypassing queue for immediate sending)
+// sendNoteOn sends a note-on message
 func (lp *Launchpad) sendNoteOn(key, velocity byte) error {
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
 	}
 	return lp.midi.sendNoteOn(key, velocity)
 }
 
-// sendControlChange sends a controller change message (bypassing queue)
+// sendControlChange sends a controller change message
 func (lp *Launchpad) sendControlChange(controller, data byte) error {
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
 	}
 	return lp.midi.sendControlChange(controller, data)
 }
 
 // handleIncomingMessage processes incoming MIDI messages
 func (lp *Launchpad) handleIncomingMessage(msg []byte) {
+	defer lp.recoverListener()
+
+	if len(msg) > 0 && msg[0] == 0xF0 {
+		lp.handleSysEx(msg)
+		return
+	}
+
 	if len(msg) < 3 {
 		return // Invalid message
 	}
 
-	status := msg[0]
-	data1 := msg[1]
-	data2 := msg[2]
-
-	var btn Button
-	var pressed bool
-
-	switch status {
-	case statusNoteOn:
-		// Grid or scene button
-		key := int(data1)
-		x := key % 16
-		y := key / 16
-
-		if x == 8 {
-			// Scene button
-			btn = NewSceneButton(y)
-		} else {
-			// Grid button
-			btn = NewGridButton(x, y)
-		}
+	lp.mu.Lock()
+	open := lp.midi != nil
+	enc := lp.profile.enc
+	lp.mu.Unlock()
 
-		pressed = data2 == velocityPressed
-
-	case statusControlChange:
-		// Top row button
-		controller := int(data1)
-		if controller >= controllerTopButton0 && controller <= controllerTopButton7 {
-			x := controller - controllerTopButton0
-			btn = NewTopButton(x)
-			pressed = data2 == velocityPressed
-		} else {
-			return // Not a button event
-		}
+	if !open {
+		return // Still opening: the model, and so the encoding, is not known yet
+	}
 
-	default:
-		return // Unknown message type
+	event, ok := enc.button(msg)
+	if !ok {
+		return // Not a button event
 	}
 
-	// Create event
-	event := ButtonEvent{
-		Button:  btn,
-		Pressed: pressed,
+	lp.dispatchButtonEvent(event)
+}
+
+// InjectButtonEvent delivers a button event to the event channel and handlers
+// as if it came from the device, for virtual pads and replayed input
+func (lp *Launchpad) InjectButtonEvent(event ButtonEvent) {
+	if !event.Button.Valid() {
+		return
 	}
+	lp.dispatchButtonEvent(event)
+}
 
+// dispatchButtonEvent sends an event to the event channel and all handlers
+func (lp *Launchpad) dispatchButtonEvent(event ButtonEvent) {
 	// Send to event channel
 	select {
 	case lp.eventChan <- event:
 	default:
 		// Channel full, drop event
+		lp.countDroppedEvent()
 	}
 
 	// Call registered handlers
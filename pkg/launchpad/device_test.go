@@ -0,0 +1,67 @@
+package launchpad_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/simulator"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// brokenOut is an output port whose sends fail
+type brokenOut struct {
+	drivers.Out
+}
+
+func (brokenOut) Send([]byte) error {
+	return errors.New("cable unplugged")
+}
+
+func TestOpenPortsResetFailure(t *testing.T) {
+	sim := simulator.New()
+	lp := launchpad.New()
+
+	if err := lp.OpenPorts(sim.In(), brokenOut{sim.Out()}); err == nil {
+		t.Fatal("OpenPorts succeeded with a failing output")
+	}
+	if lp.Health().Open {
+		t.Fatal("Launchpad open after a failed OpenPorts")
+	}
+
+	// Nothing is left running, so the ports can be opened again
+	if err := lp.OpenPorts(sim.In(), sim.Out()); err != nil {
+		t.Fatalf("OpenPorts after a failure: %v", err)
+	}
+	if err := lp.SetLED(1, 2, launchpad.ColorRed, launchpad.BrightnessFull); err != nil {
+		t.Fatal(err)
+	}
+	frame := sim.Frame()
+	if got := frame.Get(launchpad.NewGridButton(1, 2)); got.Red != launchpad.BrightnessFull {
+		t.Fatalf("LED is %v after reopening", got)
+	}
+
+	pressed := make(chan launchpad.ButtonEvent, 1)
+	lp.OnButton(func(event launchpad.ButtonEvent) { pressed <- event })
+	sim.Press(launchpad.NewGridButton(0, 0), true)
+	if len(pressed) != 1 {
+		t.Fatal("button press not delivered after reopening")
+	}
+	if err := lp.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	sim := simulator.New()
+	lp := launchpad.New()
+
+	for i := 0; i < 3; i++ {
+		if err := lp.OpenPorts(sim.In(), sim.Out()); err != nil {
+			t.Fatalf("open %d: %v", i, err)
+		}
+		if err := lp.Close(); err != nil {
+			t.Fatalf("close %d: %v", i, err)
+		}
+	}
+}
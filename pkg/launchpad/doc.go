@@ -96,6 +96,55 @@ while displaying the current one:
 	// Swap buffers for instant update
 	lp.SwapBuffers()
 
+While the display and update buffers differ, LED writes made with the default
+WriteNormal mode are sent with the Copy and Clear bits cleared, so they only
+reach the update buffer. Use an explicit write mode for other behaviour:
+
+	// Write to both buffers regardless of the buffer configuration
+	lp.SetLEDState(x, y, launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessFull).WithMode(launchpad.WriteCopy))
+
+	// Write the update buffer and clear the other buffer's copy of the LED
+	lp.SetLEDState(x, y, launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull).WithMode(launchpad.WriteClear))
+
+# Frames
+
+A Frame holds the state of all 80 LEDs. SetFrame sends it with the rapid LED
+update, and UpdateFrame sends only the LEDs that differ from a previous frame:
+
+	var frame launchpad.Frame
+	frame.SetLED(2, 3, launchpad.ColorGreen, launchpad.BrightnessFull)
+	lp.SetFrame(&frame)
+
+	next := frame
+	next.SetLED(4, 4, launchpad.ColorRed, launchpad.BrightnessFull)
+	lp.UpdateFrame(&frame, &next)
+
+Views that redraw a whole frame on every change keep a FrameWriter, which
+remembers the frame on the device and calls SetFrame or UpdateFrame as needed:
+
+	screen := launchpad.NewFrameWriter(lp)
+	screen.Draw(&frame)
+	screen.Draw(&next) // Sends one LED
+
+The device cannot be queried for its LEDs, so the Launchpad tracks every write.
+CurrentFrame returns what the displayed buffer shows and BufferFrame returns
+either buffer.
+
+# Other Ports
+
+OpenPorts connects through given MIDI ports instead of searching for a device,
+for example the simulator package's emulated Launchpad:
+
+	sim := simulator.New()
+	lp.OpenPorts(sim.In(), sim.Out())
+
+# Virtual Input
+
+InjectButtonEvent delivers an event to ButtonEvents and all OnButton handlers
+exactly like a hardware press, for on-screen pads and replayed input:
+
+	lp.InjectButtonEvent(launchpad.ButtonEvent{Button: launchpad.NewGridButton(0, 0), Pressed: true})
+
 # Colors and Brightness
 
 Available colors:
@@ -151,6 +200,67 @@ Grid coordinate system:
 	// Test all LEDs at specified brightness
 	lp.TestLEDs(launchpad.BrightnessFull)
 
+	// Send raw MIDI bytes (not validated or tracked)
+	lp.Send([]byte{0xB0, 0x00, 0x7F})
+
+	// Leave the LEDs lit when the program exits
+	lp.SetResetOnClose(false)
+
+	// Adjust the low-brightness duty cycle (default 1/5)
+	lp.SetDutyCycle(launchpad.DutyCycle{Numerator: 2, Denominator: 7})
+
+# Snapshots
+
+Snapshot captures both LED buffers, the buffer selection, flash mode, mapping
+mode and duty cycle. Restore replays it onto a Launchpad, sending only what
+differs from the current state:
+
+	lp.Snapshot().WriteFile("state.json")
+
+	snap, err := launchpad.ReadSnapshotFile("state.json")
+	if err == nil {
+		lp.Restore(snap)
+	}
+
+# Other Models
+
+The Launchpad Mk2, Mini Mk3 and X are driven through the same API. Open
+detects the model, and Profile describes it; buttons keep their coordinates
+and red/green LED states are shown as RGB colors:
+
+	fmt.Println(lp.Profile()) // Launchpad X
+	lp.SetLEDRGB(3, 4, launchpad.RGB{R: 255, B: 128})
+
+SetButtonRGB shows the nearest red and green levels on bi-color models.
+Double-buffering, the duty cycle and the drum layout return an error wrapping
+ErrUnsupported on models without them. EnableFlash succeeds on every model,
+since the others flash LEDs on their own.
+
+# Device Identity
+
+Open sends a SysEx device inquiry and detects the model from the reply, or
+from the port name if the device does not answer. DeviceInfo returns the
+reply, with the model and firmware revision:
+
+	if info, ok := lp.DeviceInfo(); ok {
+		fmt.Println(info.Family, info.Firmware())
+	}
+
+OnSysEx receives every SysEx message the device sends.
+
+# Traffic Tracing
+
+OnTraffic reports every raw MIDI message in both directions, and a Decoder
+describes them as protocol operations:
+
+	var d launchpad.Decoder
+	lp.OnTraffic(func(t launchpad.Traffic) {
+		log.Printf("%v  %s", t, d.Decode(t)) // out 90 34 0F  grid[4,3] R3G0 copy|clear
+	})
+
+Each message carries the Profile of the model, which the Decoder uses to read
+the RGB models' messages too.
+
 # Error Handling
 
 Most methods return an error which should be checked:
@@ -159,6 +269,38 @@ Most methods return an error which should be checked:
 		log.Printf("Failed to set LED: %v", err)
 	}
 
+Errors can be told apart with errors.Is and errors.As: ErrNotOpen,
+ErrDeviceNotFound, ErrUnsupported and ErrInvalid are sentinels, an
+*InvalidButtonError carries the offending button and a *TransportError wraps
+the MIDI driver's error:
+
+	var badButton *launchpad.InvalidButtonError
+	var transport *launchpad.TransportError
+	switch err := lp.SetButtonLED(btn, color, brightness); {
+	case errors.Is(err, launchpad.ErrNotOpen):
+		// Open the device first
+	case errors.As(err, &badButton):
+		log.Printf("no such button: %v", badButton.Button)
+	case errors.As(err, &transport):
+		log.Printf("MIDI failure: %v", transport.Err)
+	}
+
+# Health
+
+Failures in the background, where no method can return them, are reported as
+*AsyncError on the Errors channel and to OnError handlers: a failed reset in
+Close and panics while handling input. Every other message is sent by the
+method that wrote it, which returns its error. Health returns counters for
+monitoring:
+
+	lp.OnError(func(err *launchpad.AsyncError) {
+		log.Printf("launchpad: %v", err)
+	})
+
+	h := lp.Health()
+	log.Printf("sent %d, received %d, dropped %d events, last input %v",
+		h.MessagesSent, h.MessagesReceived, h.EventsDropped, h.LastInput)
+
 # Cleanup
 
 Always close the connection when done to reset the device and free resources:
@@ -166,15 +308,19 @@ Always close the connection when done to reset the device and free resources:
 	defer lp.Close()
 
 The Close method automatically:
-  - Resets the device (turns off all LEDs)
+  - Resets the device (turns off all LEDs), unless disabled with SetResetOnClose
   - Stops MIDI listeners
   - Closes MIDI connections
   - Closes the MIDI driver
 
 # Performance
 
-The library automatically handles MIDI message rate limiting (400 messages per second)
-to prevent overwhelming the device. Messages are queued and sent at the appropriate rate.
+Messages are sent immediately on the calling goroutine, so every method returns
+the errors of the messages it sent and LED writes stay in order with buffer,
+layout and SysEx commands. golp does not throttle them: the Launchpad Mini
+handles about MaxMessagesPerSecond, so animations should draw frames, which
+UpdateFrame and FrameWriter send as the LEDs that changed or a 41-message
+rapid update, whichever is shorter.
 
 # Thread Safety
 
@@ -189,5 +335,6 @@ See the examples directory for complete working examples:
   - examples/rainbow: Animated rainbow effect
   - examples/animation: Double-buffered bouncing ball
   - examples/gameoflife: Conway's Game of Life implementation
+  - examples/sequencer: Step sequencer with tempo clock
 */
 package launchpad
@@ -0,0 +1,359 @@
+package launchpad
+
+import "bytes"
+
+// encoding translates buttons and LED states to a model's MIDI messages
+type encoding interface {
+	// reset turns off all LEDs and selects the layout golp uses
+	reset() [][]byte
+	// teardown returns the device to its own mode before closing
+	teardown() [][]byte
+	// led lights one LED
+	led(btn Button, state LEDState) [][]byte
+	// rgb lights one LED with an RGB color
+	rgb(btn Button, c RGB) [][]byte
+	// frame lights all LEDs
+	frame(f *Frame) [][]byte
+	// button decodes a button press or release
+	button(msg []byte) (ButtonEvent, bool)
+}
+
+// miniEncoding is the original Launchpad, Launchpad S and Launchpad Mini protocol:
+// X-Y note layout, top buttons on CC 104-111 and velocity-encoded LED states
+// Frames use the rapid LED update, sent by the Launchpad itself
+type miniEncoding struct{}
+
+func (miniEncoding) reset() [][]byte {
+	return [][]byte{{statusControlChange, controllerSystem, systemReset}}
+}
+
+func (miniEncoding) teardown() [][]byte {
+	return nil
+}
+
+func (miniEncoding) led(btn Button, state LEDState) [][]byte {
+	if btn.IsTop {
+		return [][]byte{{statusControlChange, byte(btn.MIDIController()), state.Velocity()}}
+	}
+	return [][]byte{{statusNoteOn, byte(btn.MIDIKey()), state.Velocity()}}
+}
+
+func (e miniEncoding) rgb(btn Button, c RGB) [][]byte {
+	return e.led(btn, NearestLEDState(c))
+}
+
+func (e miniEncoding) frame(f *Frame) [][]byte {
+	var msgs [][]byte
+	for i := range f {
+		msgs = append(msgs, e.led(FrameButton(i), f[i])...)
+	}
+	return msgs
+}
+
+func (miniEncoding) button(msg []byte) (ButtonEvent, bool) {
+	status, data1, data2 := msg[0], msg[1], msg[2]
+
+	switch status {
+	case statusNoteOn:
+		// Grid or scene button
+		key := int(data1)
+		x := key % 16
+		y := key / 16
+
+		var btn Button
+		if x == 8 {
+			btn = NewSceneButton(y)
+		} else {
+			btn = NewGridButton(x, y)
+		}
+		return ButtonEvent{Button: btn, Pressed: data2 == velocityPressed}, true
+
+	case statusControlChange:
+		// Top row button
+		controller := int(data1)
+		if controller >= controllerTopButton0 && controller <= controllerTopButton7 {
+			btn := NewTopButton(controller - controllerTopButton0)
+			return ButtonEvent{Button: btn, Pressed: data2 == velocityPressed}, true
+		}
+	}
+	return ButtonEvent{}, false
+}
+
+// novationHeader starts every Novation SysEx message; the device byte follows
+var novationHeader = []byte{0xF0, 0x00, 0x20, 0x29, 0x02}
+
+// RGB model LED commands
+const (
+	novationLEDRGB    = 0x0B // Mk2: LED, red, green, blue (0-63), repeated
+	novationLEDSpecs  = 0x03 // Mini Mk3 and X: type, LED, data..., repeated
+	novationSpecRGB   = 0x03 // Lighting type with red, green, blue (0-127)
+	statusFlashNoteOn = 0x91 // Note-on on channel 2 flashes a palette color
+	statusFlashCC     = 0xB1 // Controller change on channel 2 flashes a palette color
+)
+
+// novationEncoding is the protocol of the RGB Launchpads (Mk2, Mini Mk3 and X)
+//
+// Grid notes count from 11 at the bottom left to 88 at the top right, with
+// the right column at 19-89. Colors are sent as RGB SysEx, and flashing LEDs
+// flash a palette color on MIDI channel 2.
+type novationEncoding struct {
+	device      byte   // SysEx device byte
+	colorSpecs  bool   // LED SysEx entries start with a lighting type
+	rgbMax      byte   // Largest RGB component value
+	topCC       byte   // Controller of the leftmost top button
+	sideCC      bool   // The right column uses controllers instead of notes
+	setupMsg    []byte // SysEx command selecting the layout golp uses
+	teardownMsg []byte // SysEx command returning to the device's own mode
+}
+
+// sysex wraps a command in the model's SysEx header
+func (e *novationEncoding) sysex(data ...byte) []byte {
+	msg := append([]byte{}, novationHeader...)
+	msg = append(msg, e.device)
+	msg = append(msg, data...)
+	return append(msg, 0xF7)
+}
+
+// index returns the note or controller of a button
+func (e *novationEncoding) index(btn Button) byte {
+	if btn.IsTop {
+		return e.topCC + byte(btn.X)
+	}
+	if btn.IsScene {
+		return byte(10*(GridHeight-btn.Y) + 9)
+	}
+	return byte(10*(GridHeight-btn.Y) + btn.X + 1)
+}
+
+// usesCC reports whether a button sends and receives controller changes
+func (e *novationEncoding) usesCC(btn Button) bool {
+	return btn.IsTop || (btn.IsScene && e.sideCC)
+}
+
+// rgbEntry appends one LED's RGB entry to an LED SysEx command
+func (e *novationEncoding) rgbEntry(data []byte, btn Button, c RGB) []byte {
+	scale := func(v uint8) byte {
+		return byte((int(v)*int(e.rgbMax) + 127) / 255)
+	}
+	if e.colorSpecs {
+		data = append(data, novationSpecRGB)
+	}
+	return append(data, e.index(btn), scale(c.R), scale(c.G), scale(c.B))
+}
+
+// command returns the LED SysEx command byte
+func (e *novationEncoding) command() byte {
+	if e.colorSpecs {
+		return novationLEDSpecs
+	}
+	return novationLEDRGB
+}
+
+// flash returns the message flashing a palette color on an LED
+func (e *novationEncoding) flash(btn Button, state LEDState) []byte {
+	if e.usesCC(btn) {
+		return []byte{statusFlashCC, e.index(btn), paletteColor(state)}
+	}
+	return []byte{statusFlashNoteOn, e.index(btn), paletteColor(state)}
+}
+
+func (e *novationEncoding) reset() [][]byte {
+	var off Frame
+	return append([][]byte{e.sysex(e.setupMsg...)}, e.frame(&off)...)
+}
+
+func (e *novationEncoding) teardown() [][]byte {
+	if e.teardownMsg == nil {
+		return nil
+	}
+	return [][]byte{e.sysex(e.teardownMsg...)}
+}
+
+func (e *novationEncoding) led(btn Button, state LEDState) [][]byte {
+	if state.Flash {
+		// Flashing alternates with the static color, which must be off
+		return [][]byte{e.sysex(e.rgbEntry([]byte{e.command()}, btn, RGB{})...), e.flash(btn, state)}
+	}
+	return e.rgb(btn, state.RGB())
+}
+
+func (e *novationEncoding) rgb(btn Button, c RGB) [][]byte {
+	return [][]byte{e.sysex(e.rgbEntry([]byte{e.command()}, btn, c)...)}
+}
+
+func (e *novationEncoding) frame(f *Frame) [][]byte {
+	// All static colors fit in one message, followed by the flashing LEDs
+	data := []byte{e.command()}
+	var flashes [][]byte
+	for i, state := range f {
+		btn := FrameButton(i)
+		if state.Flash {
+			data = e.rgbEntry(data, btn, RGB{})
+			flashes = append(flashes, e.flash(btn, state))
+		} else {
+			data = e.rgbEntry(data, btn, state.RGB())
+		}
+	}
+	return append([][]byte{e.sysex(data...)}, flashes...)
+}
+
+func (e *novationEncoding) button(msg []byte) (ButtonEvent, bool) {
+	status, data1, data2 := msg[0], msg[1], msg[2]
+	btn, ok := e.indexButton(data1)
+	if !ok {
+		return ButtonEvent{}, false
+	}
+
+	switch status {
+	case statusNoteOn, statusNoteOff:
+		// Pads are velocity sensitive; any note-on velocity is a press
+		if !e.usesCC(btn) {
+			return ButtonEvent{Button: btn, Pressed: status == statusNoteOn && data2 > 0}, true
+		}
+	case statusControlChange:
+		if e.usesCC(btn) {
+			return ButtonEvent{Button: btn, Pressed: data2 > 0}, true
+		}
+	}
+	return ButtonEvent{}, false
+}
+
+// indexButton returns the button with a note, controller or LED index
+func (e *novationEncoding) indexButton(index byte) (Button, bool) {
+	if index >= e.topCC && index < e.topCC+TopButtons {
+		return NewTopButton(int(index - e.topCC)), true
+	}
+
+	row, col := int(index)/10, int(index)%10
+	switch {
+	case row < 1 || row > GridHeight || col < 1:
+		return Button{}, false
+	case col <= GridWidth:
+		return NewGridButton(col-1, GridHeight-row), true
+	case col == 9:
+		return NewSceneButton(GridHeight - row), true
+	}
+	return Button{}, false
+}
+
+// ledWrites decodes the LED updates in an outgoing message, reported with the
+// velocity of the nearest bi-color state
+func (e *novationEncoding) ledWrites(msg []byte) []LEDWrite {
+	// Flashing palette color
+	if len(msg) == 3 && (msg[0] == statusFlashNoteOn || msg[0] == statusFlashCC) {
+		btn, ok := e.indexButton(msg[1])
+		if !ok || e.usesCC(btn) != (msg[0] == statusFlashCC) {
+			return nil
+		}
+		state := paletteState(msg[2])
+		state.Flash = state.Red != 0 || state.Green != 0
+		return []LEDWrite{{Button: btn, Velocity: state.Velocity()}}
+	}
+
+	// LED SysEx: the command byte, then one entry per LED
+	prefix := append(append([]byte{}, novationHeader...), e.device, e.command())
+	if !bytes.HasPrefix(msg, prefix) || msg[len(msg)-1] != 0xF7 {
+		return nil
+	}
+	data := msg[len(prefix) : len(msg)-1]
+
+	var writes []LEDWrite
+	add := func(index byte, state LEDState) {
+		if btn, ok := e.indexButton(index); ok {
+			writes = append(writes, LEDWrite{Button: btn, Velocity: state.Velocity()})
+		}
+	}
+	rgb := func(r, g, b byte) LEDState {
+		scale := func(v byte) uint8 {
+			return uint8(min(255, (int(v)*255+int(e.rgbMax)/2)/int(e.rgbMax)))
+		}
+		return NearestLEDState(RGB{R: scale(r), G: scale(g), B: scale(b)})
+	}
+
+	for len(data) > 0 {
+		if !e.colorSpecs {
+			if len(data) < 4 {
+				break
+			}
+			add(data[0], rgb(data[1], data[2], data[3]))
+			data = data[4:]
+			continue
+		}
+
+		// Lighting types: 0 static, 1 flashing and 2 pulsing palette colors, 3 RGB
+		if len(data) < 3 {
+			break
+		}
+		switch data[0] {
+		case 0, 2:
+			add(data[1], paletteState(data[2]))
+			data = data[3:]
+		case 1:
+			if len(data) < 4 {
+				return writes
+			}
+			state := paletteState(data[2])
+			state.Flash = state.Red != 0 || state.Green != 0
+			add(data[1], state)
+			data = data[4:]
+		case novationSpecRGB:
+			if len(data) < 5 {
+				return writes
+			}
+			add(data[1], rgb(data[2], data[3], data[4]))
+			data = data[5:]
+		default:
+			return writes
+		}
+	}
+	return writes
+}
+
+// paletteColor returns the entry of the RGB models' default palette closest to
+// a bi-color LED state, for flashing
+// The palette holds each hue at full, medium and low brightness in consecutive entries
+func paletteColor(state LEDState) byte {
+	level := state.Red
+	if state.Green > level {
+		level = state.Green
+	}
+
+	var hue byte
+	switch {
+	case level == BrightnessOff:
+		return 0
+	case state.Green == 0:
+		hue = 5 // Red
+	case state.Red == 0:
+		hue = 21 // Green
+	case state.Red > state.Green:
+		hue = 9 // Orange
+	case state.Red < state.Green:
+		hue = 17 // Lime
+	default:
+		hue = 13 // Yellow, the bi-color amber
+	}
+	return hue + byte(BrightnessFull-level)
+}
+
+// paletteState returns the bi-color LED state of a palette entry sent by
+// paletteColor; other entries are reported as off
+func paletteState(color byte) LEDState {
+	if color < 5 || color > 23 || (color-5)%4 > 2 {
+		return LEDState{}
+	}
+
+	level := BrightnessFull - Brightness((color-5)%4)
+	switch (color - 5) / 4 {
+	case 0:
+		return LEDState{Red: level} // Red
+	case 1:
+		return LEDState{Red: level, Green: level - 1} // Orange
+	case 2:
+		return LEDState{Red: level, Green: level} // Yellow
+	case 3:
+		return LEDState{Red: level - 1, Green: level} // Lime
+	default:
+		return LEDState{Green: level} // Green
+	}
+}
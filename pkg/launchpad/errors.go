@@ -0,0 +1,40 @@
+package launchpad
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by Launchpad methods, for use with errors.Is
+var (
+	ErrNotOpen        = errors.New("launchpad not open")
+	ErrAlreadyOpen    = errors.New("launchpad already open")
+	ErrDeviceNotFound = errors.New("launchpad not found")
+	ErrUnsupported    = errors.New("not supported") // The model lacks the feature
+	ErrInvalid        = errors.New("invalid")       // An argument other than a button is out of range
+	ErrNoReply        = errors.New("no reply to device inquiry")
+)
+
+// InvalidButtonError reports a button outside the Launchpad's layout
+type InvalidButtonError struct {
+	Button Button
+}
+
+func (e *InvalidButtonError) Error() string {
+	return fmt.Sprintf("invalid button: %v", e.Button)
+}
+
+// TransportError reports a failure of the MIDI driver or ports
+type TransportError struct {
+	Op  string // What failed, e.g. "send" or "open input port"
+	Err error  // Error from the driver
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("MIDI %s: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the driver error
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
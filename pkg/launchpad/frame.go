@@ -0,0 +1,234 @@
+package launchpad
+
+import "fmt"
+
+// FrameSize is the number of LEDs in a Frame (64 grid + 8 scene + 8 top)
+const FrameSize = GridWidth*GridHeight + SceneButtons + TopButtons
+
+// Frame holds the LED state of all 80 buttons
+// Cells are stored in rapid-update order: the grid left-to-right and top-to-bottom,
+// then the scene buttons top-to-bottom, then the top buttons left-to-right
+type Frame [FrameSize]LEDState
+
+// FrameIndex returns the index of a button within a Frame, or -1 if the button is invalid
+func FrameIndex(btn Button) int {
+	if !btn.Valid() {
+		return -1
+	}
+	if btn.IsTop {
+		return GridWidth*GridHeight + SceneButtons + btn.X
+	}
+	if btn.IsScene {
+		return GridWidth*GridHeight + btn.Y
+	}
+	return (GridWidth * btn.Y) + btn.X
+}
+
+// FrameButton returns the button stored at a Frame index
+func FrameButton(index int) Button {
+	switch {
+	case index >= GridWidth*GridHeight+SceneButtons:
+		return NewTopButton(index - GridWidth*GridHeight - SceneButtons)
+	case index >= GridWidth*GridHeight:
+		return NewSceneButton(index - GridWidth*GridHeight)
+	default:
+		return NewGridButton(index%GridWidth, index/GridWidth)
+	}
+}
+
+// Get returns the LED state of a button (off for invalid buttons)
+func (f *Frame) Get(btn Button) LEDState {
+	i := FrameIndex(btn)
+	if i < 0 {
+		return LEDState{}
+	}
+	return f[i]
+}
+
+// Set sets the LED state of a button; invalid buttons are ignored
+func (f *Frame) Set(btn Button, state LEDState) {
+	i := FrameIndex(btn)
+	if i < 0 {
+		return
+	}
+	f[i] = state
+}
+
+// SetLED sets a grid LED from a color and brightness
+func (f *Frame) SetLED(x, y int, color Color, brightness Brightness) {
+	f.Set(NewGridButton(x, y), NewLEDState(color, brightness))
+}
+
+// Fill sets every LED in the frame to the same state
+func (f *Frame) Fill(state LEDState) {
+	for i := range f {
+		f[i] = state
+	}
+}
+
+// SetFrame writes a complete frame to the device using rapid LED update
+// All 80 LEDs are sent in 40 messages instead of 80
+func (lp *Launchpad) SetFrame(frame *Frame) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.midi == nil {
+		return ErrNotOpen
+	}
+
+	return lp.sendFrame(frame)
+}
+
+// UpdateFrame brings the device from the prev frame to the next frame
+// Only changed LEDs are sent, falling back to a rapid update when that is cheaper
+func (lp *Launchpad) UpdateFrame(prev, next *Frame) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.midi == nil {
+		return ErrNotOpen
+	}
+
+	var changed []int
+	for i := range next {
+		if next[i] != prev[i] {
+			changed = append(changed, i)
+		}
+	}
+
+	// A rapid update costs one message per LED pair plus one to leave the mode
+	if len(changed) > FrameSize/2 {
+		return lp.sendFrame(next)
+	}
+
+	for _, i := range changed {
+		err := lp.sendLEDState(FrameButton(i), next[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FrameWriter keeps the device showing the last frame drawn, remembering it so
+// that the next Draw only sends what changed
+// It is not safe for concurrent use; views call it under their own lock.
+type FrameWriter struct {
+	lp    *Launchpad
+	shown Frame // Frame currently on the device
+	known bool  // Whether shown is known
+}
+
+// NewFrameWriter creates a FrameWriter whose first Draw sends a full frame
+func NewFrameWriter(lp *Launchpad) *FrameWriter {
+	return &FrameWriter{lp: lp}
+}
+
+// Draw brings the device up to date with a frame, sending only changes or a
+// rapid update, whichever is faster
+// After an error the device state is unknown and the next Draw sends the full frame.
+func (w *FrameWriter) Draw(frame *Frame) error {
+	var err error
+	if w.known {
+		err = w.lp.UpdateFrame(&w.shown, frame)
+	} else {
+		err = w.lp.SetFrame(frame)
+	}
+	if err != nil {
+		w.known = false
+		return err
+	}
+
+	w.shown = *frame
+	w.known = true
+	return nil
+}
+
+// Invalidate forgets the frame on the device, so the next Draw sends it in full
+// Call it when something else may have drawn on the device.
+func (w *FrameWriter) Invalidate() {
+	w.known = false
+}
+
+// sendFrame sends a frame using rapid LED update, or the model's own frame
+// messages (caller must hold the lock)
+func (lp *Launchpad) sendFrame(frame *Frame) error {
+	// Other models light a whole frame with their own messages
+	if !lp.profile.Buffers {
+		for _, msg := range lp.profile.enc.frame(frame) {
+			if err := lp.midi.send(msg); err != nil {
+				return fmt.Errorf("failed to send frame: %w", err)
+			}
+		}
+		for i := range frame {
+			lp.recordLED(i, frame[i])
+		}
+		return nil
+	}
+
+	for i := 0; i < FrameSize; i += 2 {
+		s1 := lp.resolveWriteMode(frame[i])
+		s2 := lp.resolveWriteMode(frame[i+1])
+		err := lp.midi.sendMessage(statusNoteOnChannel3, s1.Velocity(), s2.Velocity())
+		if err != nil {
+			return fmt.Errorf("failed to send frame: %w", err)
+		}
+		lp.recordLED(i, s1)
+		lp.recordLED(i+1, s2)
+	}
+
+	// Leave rapid update mode so the next frame starts at the top-left again
+	// Re-sending the last LED as a standard message is harmless
+	return lp.sendLEDState(FrameButton(FrameSize-1), frame[FrameSize-1])
+}
+
+// CurrentFrame returns the LED state of the displayed buffer, as last written
+// The Launchpad cannot be queried, so this tracks what golp has sent
+func (lp *Launchpad) CurrentFrame() Frame {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.leds[lp.displayBuffer]
+}
+
+// BufferFrame returns the LED state of a buffer, as last written
+func (lp *Launchpad) BufferFrame(buffer BufferID) Frame {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if !buffer.Valid() {
+		return Frame{}
+	}
+	return lp.leds[buffer]
+}
+
+// recordLED tracks an LED write with a resolved write mode (caller must hold the lock)
+// Copy writes both buffers, Clear (and flash) clears the other buffer's copy
+func (lp *Launchpad) recordLED(i int, state LEDState) {
+	if i < 0 || i >= FrameSize {
+		return
+	}
+
+	mode := state.Mode
+	if state.Flash {
+		mode = WriteClear
+	}
+	state.Mode = WriteNormal
+
+	// Models without buffers show every write
+	if !lp.profile.Buffers {
+		lp.leds[Buffer0][i] = state
+		lp.leds[Buffer1][i] = state
+		return
+	}
+
+	update := lp.updateBuffer
+	other := 1 - update
+	lp.leds[update][i] = state
+
+	switch mode {
+	case WriteNormal, WriteCopy:
+		lp.leds[other][i] = state
+	case WriteClear:
+		lp.leds[other][i] = LEDState{}
+	}
+}
@@ -0,0 +1,60 @@
+package launchpad_test
+
+import (
+	"testing"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/simulator"
+)
+
+func TestFrameWriter(t *testing.T) {
+	sim := simulator.New()
+	lp := launchpad.New()
+	if err := lp.OpenPorts(sim.In(), sim.Out()); err != nil {
+		t.Fatal(err)
+	}
+	defer lp.Close()
+
+	sent := 0
+	lp.OnTraffic(func(tr launchpad.Traffic) {
+		if tr.Direction == launchpad.Outgoing {
+			sent++
+		}
+	})
+	w := launchpad.NewFrameWriter(lp)
+
+	var frame launchpad.Frame
+	frame.Set(launchpad.NewGridButton(1, 1), launchpad.LEDState{Red: launchpad.BrightnessFull})
+
+	tests := []struct {
+		name       string
+		change     func()
+		invalidate bool
+		want       int // Messages sent
+	}{
+		{"first draw is a full frame", nil, false, launchpad.FrameSize/2 + 1},
+		{"unchanged", nil, false, 0},
+		{"one LED", func() { frame.Set(launchpad.NewSceneButton(3), launchpad.LEDState{Green: launchpad.BrightnessLow}) }, false, 1},
+		{"invalidated", nil, true, launchpad.FrameSize/2 + 1},
+	}
+
+	for _, tt := range tests {
+		if tt.change != nil {
+			tt.change()
+		}
+		if tt.invalidate {
+			w.Invalidate()
+		}
+
+		sent = 0
+		if err := w.Draw(&frame); err != nil {
+			t.Fatal(err)
+		}
+		if sent != tt.want {
+			t.Errorf("%s: sent %d messages, want %d", tt.name, sent, tt.want)
+		}
+		if got := sim.Frame(); got != frame {
+			t.Errorf("%s: simulator shows %v, want %v", tt.name, got, frame)
+		}
+	}
+}
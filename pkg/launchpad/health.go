@@ -0,0 +1,121 @@
+package launchpad
+
+import (
+	"fmt"
+	"time"
+)
+
+// AsyncError is a failure that happened in the background, where no method
+// could return it: resetting on Close or handling input
+// Every other message is sent by the method that wrote it, which returns its error.
+type AsyncError struct {
+	Time time.Time
+	Op   string // What failed, e.g. "reset on close" or "listener"
+	Err  error
+}
+
+func (e *AsyncError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *AsyncError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorHandler is a function that handles background failures
+type ErrorHandler func(*AsyncError)
+
+// Health is a snapshot of the connection's counters
+type Health struct {
+	Open             bool      // The device is open
+	MessagesSent     uint64    // MIDI messages sent
+	MessagesReceived uint64    // MIDI messages received
+	EventsDropped    uint64    // Button events dropped because ButtonEvents was not read
+	Errors           uint64    // Background failures
+	LastError        error     // Most recent background failure, or nil
+	LastErrorTime    time.Time // Time of LastError
+	LastInput        time.Time // Time of the last message received, zero if none
+}
+
+// Errors returns a channel that receives background failures as *AsyncError
+// Failures are dropped when the channel is full; Health still counts them
+func (lp *Launchpad) Errors() <-chan error {
+	return lp.errChan
+}
+
+// OnError registers a handler for background failures
+// Failures may be reported while the Launchpad is locked, so handlers must
+// return quickly and must not call Launchpad methods
+func (lp *Launchpad) OnError(handler ErrorHandler) {
+	lp.healthMu.Lock()
+	defer lp.healthMu.Unlock()
+	lp.errorHandlers = append(lp.errorHandlers, handler)
+}
+
+// Health returns the connection's counters
+func (lp *Launchpad) Health() Health {
+	lp.mu.Lock()
+	open := lp.midi != nil
+	lp.mu.Unlock()
+
+	lp.healthMu.Lock()
+	defer lp.healthMu.Unlock()
+
+	h := lp.health
+	h.Open = open
+	return h
+}
+
+// reportError records a background failure and passes it to the Errors
+// channel and the error handlers
+func (lp *Launchpad) reportError(op string, err error) {
+	e := &AsyncError{Time: time.Now(), Op: op, Err: err}
+
+	lp.healthMu.Lock()
+	lp.health.Errors++
+	lp.health.LastError = e
+	lp.health.LastErrorTime = e.Time
+	handlers := make([]ErrorHandler, len(lp.errorHandlers))
+	copy(handlers, lp.errorHandlers)
+	lp.healthMu.Unlock()
+
+	select {
+	case lp.errChan <- e:
+	default:
+		// Channel full, drop error
+	}
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+}
+
+// observeTraffic counts a message and passes it to the traffic handlers
+func (lp *Launchpad) observeTraffic(dir Direction, data []byte) {
+	lp.healthMu.Lock()
+	if dir == Outgoing {
+		lp.health.MessagesSent++
+	} else {
+		lp.health.MessagesReceived++
+		lp.health.LastInput = time.Now()
+	}
+	lp.healthMu.Unlock()
+
+	lp.recordTraffic(dir, data)
+}
+
+// countDroppedEvent records a button event that was dropped
+func (lp *Launchpad) countDroppedEvent() {
+	lp.healthMu.Lock()
+	defer lp.healthMu.Unlock()
+	lp.health.EventsDropped++
+}
+
+// recoverListener reports a panic in input handling instead of crashing the
+// MIDI input goroutine
+func (lp *Launchpad) recoverListener() {
+	if r := recover(); r != nil {
+		lp.reportError("listener", fmt.Errorf("panic: %v", r))
+	}
+}
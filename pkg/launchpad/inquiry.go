@@ -0,0 +1,173 @@
+package launchpad
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeviceInquiryTimeout is how long Open and Identify wait for the device to
+// answer a device inquiry
+const DeviceInquiryTimeout = 250 * time.Millisecond
+
+// ManufacturerNovation is Novation's SysEx manufacturer ID
+const ManufacturerNovation = 0x002029
+
+// deviceInquiry is the universal SysEx device inquiry, addressed to all devices
+var deviceInquiry = []byte{0xF0, 0x7E, 0x7F, 0x06, 0x01, 0xF7}
+
+// SysExHandler is a function that handles incoming SysEx messages
+type SysExHandler func(msg []byte)
+
+// DeviceInfo is a device's reply to the universal SysEx device inquiry
+type DeviceInfo struct {
+	DeviceID     byte    // SysEx device ID the reply was sent from
+	Manufacturer uint32  // 1-byte ID, or 3-byte ID starting with 00 (ManufacturerNovation)
+	Family       uint16  // Product family, e.g. 0x0113 for the Launchpad Mini Mk3
+	Model        uint16  // Model within the family
+	Version      [4]byte // Firmware revision
+}
+
+// ParseDeviceInfo decodes an identity reply (F0 7E id 06 02 ... F7)
+func ParseDeviceInfo(msg []byte) (DeviceInfo, error) {
+	if len(msg) < 5 || msg[0] != 0xF0 || msg[1] != 0x7E || msg[3] != 0x06 || msg[4] != 0x02 || msg[len(msg)-1] != 0xF7 {
+		return DeviceInfo{}, fmt.Errorf("%w device identity reply: % X", ErrInvalid, msg)
+	}
+
+	info := DeviceInfo{DeviceID: msg[2]}
+	body := msg[5 : len(msg)-1]
+
+	// Manufacturer IDs starting with 00 are three bytes long
+	idLen := 1
+	if len(body) > 0 && body[0] == 0x00 {
+		idLen = 3
+	}
+	if len(body) != idLen+8 {
+		return DeviceInfo{}, fmt.Errorf("%w device identity reply length: % X", ErrInvalid, msg)
+	}
+	for _, b := range body[:idLen] {
+		info.Manufacturer = info.Manufacturer<<8 | uint32(b)
+	}
+
+	body = body[idLen:]
+	info.Family = uint16(body[1])<<8 | uint16(body[0])
+	info.Model = uint16(body[3])<<8 | uint16(body[2])
+	copy(info.Version[:], body[4:8])
+	return info, nil
+}
+
+// Profile returns the Launchpad model of the device, or nil if it is not a known Launchpad
+func (d DeviceInfo) Profile() *Profile {
+	if d.Manufacturer != ManufacturerNovation {
+		return nil
+	}
+	return ProfileForFamily(d.Family)
+}
+
+// Firmware returns the firmware revision, e.g. "0462"
+// Launchpads report one decimal digit per byte; other values are joined with dots
+func (d DeviceInfo) Firmware() string {
+	digits := true
+	for _, b := range d.Version {
+		if b > 9 {
+			digits = false
+		}
+	}
+	if digits {
+		return fmt.Sprintf("%d%d%d%d", d.Version[0], d.Version[1], d.Version[2], d.Version[3])
+	}
+
+	parts := make([]string, len(d.Version))
+	for i, b := range d.Version {
+		parts[i] = fmt.Sprint(b)
+	}
+	return strings.Join(parts, ".")
+}
+
+// String returns the model and firmware, e.g. "Launchpad X (family 0103 model 0000) firmware 0462"
+func (d DeviceInfo) String() string {
+	name := fmt.Sprintf("manufacturer %06X", d.Manufacturer)
+	if p := d.Profile(); p != nil {
+		name = p.Name
+	}
+	return fmt.Sprintf("%s (family %04X model %04X) firmware %s", name, d.Family, d.Model, d.Firmware())
+}
+
+// DeviceInfo returns the device's identity, as reported when it was opened
+// or by the last Identify; ok is false if the device never answered
+func (lp *Launchpad) DeviceInfo() (info DeviceInfo, ok bool) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.info, lp.hasInfo
+}
+
+// Identify sends a device inquiry and waits for the reply
+// The original Launchpad and some early firmware do not answer
+// LED updates and button events carry on while it waits
+func (lp *Launchpad) Identify() (DeviceInfo, error) {
+	info, err := lp.inquire(lp.Send)
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.info = info
+	lp.hasInfo = true
+	return info, nil
+}
+
+// OnSysEx registers a handler for incoming SysEx messages, including identity replies
+// Handlers run on the MIDI input goroutine
+func (lp *Launchpad) OnSysEx(handler SysExHandler) {
+	lp.sysexMu.Lock()
+	defer lp.sysexMu.Unlock()
+	lp.sysexHandlers = append(lp.sysexHandlers, handler)
+}
+
+// inquire sends a device inquiry with send and waits for the reply
+// It must be called without the main lock, which the listener needs to
+// deliver button events arriving before the reply
+func (lp *Launchpad) inquire(send func([]byte) error) (DeviceInfo, error) {
+	lp.inquiryMu.Lock()
+	defer lp.inquiryMu.Unlock()
+
+	// Drop a reply left over from an earlier inquiry
+	select {
+	case <-lp.identity:
+	default:
+	}
+
+	if err := send(deviceInquiry); err != nil {
+		return DeviceInfo{}, fmt.Errorf("failed to send device inquiry: %w", err)
+	}
+
+	select {
+	case info := <-lp.identity:
+		return info, nil
+	case <-time.After(DeviceInquiryTimeout):
+		return DeviceInfo{}, ErrNoReply
+	}
+}
+
+// handleSysEx passes identity replies to a waiting inquiry and calls the SysEx handlers
+// It does not take the main lock, so replies are never held up by LED updates
+func (lp *Launchpad) handleSysEx(msg []byte) {
+	if info, err := ParseDeviceInfo(msg); err == nil {
+		select {
+		case lp.identity <- info:
+		default:
+			// Nobody is waiting and a reply is already pending
+		}
+	}
+
+	lp.sysexMu.Lock()
+	handlers := make([]SysExHandler, len(lp.sysexHandlers))
+	copy(handlers, lp.sysexHandlers)
+	lp.sysexMu.Unlock()
+
+	for _, handler := range handlers {
+		handler(bytes.Clone(msg))
+	}
+}
@@ -14,31 +14,21 @@ func (lp *Launchpad) SetButtonLED(btn Button, color Color, brightness Brightness
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
 	}
 
 	if !btn.Valid() {
-		return fmt.Errorf("invalid button: %v", btn)
+		return &InvalidButtonError{Button: btn}
 	}
 
 	if !brightness.Valid() {
-		return fmt.Errorf("invalid brightness: %v", brightness)
+		return fmt.Errorf("%w brightness: %v", ErrInvalid, brightness)
 	}
 
 	// Create LED state
 	state := NewLEDState(color, brightness)
-	velocity := state.Velocity()
-
-	// Send appropriate MIDI message based on button type
-	if btn.IsTop {
-		// Top row uses controller change
-		controller := byte(btn.MIDIController())
-		return lp.sendControlChange(controller, velocity)
-	} else {
-		// Grid and scene buttons use note-on
-		key := byte(btn.MIDIKey())
-		return lp.sendNoteOn(key, velocity)
-	}
+
+	return lp.sendLEDState(btn, state)
 }
 
 // SetLEDState sets the LED state using a custom LEDState (for advanced control)
@@ -53,22 +43,33 @@ func (lp *Launchpad) SetButtonLEDState(btn Button, state LEDState) error {
 	defer lp.mu.Unlock()
 
 	if lp.midi == nil {
-		return fmt.Errorf("launchpad not open")
+		return ErrNotOpen
 	}
 
 	if !btn.Valid() {
-		return fmt.Errorf("invalid button: %v", btn)
+		return &InvalidButtonError{Button: btn}
 	}
 
-	velocity := state.Velocity()
+	if !state.Mode.Valid() {
+		return fmt.Errorf("%w write mode: %v", ErrInvalid, state.Mode)
+	}
+
+	return lp.sendLEDState(btn, state)
+}
+
+// sendLEDState sends an LED state to a button, resolving its write mode against
+// the current buffer configuration (caller must hold the lock)
+func (lp *Launchpad) sendLEDState(btn Button, state LEDState) error {
+	state = lp.resolveWriteMode(state)
 
-	if btn.IsTop {
-		controller := byte(btn.MIDIController())
-		return lp.sendControlChange(controller, velocity)
-	} else {
-		key := byte(btn.MIDIKey())
-		return lp.sendNoteOn(key, velocity)
+	for _, msg := range lp.profile.enc.led(btn, state) {
+		if err := lp.midi.send(msg); err != nil {
+			return err
+		}
 	}
+
+	lp.recordLED(FrameIndex(btn), state)
+	return nil
 }
 
 // Clear turns off all LEDs
@@ -124,7 +125,7 @@ func (lp *Launchpad) SetAllLEDs(color Color, brightness Brightness) error {
 // SetRow sets all LEDs in a row to the same color and brightness
 func (lp *Launchpad) SetRow(y int, color Color, brightness Brightness) error {
 	if y < 0 || y >= GridHeight {
-		return fmt.Errorf("invalid row: %d", y)
+		return fmt.Errorf("%w row: %d", ErrInvalid, y)
 	}
 
 	for x := 0; x < GridWidth; x++ {
@@ -139,7 +140,7 @@ func (lp *Launchpad) SetRow(y int, color Color, brightness Brightness) error {
 // SetColumn sets all LEDs in a column to the same color and brightness
 func (lp *Launchpad) SetColumn(x int, color Color, brightness Brightness) error {
 	if x < 0 || x >= GridWidth {
-		return fmt.Errorf("invalid column: %d", x)
+		return fmt.Errorf("%w column: %d", ErrInvalid, x)
 	}
 
 	for y := 0; y < GridHeight; y++ {
@@ -154,7 +155,7 @@ func (lp *Launchpad) SetColumn(x int, color Color, brightness Brightness) error
 // SetSceneButton sets the LED for a scene button (right column)
 func (lp *Launchpad) SetSceneButton(y int, color Color, brightness Brightness) error {
 	if y < 0 || y >= SceneButtons {
-		return fmt.Errorf("invalid scene button index: %d", y)
+		return &InvalidButtonError{Button: NewSceneButton(y)}
 	}
 
 	btn := NewSceneButton(y)
@@ -164,7 +165,7 @@ func (lp *Launchpad) SetSceneButton(y int, color Color, brightness Brightness) e
 // SetTopButton sets the LED for a top row button
 func (lp *Launchpad) SetTopButton(x int, color Color, brightness Brightness) error {
 	if x < 0 || x >= TopButtons {
-		return fmt.Errorf("invalid top button index: %d", x)
+		return &InvalidButtonError{Button: NewTopButton(x)}
 	}
 
 	btn := NewTopButton(x)
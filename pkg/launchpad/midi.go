@@ -2,6 +2,7 @@ package launchpad
 
 import (
 	"fmt"
+	"strings"
 
 	"gitlab.com/gomidi/midi/v2"
 	"gitlab.com/gomidi/midi/v2/drivers"
@@ -10,11 +11,12 @@ import (
 
 // midiConnection wraps a MIDI input/output connection
 type midiConnection struct {
-	in  drivers.In
-	out drivers.Out
+	in        drivers.In
+	out       drivers.Out
+	onTraffic func(dir Direction, data []byte) // Called for every message sent or received
 }
 
-// findLaunchpad searches for a connected Launchpad Mini device
+// findLaunchpad searches for a connected Launchpad device
 // Returns the input and output ports if found
 func findLaunchpad() (drivers.In, drivers.Out, error) {
 	ins := midi.GetInPorts()
@@ -25,28 +27,26 @@ func findLaunchpad() (drivers.In, drivers.Out, error) {
 	for _, port := range ins {
 		name := port.String()
 		// Launchpad Mini typically shows up as "Launchpad Mini" or similar
-		if containsLaunchpad(name) {
+		if containsLaunchpad(name) && (inPort == nil || preferPort(inPort.String(), name)) {
 			inPort = port
-			break
 		}
 	}
 
 	if inPort == nil {
-		return nil, nil, fmt.Errorf("launchpad input port not found")
+		return nil, nil, fmt.Errorf("%w: no input port", ErrDeviceNotFound)
 	}
 
 	// Look for matching output port
 	var outPort drivers.Out
 	for _, port := range outs {
 		name := port.String()
-		if containsLaunchpad(name) {
+		if containsLaunchpad(name) && (outPort == nil || preferPort(outPort.String(), name)) {
 			outPort = port
-			break
 		}
 	}
 
 	if outPort == nil {
-		return nil, nil, fmt.Errorf("launchpad output port not found")
+		return nil, nil, fmt.Errorf("%w: no output port", ErrDeviceNotFound)
 	}
 
 	return inPort, outPort, nil
@@ -79,22 +79,26 @@ func containsLaunchpad(name string) bool {
 	return false
 }
 
-// openMIDI opens MIDI input and output connections to the Launchpad
-func openMIDI() (*midiConnection, error) {
-	inPort, outPort, err := findLaunchpad()
-	if err != nil {
-		return nil, fmt.Errorf("failed to find launchpad: %w", err)
+// preferPort reports whether a Launchpad port should replace the one found so far
+// Mini Mk3 and X models also have a DAW port, which golp does not use
+func preferPort(found, name string) bool {
+	isDAW := func(name string) bool {
+		return strings.Contains(strings.ToLower(name), "daw")
 	}
+	return isDAW(found) && !isDAW(name)
+}
 
-	err = inPort.Open()
+// openMIDI opens MIDI input and output connections to the Launchpad
+func openMIDI(inPort drivers.In, outPort drivers.Out) (*midiConnection, error) {
+	err := inPort.Open()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open input port: %w", err)
+		return nil, &TransportError{Op: "open input port", Err: err}
 	}
 
 	err = outPort.Open()
 	if err != nil {
 		inPort.Close()
-		return nil, fmt.Errorf("failed to open output port: %w", err)
+		return nil, &TransportError{Op: "open output port", Err: err}
 	}
 
 	return &midiConnection{
@@ -121,7 +125,19 @@ func (mc *midiConnection) close() error {
 
 // sendMessage sends a 3-byte MIDI message to the Launchpad
 func (mc *midiConnection) sendMessage(status, data1, data2 byte) error {
-	return mc.out.Send([]byte{status, data1, data2})
+	return mc.send([]byte{status, data1, data2})
+}
+
+// send sends a raw MIDI message to the Launchpad
+func (mc *midiConnection) send(msg []byte) error {
+	err := mc.out.Send(msg)
+	if err != nil {
+		return &TransportError{Op: "send", Err: err}
+	}
+	if mc.onTraffic != nil {
+		mc.onTraffic(Outgoing, msg)
+	}
+	return nil
 }
 
 // sendNoteOn sends a note-on message (LED control)
@@ -145,12 +161,18 @@ func (mc *midiConnection) sendControlChange(controller, data byte) error {
 func (mc *midiConnection) startListening(handler func([]byte)) (func(), error) {
 	// Set up a listener that calls the handler for each message
 	stop, err := midi.ListenTo(mc.in, func(msg midi.Message, timestampms int32) {
+		if len(msg) == 0 {
+			return
+		}
+		if mc.onTraffic != nil {
+			mc.onTraffic(Incoming, msg)
+		}
 		// Message is already a []byte alias, pass it directly
 		handler([]byte(msg))
-	})
+	}, midi.UseSysEx())
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to start MIDI listener: %w", err)
+		return nil, &TransportError{Op: "listen", Err: err}
 	}
 
 	return stop, nil
@@ -0,0 +1,251 @@
+package launchpad
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColorSupport describes which colors a model's LEDs can show
+type ColorSupport int
+
+const (
+	ColorsRedGreen ColorSupport = iota // Bi-color LEDs with four red and four green levels
+	ColorsRGB                          // Full RGB LEDs
+)
+
+// String returns the string representation of a ColorSupport
+func (c ColorSupport) String() string {
+	switch c {
+	case ColorsRedGreen:
+		return "red/green"
+	case ColorsRGB:
+		return "RGB"
+	default:
+		return fmt.Sprintf("ColorSupport(%d)", c)
+	}
+}
+
+// Profile describes a Launchpad model: its layout, LED colors and MIDI encoding
+//
+// Every model is driven through the same Button coordinates and LED states:
+// golp translates them to the model's note layout and messages, so the
+// high-level API works unchanged on all of them.
+type Profile struct {
+	Name       string       // Model name
+	Families   []uint16     // Family codes in replies to a SysEx device inquiry
+	GridWidth  int          // Grid columns
+	GridHeight int          // Grid rows
+	Colors     ColorSupport // LED colors
+	Buffers    bool         // Double-buffering, buffer flash mode, duty cycle, drum layout and rapid LED update
+
+	ports []string // Lowercase port name fragments identifying the model
+	enc   encoding
+}
+
+// String returns the model name
+func (p *Profile) String() string {
+	return p.Name
+}
+
+// Supported models
+var (
+	// ProfileMini is the original Launchpad, the Launchpad S and the Launchpad Mini
+	// up to Mk2, which share the protocol golp was written for
+	ProfileMini = &Profile{
+		Name:       "Launchpad Mini",
+		Families:   []uint16{0x0020, 0x0036}, // Launchpad S, Launchpad Mini
+		GridWidth:  GridWidth,
+		GridHeight: GridHeight,
+		Colors:     ColorsRedGreen,
+		Buffers:    true,
+		ports:      []string{"launchpad"},
+		enc:        miniEncoding{},
+	}
+
+	// ProfileMk2 is the Launchpad Mk2, used in its Session layout
+	ProfileMk2 = &Profile{
+		Name:       "Launchpad Mk2",
+		Families:   []uint16{0x0069},
+		GridWidth:  GridWidth,
+		GridHeight: GridHeight,
+		Colors:     ColorsRGB,
+		ports:      []string{"launchpad mk2"},
+		enc: &novationEncoding{
+			device:   0x18,
+			rgbMax:   63,
+			topCC:    104,
+			setupMsg: []byte{0x22, 0x00}, // Session layout
+		},
+	}
+
+	// ProfileMiniMk3 is the Launchpad Mini Mk3, used in Programmer mode
+	ProfileMiniMk3 = &Profile{
+		Name:       "Launchpad Mini Mk3",
+		Families:   []uint16{0x0113},
+		GridWidth:  GridWidth,
+		GridHeight: GridHeight,
+		Colors:     ColorsRGB,
+		ports:      []string{"mini mk3", "lpminimk3"},
+		enc: &novationEncoding{
+			device:      0x0D,
+			colorSpecs:  true,
+			rgbMax:      127,
+			topCC:       91,
+			sideCC:      true,
+			setupMsg:    []byte{0x0E, 0x01}, // Programmer mode
+			teardownMsg: []byte{0x0E, 0x00}, // Live mode
+		},
+	}
+
+	// ProfileX is the Launchpad X, used in Programmer mode
+	ProfileX = &Profile{
+		Name:       "Launchpad X",
+		Families:   []uint16{0x0103},
+		GridWidth:  GridWidth,
+		GridHeight: GridHeight,
+		Colors:     ColorsRGB,
+		ports:      []string{"launchpad x", "lpx"},
+		enc: &novationEncoding{
+			device:      0x0C,
+			colorSpecs:  true,
+			rgbMax:      127,
+			topCC:       91,
+			sideCC:      true,
+			setupMsg:    []byte{0x0E, 0x01}, // Programmer mode
+			teardownMsg: []byte{0x0E, 0x00}, // Live mode
+		},
+	}
+)
+
+// Profiles returns the supported models, most specific port names first
+func Profiles() []*Profile {
+	return []*Profile{ProfileMiniMk3, ProfileX, ProfileMk2, ProfileMini}
+}
+
+// ProfileForPort returns the model matching a MIDI port name, or nil if the
+// name is not a known Launchpad
+func ProfileForPort(name string) *Profile {
+	lower := strings.ToLower(name)
+	for _, p := range Profiles() {
+		for _, fragment := range p.ports {
+			if strings.Contains(lower, fragment) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// ProfileForFamily returns the model with a device inquiry family code, or nil if unknown
+func ProfileForFamily(family uint16) *Profile {
+	for _, p := range Profiles() {
+		for _, f := range p.Families {
+			if f == family {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// SetProfile selects the model to drive instead of detecting it when opening
+// A nil profile restores detection
+func (lp *Launchpad) SetProfile(p *Profile) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.midi != nil {
+		return ErrAlreadyOpen
+	}
+	lp.chosenProfile = p
+	if p != nil {
+		lp.profile = p
+		lp.setTrafficProfile(p)
+	}
+	return nil
+}
+
+// Profile returns the model being driven
+// Before opening, this is the profile set with SetProfile or ProfileMini
+func (lp *Launchpad) Profile() *Profile {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	return lp.profile
+}
+
+// requireBuffers returns an error if the model lacks the Mini's buffer and
+// system commands (caller must hold the lock)
+func (lp *Launchpad) requireBuffers(feature string) error {
+	if lp.profile.Buffers {
+		return nil
+	}
+	return fmt.Errorf("%s %w by %s", feature, ErrUnsupported, lp.profile.Name)
+}
+
+// RGB is a 24-bit LED color
+// Colors are scaled to the model's range, and shown with the nearest red and
+// green levels on bi-color models
+type RGB struct {
+	R, G, B uint8
+}
+
+// String returns the color in hex, e.g. "#FF8000"
+func (c RGB) String() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// rgbLevels are the RGB values of the four brightness levels
+var rgbLevels = [4]uint8{0, 85, 170, 255}
+
+// RGB returns the color of an LED state on RGB models
+func (s LEDState) RGB() RGB {
+	return RGB{R: rgbLevels[s.Red&3], G: rgbLevels[s.Green&3]}
+}
+
+// NearestLEDState returns the bi-color LED state closest to an RGB color
+// Blue has no bi-color equivalent and is shown as amber at half its level
+func NearestLEDState(c RGB) LEDState {
+	level := func(v int) Brightness {
+		if v > 255 {
+			v = 255
+		}
+		return Brightness((v*3 + 127) / 255)
+	}
+	return LEDState{
+		Red:   level(int(c.R) + int(c.B)/2),
+		Green: level(int(c.G) + int(c.B)/2),
+	}
+}
+
+// SetLEDRGB sets a grid LED to an RGB color
+func (lp *Launchpad) SetLEDRGB(x, y int, c RGB) error {
+	return lp.SetButtonRGB(NewGridButton(x, y), c)
+}
+
+// SetButtonRGB sets any button's LED to an RGB color
+// Bi-color models show the nearest red and green levels; the tracked LED state
+// is always that nearest state
+func (lp *Launchpad) SetButtonRGB(btn Button, c RGB) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.midi == nil {
+		return ErrNotOpen
+	}
+
+	if !btn.Valid() {
+		return &InvalidButtonError{Button: btn}
+	}
+
+	if lp.profile.Colors != ColorsRGB {
+		return lp.sendLEDState(btn, NearestLEDState(c))
+	}
+
+	for _, msg := range lp.profile.enc.rgb(btn, c) {
+		if err := lp.midi.send(msg); err != nil {
+			return err
+		}
+	}
+	lp.recordLED(FrameIndex(btn), NearestLEDState(c))
+	return nil
+}
@@ -0,0 +1,139 @@
+package launchpad_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/simulator"
+)
+
+// lit strips the flash bit, which is never sent to the device
+func lit(frame launchpad.Frame) launchpad.Frame {
+	for i := range frame {
+		frame[i].Flash = false
+	}
+	return frame
+}
+
+func TestRestore(t *testing.T) {
+	var pattern launchpad.Frame
+	for i := range pattern {
+		pattern[i] = launchpad.LEDState{Red: launchpad.Brightness(i % 4), Green: launchpad.Brightness(3 - i%4)}
+	}
+	few := pattern
+	few.Set(launchpad.NewGridButton(2, 2), launchpad.LEDState{})
+	few.Set(launchpad.NewSceneButton(6), launchpad.LEDState{Red: launchpad.BrightnessFull, Flash: true})
+	few.Set(launchpad.NewTopButton(1), launchpad.LEDState{Green: launchpad.BrightnessFull})
+
+	var sparse launchpad.Frame
+	sparse.Set(launchpad.NewGridButton(0, 0), launchpad.LEDState{Red: launchpad.BrightnessFull})
+	sparse.Set(launchpad.NewTopButton(7), launchpad.LEDState{Green: launchpad.BrightnessLow, Flash: true})
+
+	duty := launchpad.DutyCycle{Numerator: 1, Denominator: 5}
+	tests := []struct {
+		name      string
+		snap      launchpad.Snapshot
+		wantRapid int // Rapid update messages
+		wantLEDs  int // Single LED messages
+	}{
+		{
+			"rapid update",
+			launchpad.Snapshot{Buffers: [2]launchpad.Frame{pattern, pattern}, DutyCycle: duty},
+			launchpad.FrameSize / 2, 1,
+		},
+		{
+			"per LED",
+			launchpad.Snapshot{Buffers: [2]launchpad.Frame{sparse, sparse}, DutyCycle: duty},
+			0, 2,
+		},
+		{
+			// Buffer 0 is written to both buffers, so buffer 1 only needs its
+			// three differences
+			"rapid then per LED",
+			launchpad.Snapshot{Buffers: [2]launchpad.Frame{pattern, few}, DisplayBuffer: launchpad.Buffer1, MappingMode: launchpad.MappingDrum, DutyCycle: duty},
+			launchpad.FrameSize / 2, 1 + 3,
+		},
+		{
+			"update buffer only",
+			launchpad.Snapshot{Buffers: [2]launchpad.Frame{{}, sparse}, UpdateBuffer: launchpad.Buffer1, DutyCycle: duty},
+			0, 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := simulator.New()
+			lp := launchpad.New()
+			if err := lp.OpenPorts(sim.In(), sim.Out()); err != nil {
+				t.Fatal(err)
+			}
+			defer lp.Close()
+
+			var mu sync.Mutex
+			var rapid, leds int
+			lp.OnTraffic(func(tr launchpad.Traffic) {
+				if tr.Direction != launchpad.Outgoing || len(tr.Data) != 3 {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case tr.Data[0] == 0x92:
+					rapid++
+				case tr.Data[0] == 0x90, tr.Data[0] == 0xB0 && tr.Data[1] >= 0x68:
+					leds++
+				}
+			})
+
+			if err := lp.Restore(tt.snap); err != nil {
+				t.Fatal(err)
+			}
+
+			mu.Lock()
+			if rapid != tt.wantRapid || leds != tt.wantLEDs {
+				t.Errorf("sent %d rapid update and %d LED messages, want %d and %d", rapid, leds, tt.wantRapid, tt.wantLEDs)
+			}
+			mu.Unlock()
+
+			if got := lp.Snapshot(); got != tt.snap {
+				t.Errorf("Snapshot() = %+v, want %+v", got, tt.snap)
+			}
+			if got := sim.MappingMode(); got != tt.snap.MappingMode {
+				t.Errorf("simulator mapping = %v, want %v", got, tt.snap.MappingMode)
+			}
+
+			// Show each buffer in turn to check what the device holds
+			for _, b := range []launchpad.BufferID{tt.snap.DisplayBuffer, 1 - tt.snap.DisplayBuffer} {
+				if err := lp.SetDisplayBuffer(b); err != nil {
+					t.Fatal(err)
+				}
+				if got, want := sim.Frame(), lit(tt.snap.Buffers[b]); got != want {
+					t.Errorf("simulator %v = %v, want %v", b, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreNothingChanged(t *testing.T) {
+	sim := simulator.New()
+	lp := launchpad.New()
+	if err := lp.OpenPorts(sim.In(), sim.Out()); err != nil {
+		t.Fatal(err)
+	}
+	defer lp.Close()
+
+	sent := 0
+	lp.OnTraffic(func(tr launchpad.Traffic) {
+		if tr.Direction == launchpad.Outgoing {
+			sent++
+		}
+	})
+	if err := lp.Restore(lp.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if sent != 0 {
+		t.Fatalf("restoring the current state sent %d messages", sent)
+	}
+}
@@ -0,0 +1,344 @@
+package launchpad
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SnapshotVersion is the version of the snapshot encoding written by this package
+const SnapshotVersion = 1
+
+// Snapshot is the complete state of a Launchpad, as tracked by golp
+type Snapshot struct {
+	Buffers       [2]Frame    // LED state of each buffer
+	DisplayBuffer BufferID    // Buffer being displayed
+	UpdateBuffer  BufferID    // Buffer receiving LED updates
+	Flash         bool        // Auto-flash mode
+	MappingMode   MappingMode // Button layout
+	DutyCycle     DutyCycle   // Low-brightness duty cycle
+}
+
+// Snapshot returns the current device state
+func (lp *Launchpad) Snapshot() Snapshot {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	return Snapshot{
+		Buffers:       lp.leds,
+		DisplayBuffer: lp.displayBuffer,
+		UpdateBuffer:  lp.updateBuffer,
+		Flash:         lp.flashEnabled,
+		MappingMode:   lp.mappingMode,
+		DutyCycle:     lp.dutyCycle,
+	}
+}
+
+// Restore brings the device to a snapshot's state
+//
+// Only the settings and LEDs that differ from the current state are sent, so
+// restoring onto a freshly opened Launchpad costs nothing for LEDs that are off.
+// Each buffer's LEDs are written without touching the other buffer, then the
+// display and update buffers and flash mode are set in a single command.
+// Models without double-buffering show the displayed buffer and ignore the
+// other settings.
+func (lp *Launchpad) Restore(s Snapshot) error {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	if lp.midi == nil {
+		return ErrNotOpen
+	}
+
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	// Models without buffers only show the displayed buffer
+	if !lp.profile.Buffers {
+		frame := s.Buffers[s.DisplayBuffer]
+		return lp.sendFrame(&frame)
+	}
+
+	if s.MappingMode != lp.mappingMode {
+		data := byte(systemLayoutXY)
+		if s.MappingMode == MappingDrum {
+			data = systemLayoutDrum
+		}
+		if err := lp.sendControlChange(controllerSystem, data); err != nil {
+			return fmt.Errorf("failed to restore mapping mode: %w", err)
+		}
+		lp.mappingMode = s.MappingMode
+	}
+
+	if s.DutyCycle != lp.dutyCycle {
+		if err := lp.sendDutyCycle(s.DutyCycle); err != nil {
+			return fmt.Errorf("failed to restore duty cycle: %w", err)
+		}
+		lp.dutyCycle = s.DutyCycle
+	}
+
+	// When both buffers change, the first is written to both so the second
+	// only needs the LEDs where they differ
+	both := lp.leds[Buffer0] != s.Buffers[Buffer0] && lp.leds[Buffer1] != s.Buffers[Buffer1]
+	for b := Buffer0; b <= Buffer1; b++ {
+		if lp.leds[b] == s.Buffers[b] {
+			continue
+		}
+		// Display and update the same buffer with flash off so that
+		// writes land exactly where they are meant to
+		if err := lp.sendBufferCommand(b, b, false); err != nil {
+			return fmt.Errorf("failed to restore buffers: %w", err)
+		}
+		if err := lp.restoreBuffer(b, &s.Buffers[b], both); err != nil {
+			return err
+		}
+		both = false
+	}
+
+	if s.DisplayBuffer != lp.displayBuffer || s.UpdateBuffer != lp.updateBuffer || s.Flash != lp.flashEnabled {
+		if err := lp.sendBufferCommand(s.DisplayBuffer, s.UpdateBuffer, s.Flash); err != nil {
+			return fmt.Errorf("failed to restore buffers: %w", err)
+		}
+	}
+	return nil
+}
+
+// sendBufferCommand selects the display and update buffers and flash mode,
+// recording the new configuration (caller must hold the lock)
+func (lp *Launchpad) sendBufferCommand(display, update BufferID, flash bool) error {
+	flags := 0
+	if flash {
+		flags = bufferFlagFlash
+	}
+
+	// Formula: data = (4 × update) + display + 32 + flags
+	data := byte((4 * int(update)) + int(display) + bufferBase + flags)
+	if err := lp.sendControlChange(controllerSystem, data); err != nil {
+		return err
+	}
+
+	lp.displayBuffer = display
+	lp.updateBuffer = update
+	lp.flashEnabled = flash
+	return nil
+}
+
+// restoreBuffer writes a frame to a buffer, or to both buffers if both is true,
+// sending only the LEDs that differ from the tracked state (caller must hold
+// the lock and have selected the buffer for both display and update)
+func (lp *Launchpad) restoreBuffer(buffer BufferID, frame *Frame, both bool) error {
+	mode := WriteUpdateOnly
+	if both {
+		mode = WriteNormal
+	}
+
+	var changed []int
+	for i := range frame {
+		if frame[i] != lp.leds[buffer][i] || (both && frame[i] != lp.leds[1-buffer][i]) {
+			changed = append(changed, i)
+		}
+	}
+
+	// Flashing is carried by the contents of the two buffers, so the flash bit
+	// is only kept in the tracked state and never sent
+	velocity := func(i int) byte {
+		return LEDState{Red: frame[i].Red, Green: frame[i].Green, Mode: mode}.Velocity()
+	}
+	send := func(i int) error {
+		btn := FrameButton(i)
+		if btn.IsTop {
+			return lp.sendControlChange(byte(btn.MIDIController()), velocity(i))
+		}
+		return lp.sendNoteOn(byte(btn.MIDIKey()), velocity(i))
+	}
+
+	// A rapid update costs one message per LED pair plus one to leave the mode
+	if len(changed) > FrameSize/2 {
+		for i := 0; i < FrameSize; i += 2 {
+			err := lp.midi.sendMessage(statusNoteOnChannel3, velocity(i), velocity(i+1))
+			if err != nil {
+				return fmt.Errorf("failed to restore %v: %w", buffer, err)
+			}
+		}
+		changed = []int{FrameSize - 1}
+	}
+
+	for _, i := range changed {
+		if err := send(i); err != nil {
+			return fmt.Errorf("failed to restore %v: %w", buffer, err)
+		}
+	}
+
+	lp.leds[buffer] = *frame
+	if both {
+		lp.leds[1-buffer] = *frame
+	}
+	return nil
+}
+
+// validate checks that a snapshot can be sent to the device
+func (s *Snapshot) validate() error {
+	if !s.DisplayBuffer.Valid() {
+		return fmt.Errorf("%w display buffer: %v", ErrInvalid, s.DisplayBuffer)
+	}
+	if !s.UpdateBuffer.Valid() {
+		return fmt.Errorf("%w update buffer: %v", ErrInvalid, s.UpdateBuffer)
+	}
+	if s.MappingMode != MappingXY && s.MappingMode != MappingDrum {
+		return fmt.Errorf("%w mapping mode: %v", ErrInvalid, s.MappingMode)
+	}
+	if !s.DutyCycle.Valid() {
+		return fmt.Errorf("%w duty cycle: %v", ErrInvalid, s.DutyCycle)
+	}
+	for b := range s.Buffers {
+		for i, state := range s.Buffers[b] {
+			if !state.Red.Valid() || !state.Green.Valid() {
+				return fmt.Errorf("%w LED state in %v: %v: %v", ErrInvalid, BufferID(b), FrameButton(i), state)
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotJSON is the on-disk format of a Snapshot
+// Buffers hold one hex byte per LED in Frame order: 16 × green + red, plus 8 when flashing
+type snapshotJSON struct {
+	Version       int       `json:"version"`
+	Mapping       string    `json:"mapping"`
+	DutyCycle     [2]int    `json:"duty_cycle"`
+	DisplayBuffer BufferID  `json:"display_buffer"`
+	UpdateBuffer  BufferID  `json:"update_buffer"`
+	Flash         bool      `json:"flash"`
+	Buffers       [2]string `json:"buffers"`
+}
+
+// snapshotMappings names mapping modes in the on-disk format
+var snapshotMappings = map[MappingMode]string{
+	MappingXY:   "xy",
+	MappingDrum: "drum",
+}
+
+// MarshalJSON encodes a snapshot in the versioned on-disk format
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	mapping, ok := snapshotMappings[s.MappingMode]
+	if !ok {
+		return nil, fmt.Errorf("%w mapping mode: %v", ErrInvalid, s.MappingMode)
+	}
+
+	v := snapshotJSON{
+		Version:       SnapshotVersion,
+		Mapping:       mapping,
+		DutyCycle:     [2]int{s.DutyCycle.Numerator, s.DutyCycle.Denominator},
+		DisplayBuffer: s.DisplayBuffer,
+		UpdateBuffer:  s.UpdateBuffer,
+		Flash:         s.Flash,
+	}
+	for b, frame := range s.Buffers {
+		var cells [FrameSize]byte
+		for i, state := range frame {
+			cells[i] = byte(16*int(state.Green&3) + int(state.Red&3))
+			if state.Flash {
+				cells[i] |= velocityFlagsFlash
+			}
+		}
+		v.Buffers[b] = hex.EncodeToString(cells[:])
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a snapshot, rejecting versions newer than SnapshotVersion
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	var v snapshotJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Version < 1 || v.Version > SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", v.Version)
+	}
+
+	snap := Snapshot{
+		DisplayBuffer: v.DisplayBuffer,
+		UpdateBuffer:  v.UpdateBuffer,
+		Flash:         v.Flash,
+		DutyCycle:     DutyCycle{Numerator: v.DutyCycle[0], Denominator: v.DutyCycle[1]},
+	}
+	found := false
+	for mode, name := range snapshotMappings {
+		if name == v.Mapping {
+			snap.MappingMode = mode
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w mapping mode: %q", ErrInvalid, v.Mapping)
+	}
+
+	for b, encoded := range v.Buffers {
+		cells, err := hex.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("%w %v: %w", ErrInvalid, BufferID(b), err)
+		}
+		if len(cells) != FrameSize {
+			return fmt.Errorf("%w %v: %d LEDs, want %d", ErrInvalid, BufferID(b), len(cells), FrameSize)
+		}
+		for i, cell := range cells {
+			if cell&^(0x33|velocityFlagsFlash) != 0 {
+				return fmt.Errorf("%w %v: LED %d: %02X", ErrInvalid, BufferID(b), i, cell)
+			}
+			snap.Buffers[b][i] = LEDState{
+				Red:   Brightness(cell & 0x03),
+				Green: Brightness((cell >> 4) & 0x03),
+				Flash: cell&velocityFlagsFlash != 0,
+			}
+		}
+	}
+
+	if err := snap.validate(); err != nil {
+		return err
+	}
+	*s = snap
+	return nil
+}
+
+// WriteTo writes the snapshot as indented JSON
+func (s Snapshot) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// WriteFile saves the snapshot to a file
+func (s Snapshot) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := s.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadSnapshot reads a snapshot written by WriteTo
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var s Snapshot
+	err := json.NewDecoder(r).Decode(&s)
+	return s, err
+}
+
+// ReadSnapshotFile loads a snapshot saved by WriteFile
+func ReadSnapshotFile(path string) (Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer f.Close()
+	return ReadSnapshot(f)
+}
@@ -0,0 +1,139 @@
+package launchpad
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// goldenSnapshot is the snapshot stored in testdata/snapshot_v1.json
+func goldenSnapshot() Snapshot {
+	s := Snapshot{
+		DisplayBuffer: Buffer1,
+		UpdateBuffer:  Buffer0,
+		Flash:         true,
+		MappingMode:   MappingDrum,
+		DutyCycle:     DutyCycle{Numerator: 1, Denominator: 5},
+	}
+	for i := range s.Buffers[Buffer0] {
+		s.Buffers[Buffer0][i] = LEDState{Red: Brightness(i % 4), Green: Brightness(i / 4 % 4)}
+	}
+	s.Buffers[Buffer1].Set(NewGridButton(0, 0), LEDState{Red: BrightnessFull, Flash: true})
+	s.Buffers[Buffer1].Set(NewGridButton(7, 7), LEDState{Green: BrightnessLow, Flash: true})
+	s.Buffers[Buffer1].Set(NewSceneButton(3), LEDState{Red: BrightnessMedium, Green: BrightnessMedium})
+	s.Buffers[Buffer1].Set(NewTopButton(5), LEDState{Red: BrightnessFull, Green: BrightnessFull, Flash: true})
+	return s
+}
+
+// The version 1 format is read by files already on disk, so it must not change
+func TestSnapshotGoldenV1(t *testing.T) {
+	golden, err := os.ReadFile(filepath.Join("testdata", "snapshot_v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := goldenSnapshot().WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), golden) {
+		t.Errorf("WriteTo wrote\n%s\nwant\n%s", buf.Bytes(), golden)
+	}
+
+	got, err := ReadSnapshot(bytes.NewReader(golden))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != goldenSnapshot() {
+		t.Errorf("ReadSnapshot = %+v, want %+v", got, goldenSnapshot())
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	var allFlash Snapshot
+	allFlash.DutyCycle = DutyCycle{Numerator: 16, Denominator: 18}
+	for b := range allFlash.Buffers {
+		for i := range allFlash.Buffers[b] {
+			allFlash.Buffers[b][i] = LEDState{Red: Brightness(3 - i%4), Green: Brightness(i % 4), Flash: true}
+		}
+	}
+
+	tests := []struct {
+		name string
+		snap Snapshot
+	}{
+		{"zero buffers", Snapshot{DutyCycle: DutyCycle{Numerator: 1, Denominator: 5}}},
+		{"golden", goldenSnapshot()},
+		{"flash everywhere", allFlash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.json")
+			if err := tt.snap.WriteFile(path); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadSnapshotFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.snap {
+				t.Fatalf("round trip = %+v, want %+v", got, tt.snap)
+			}
+		})
+	}
+}
+
+func TestReadSnapshotErrors(t *testing.T) {
+	off := strings.Repeat("00", FrameSize)
+
+	// snapshot returns a version 1 document with one field replaced
+	snapshot := func(field, value string) string {
+		fields := map[string]string{
+			"version":        "1",
+			"mapping":        `"xy"`,
+			"duty_cycle":     "[1, 5]",
+			"display_buffer": "0",
+			"update_buffer":  "0",
+			"buffers":        `["` + off + `", "` + off + `"]`,
+		}
+		fields[field] = value
+
+		var parts []string
+		for name, value := range fields {
+			parts = append(parts, `"`+name+`": `+value)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"version 0", snapshot("version", "0"), "unsupported snapshot version: 0"},
+		{"missing version", snapshot("version", "null"), "unsupported snapshot version: 0"},
+		{"future version", snapshot("version", "2"), "unsupported snapshot version: 2"},
+		{"unknown mapping", snapshot("mapping", `"grid"`), "mapping mode"},
+		{"invalid duty cycle", snapshot("duty_cycle", "[0, 5]"), "duty cycle"},
+		{"invalid buffer", snapshot("display_buffer", "2"), "display buffer"},
+		{"not hex", snapshot("buffers", `["zz", "`+off+`"]`), "Buffer0"},
+		{"short buffer", snapshot("buffers", `["`+off+`", "00"]`), "1 LEDs, want 80"},
+		{"invalid cell", snapshot("buffers", `["04`+off[2:]+`", "`+off+`"]`), "LED 0: 04"},
+		{"not JSON", "snapshot", "invalid character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ReadSnapshot(strings.NewReader(tt.data))
+			if err == nil {
+				t.Fatalf("read %+v, want an error", s)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package launchpad
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Direction is the direction of MIDI traffic
+type Direction int
+
+const (
+	Outgoing Direction = iota // Sent to the device
+	Incoming                  // Received from the device
+)
+
+// String returns the string representation of a Direction
+func (d Direction) String() string {
+	switch d {
+	case Outgoing:
+		return "out"
+	case Incoming:
+		return "in"
+	default:
+		return fmt.Sprintf("Direction(%d)", d)
+	}
+}
+
+// Traffic is one raw MIDI message sent to or received from the device
+type Traffic struct {
+	Time      time.Time
+	Direction Direction
+	Data      []byte
+	Profile   *Profile // Model the message was sent to or received from
+}
+
+// String returns the direction and the bytes in hex
+func (t Traffic) String() string {
+	return fmt.Sprintf("%s % X", t.Direction, t.Data)
+}
+
+// TrafficHandler is a function that receives raw MIDI traffic
+type TrafficHandler func(Traffic)
+
+// OnTraffic registers a handler for every MIDI message sent to or received
+// from the device, for protocol-level debugging
+// Outgoing traffic is reported while the Launchpad is locked, so handlers must
+// return quickly and must not call Launchpad methods
+func (lp *Launchpad) OnTraffic(handler TrafficHandler) {
+	lp.trafficMu.Lock()
+	defer lp.trafficMu.Unlock()
+	lp.trafficHandlers = append(lp.trafficHandlers, handler)
+}
+
+// setTrafficProfile sets the model reported with traffic
+func (lp *Launchpad) setTrafficProfile(p *Profile) {
+	lp.trafficMu.Lock()
+	defer lp.trafficMu.Unlock()
+	lp.trafficProfile = p
+}
+
+// recordTraffic passes a message to the traffic handlers
+func (lp *Launchpad) recordTraffic(dir Direction, data []byte) {
+	lp.trafficMu.Lock()
+	handlers := make([]TrafficHandler, len(lp.trafficHandlers))
+	copy(handlers, lp.trafficHandlers)
+	profile := lp.trafficProfile
+	lp.trafficMu.Unlock()
+
+	if len(handlers) == 0 {
+		return
+	}
+
+	t := Traffic{
+		Time:      time.Now(),
+		Direction: dir,
+		Data:      append([]byte(nil), data...),
+		Profile:   profile,
+	}
+	for _, handler := range handlers {
+		handler(t)
+	}
+}
+
+// LEDWrite is a single LED update found in outgoing traffic
+type LEDWrite struct {
+	Button   Button
+	Velocity byte // Raw velocity, see LEDStateFromVelocity
+}
+
+// Decoder turns raw traffic into readable protocol operations such as
+// "grid[3,4] R3G0 copy|clear" or "buffer display=1 update=0 flash"
+// It keeps track of rapid LED updates, so use one Decoder per traffic stream
+// Messages are decoded with the protocol of their Profile (ProfileMini if nil);
+// colors sent to RGB models are reported as the nearest bi-color states
+type Decoder struct {
+	rapid int // Frame index of the next rapid update LED
+}
+
+// profile returns the model of a message, defaulting to ProfileMini
+func (t Traffic) profile() *Profile {
+	if t.Profile == nil {
+		return ProfileMini
+	}
+	return t.Profile
+}
+
+// Decode describes a message
+func (d *Decoder) Decode(t Traffic) string {
+	data := t.Data
+	if len(data) == 0 {
+		return "empty"
+	}
+
+	if t.Direction == Incoming {
+		if data[0] == 0xF0 {
+			return decodeSysEx(data)
+		}
+		return decodeIncoming(t.profile().enc, data)
+	}
+
+	if e, ok := t.profile().enc.(*novationEncoding); ok {
+		return decodeNovation(e, data)
+	}
+
+	if data[0] == 0xF0 {
+		return decodeSysEx(data)
+	}
+
+	writes := d.LEDWrites(t)
+	switch {
+	case len(writes) == 0:
+	case data[0] == statusNoteOff:
+		return buttonName(writes[0].Button) + " off"
+	case data[0] == statusNoteOnChannel3:
+		return fmt.Sprintf("rapid %s %s, %s %s",
+			buttonName(writes[0].Button), decodeVelocity(writes[0].Velocity),
+			buttonName(writes[1].Button), decodeVelocity(writes[1].Velocity))
+	default:
+		return buttonName(writes[0].Button) + " " + decodeVelocity(writes[0].Velocity)
+	}
+
+	if len(data) == 3 && data[0] == statusControlChange {
+		return decodeControl(data[1], data[2])
+	}
+	return fmt.Sprintf("unknown % X", data)
+}
+
+// LEDWrites returns the LED updates in an outgoing message, expanding rapid
+// updates into their two LEDs; note-offs are reported with velocity 0
+// It returns nil for incoming traffic and messages that do not set LEDs
+func (d *Decoder) LEDWrites(t Traffic) []LEDWrite {
+	data := t.Data
+	if t.Direction != Outgoing || len(data) == 0 {
+		return nil
+	}
+
+	if e, ok := t.profile().enc.(*novationEncoding); ok {
+		return e.ledWrites(data)
+	}
+
+	// Any other message ends a rapid update
+	if data[0] != statusNoteOnChannel3 {
+		d.rapid = 0
+	}
+
+	if len(data) != 3 {
+		return nil
+	}
+
+	switch data[0] {
+	case statusNoteOn, statusNoteOff:
+		btn, ok := ButtonForKey(int(data[1]))
+		if !ok {
+			return nil
+		}
+		velocity := data[2]
+		if data[0] == statusNoteOff {
+			velocity = 0
+		}
+		return []LEDWrite{{Button: btn, Velocity: velocity}}
+
+	case statusNoteOnChannel3:
+		if d.rapid >= FrameSize {
+			d.rapid = 0
+		}
+		i := d.rapid
+		d.rapid += 2
+		return []LEDWrite{
+			{Button: FrameButton(i), Velocity: data[1]},
+			{Button: FrameButton(i + 1), Velocity: data[2]},
+		}
+
+	case statusControlChange:
+		if data[1] >= controllerTopButton0 && data[1] <= controllerTopButton7 {
+			btn := NewTopButton(int(data[1] - controllerTopButton0))
+			return []LEDWrite{{Button: btn, Velocity: data[2]}}
+		}
+	}
+	return nil
+}
+
+// decodeIncoming describes a message from the device
+func decodeIncoming(enc encoding, data []byte) string {
+	if len(data) == 3 {
+		if event, ok := enc.button(data); ok && event.Button.Valid() {
+			action := "release"
+			if event.Pressed {
+				action = "press"
+			}
+			return action + " " + buttonName(event.Button)
+		}
+	}
+	return fmt.Sprintf("unknown % X", data)
+}
+
+// decodeNovation describes a message sent to an RGB model
+func decodeNovation(e *novationEncoding, data []byte) string {
+	writes := e.ledWrites(data)
+	switch {
+	case len(writes) == 1:
+		return buttonName(writes[0].Button) + " " + decodeState(LEDStateFromVelocity(writes[0].Velocity))
+	case len(writes) > 1:
+		return fmt.Sprintf("leds %d", len(writes))
+	case bytes.Equal(data, e.sysex(e.setupMsg...)):
+		return "setup"
+	case e.teardownMsg != nil && bytes.Equal(data, e.sysex(e.teardownMsg...)):
+		return "teardown"
+	case data[0] == 0xF0:
+		return decodeSysEx(data)
+	}
+	return fmt.Sprintf("unknown % X", data)
+}
+
+// decodeSysEx describes a SysEx message
+func decodeSysEx(data []byte) string {
+	if bytes.Equal(data, deviceInquiry) {
+		return "device inquiry"
+	}
+	if info, err := ParseDeviceInfo(data); err == nil {
+		return "identity " + info.String()
+	}
+	return fmt.Sprintf("sysex % X", data)
+}
+
+// decodeControl describes a controller change sent to the device
+// Top button LEDs are handled by LEDWrites
+func decodeControl(controller, data byte) string {
+	switch {
+	case controller == controllerDutyCycleLow:
+		return fmt.Sprintf("duty cycle %d/%d", 1+data/16, 3+data%16)
+
+	case controller == controllerDutyCycleHigh:
+		return fmt.Sprintf("duty cycle %d/%d", 9+data/16, 3+data%16)
+
+	case controller != controllerSystem:
+		return fmt.Sprintf("unknown B0 %02X %02X", controller, data)
+	}
+
+	switch {
+	case data == systemReset:
+		return "reset"
+	case data == systemLayoutXY:
+		return "layout x-y"
+	case data == systemLayoutDrum:
+		return "layout drum"
+	case data == systemTestLow:
+		return "test low"
+	case data == systemTestMedium:
+		return "test medium"
+	case data == systemTestFull:
+		return "test full"
+	case data >= bufferBase && data < bufferBase+32:
+		s := fmt.Sprintf("buffer display=%d update=%d", data&1, (data>>2)&1)
+		if data&bufferFlagFlash != 0 {
+			s += " flash"
+		}
+		if data&bufferFlagCopy != 0 {
+			s += " copy"
+		}
+		return s
+	default:
+		return fmt.Sprintf("unknown system command %d", data)
+	}
+}
+
+// decodeVelocity describes an LED velocity as "R3G0 copy|clear"
+func decodeVelocity(v byte) string {
+	var flags []string
+	if v&velocityFlagsCopy != 0 {
+		flags = append(flags, "copy")
+	}
+	if v&velocityFlagsClear != 0 {
+		flags = append(flags, "clear")
+	}
+	if len(flags) == 0 {
+		flags = append(flags, "update-only")
+	}
+	return fmt.Sprintf("R%dG%d %s", v&0x03, (v>>4)&0x03, strings.Join(flags, "|"))
+}
+
+// decodeState describes an LED state as "R3G0", adding "flash" for flashing LEDs
+func decodeState(s LEDState) string {
+	if s.Flash {
+		return fmt.Sprintf("R%dG%d flash", s.Red, s.Green)
+	}
+	return fmt.Sprintf("R%dG%d", s.Red, s.Green)
+}
+
+// buttonName returns a compact button name such as grid[3,4], scene[2] or top[5]
+func buttonName(btn Button) string {
+	switch {
+	case btn.IsTop:
+		return fmt.Sprintf("top[%d]", btn.X)
+	case btn.IsScene:
+		return fmt.Sprintf("scene[%d]", btn.Y)
+	default:
+		return fmt.Sprintf("grid[%d,%d]", btn.X, btn.Y)
+	}
+}
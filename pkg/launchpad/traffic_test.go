@@ -0,0 +1,140 @@
+package launchpad
+
+import (
+	"strings"
+	"testing"
+)
+
+// outgoing wraps messages as outgoing traffic to a model
+func outgoing(p *Profile, msgs [][]byte) []Traffic {
+	traffic := make([]Traffic, len(msgs))
+	for i, msg := range msgs {
+		traffic[i] = Traffic{Direction: Outgoing, Data: msg, Profile: p}
+	}
+	return traffic
+}
+
+// decodeFrame applies the LED writes of a traffic stream to a frame
+func decodeFrame(traffic []Traffic) Frame {
+	var d Decoder
+	var frame Frame
+	for _, t := range traffic {
+		for _, w := range d.LEDWrites(t) {
+			frame.Set(w.Button, LEDStateFromVelocity(w.Velocity))
+		}
+	}
+	return frame
+}
+
+func TestDecoderLEDWrites(t *testing.T) {
+	states := []LEDState{
+		{},
+		{Red: BrightnessFull},
+		{Green: BrightnessMedium},
+		{Red: BrightnessLow, Green: BrightnessLow},
+		{Red: BrightnessFull, Green: BrightnessMedium},
+		{Red: BrightnessFull, Flash: true},
+		{Green: BrightnessLow, Flash: true},
+		{Red: BrightnessMedium, Green: BrightnessMedium, Flash: true},
+		{Red: BrightnessMedium, Green: BrightnessFull, Flash: true},
+	}
+	buttons := []Button{NewGridButton(0, 0), NewGridButton(7, 7), NewGridButton(3, 4), NewSceneButton(2), NewTopButton(5)}
+
+	for _, p := range Profiles() {
+		t.Run(p.Name, func(t *testing.T) {
+			for _, btn := range buttons {
+				for _, state := range states {
+					got := decodeFrame(outgoing(p, p.enc.led(btn, state)))
+					if got.Get(btn) != state {
+						t.Errorf("%v %v decoded as %v", btn, state, got.Get(btn))
+					}
+				}
+			}
+
+			var want Frame
+			for i := range want {
+				want[i] = states[i%len(states)]
+			}
+			if got := decodeFrame(outgoing(p, p.enc.frame(&want))); got != want {
+				t.Errorf("frame decoded as %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecoderRapidUpdate(t *testing.T) {
+	var want Frame
+	for i := range want {
+		want[i] = LEDState{Red: Brightness(i % 4), Green: Brightness(i / 4 % 4)}
+	}
+
+	var traffic []Traffic
+	for i := 0; i < FrameSize; i += 2 {
+		traffic = append(traffic, Traffic{
+			Direction: Outgoing,
+			Data:      []byte{statusNoteOnChannel3, want[i].Velocity(), want[i+1].Velocity()},
+		})
+	}
+	if got := decodeFrame(traffic); got != want {
+		t.Fatalf("rapid update decoded as %v, want %v", got, want)
+	}
+}
+
+func TestDecoderIncoming(t *testing.T) {
+	tests := []struct {
+		profile *Profile
+		data    []byte
+		want    string
+	}{
+		{ProfileMini, []byte{0x90, 0x34, 0x7F}, "press grid[4,3]"},
+		{ProfileMini, []byte{0x90, 0x28, 0x00}, "release scene[2]"},
+		{ProfileMini, []byte{0xB0, 0x69, 0x7F}, "press top[1]"},
+		{nil, []byte{0x90, 0x00, 0x7F}, "press grid[0,0]"},
+		{ProfileMk2, []byte{0x90, 11, 0x40}, "press grid[0,7]"},
+		{ProfileMk2, []byte{0x90, 89, 0x00}, "release scene[0]"},
+		{ProfileMk2, []byte{0xB0, 104, 0x7F}, "press top[0]"},
+		{ProfileMiniMk3, []byte{0x90, 88, 0x10}, "press grid[7,0]"},
+		{ProfileMiniMk3, []byte{0xB0, 19, 0x7F}, "press scene[7]"},
+		{ProfileMiniMk3, []byte{0xB0, 98, 0x7F}, "press top[7]"},
+		{ProfileX, []byte{0x80, 55, 0x00}, "release grid[4,3]"},
+		{ProfileX, []byte{0x90, 19, 0x7F}, "unknown 90 13 7F"},
+	}
+
+	for _, tt := range tests {
+		var d Decoder
+		got := d.Decode(Traffic{Direction: Incoming, Data: tt.data, Profile: tt.profile})
+		if got != tt.want {
+			t.Errorf("%v % X decoded as %q, want %q", tt.profile, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestDecoderDescribesRGBModels(t *testing.T) {
+	enc := ProfileX.enc.(*novationEncoding)
+	tests := []struct {
+		msg  []byte
+		want string
+	}{
+		{enc.reset()[0], "setup"},
+		{enc.teardown()[0], "teardown"},
+		{enc.led(NewGridButton(3, 4), LEDState{Red: BrightnessFull})[0], "grid[3,4] R3G0"},
+		{enc.led(NewSceneButton(1), LEDState{Green: BrightnessLow, Flash: true})[1], "scene[1] R0G1 flash"},
+		{enc.frame(&Frame{})[0], "leds 80"},
+		{deviceInquiry, "device inquiry"},
+	}
+
+	for _, tt := range tests {
+		var d Decoder
+		got := d.Decode(Traffic{Direction: Outgoing, Data: tt.msg, Profile: ProfileX})
+		if got != tt.want {
+			t.Errorf("% X decoded as %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+
+	// Mini messages sent to an RGB model are not LED writes
+	var d Decoder
+	got := d.Decode(Traffic{Direction: Outgoing, Data: []byte{0x90, 0x34, 0x0F}, Profile: ProfileX})
+	if !strings.HasPrefix(got, "unknown") {
+		t.Errorf("Mini note to Launchpad X decoded as %q", got)
+	}
+}
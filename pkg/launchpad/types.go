@@ -67,6 +67,26 @@ func (m MappingMode) String() string {
 	}
 }
 
+// DutyCycle is the fraction of time low-brightness LEDs are lit
+// Medium-brightness LEDs are always lit for twice as long
+type DutyCycle struct {
+	Numerator   int // 1-16
+	Denominator int // 3-18
+}
+
+// DefaultDutyCycle is the duty cycle after a reset
+var DefaultDutyCycle = DutyCycle{Numerator: 1, Denominator: 5}
+
+// Valid returns true if the duty cycle can be sent to the device
+func (d DutyCycle) Valid() bool {
+	return d.Numerator >= 1 && d.Numerator <= 16 && d.Denominator >= 3 && d.Denominator <= 18
+}
+
+// String returns the duty cycle as a fraction, e.g. "1/5"
+func (d DutyCycle) String() string {
+	return fmt.Sprintf("%d/%d", d.Numerator, d.Denominator)
+}
+
 // Button represents a button on the Launchpad
 type Button struct {
 	X       int        // Column position (0-7 for grid, 8 for scene buttons)
@@ -105,6 +125,20 @@ func NewTopButton(x int) Button {
 	}
 }
 
+// ButtonAt returns the button at X-Y coordinates: x=8 is a scene button and
+// y=-1 a top button, as in Button.X and Button.Y
+// The button is not validated; check Valid before using it
+func ButtonAt(x, y int) Button {
+	switch {
+	case y == -1:
+		return NewTopButton(x)
+	case x == 8:
+		return NewSceneButton(y)
+	default:
+		return NewGridButton(x, y)
+	}
+}
+
 // String returns the string representation of a Button
 func (b Button) String() string {
 	if b.IsTop {
@@ -144,6 +178,17 @@ func (b Button) MIDIController() int {
 	return controllerTopButton0 + b.X
 }
 
+// ButtonForKey returns the grid or scene button for an X-Y mode MIDI key,
+// the inverse of MIDIKey; ok is false for keys outside the layout
+func ButtonForKey(key int) (btn Button, ok bool) {
+	x, y := key%16, key/16
+	btn = NewGridButton(x, y)
+	if x == 8 {
+		btn = NewSceneButton(y)
+	}
+	return btn, key >= 0 && btn.Valid()
+}
+
 // ButtonEvent represents a button press or release event
 type ButtonEvent struct {
 	Button  Button // The button that triggered the event
@@ -177,16 +222,55 @@ func (b BufferID) String() string {
 	return fmt.Sprintf("Buffer%d", b)
 }
 
+// WriteMode represents how an LED write interacts with the two LED buffers
+type WriteMode int
+
+// String returns the string representation of a WriteMode
+func (m WriteMode) String() string {
+	switch m {
+	case WriteNormal:
+		return "Normal"
+	case WriteUpdateOnly:
+		return "UpdateOnly"
+	case WriteCopy:
+		return "Copy"
+	case WriteClear:
+		return "Clear"
+	default:
+		return fmt.Sprintf("WriteMode(%d)", m)
+	}
+}
+
+// Valid returns true if the write mode is a known mode
+func (m WriteMode) Valid() bool {
+	return m >= WriteNormal && m <= WriteClear
+}
+
+// flags returns the Copy and Clear velocity bits for this write mode
+func (m WriteMode) flags() int {
+	switch m {
+	case WriteUpdateOnly:
+		return velocityFlagsNone
+	case WriteCopy:
+		return velocityFlagsCopy
+	case WriteClear:
+		return velocityFlagsClear
+	default:
+		return velocityFlagsNormal
+	}
+}
+
 // LEDState represents the state of a single LED
 type LEDState struct {
 	Red   Brightness // Red component brightness (0-3)
 	Green Brightness // Green component brightness (0-3)
-	Flash bool       // True if LED should flash
+	Flash bool       // True if LED should flash (overrides Mode)
+	Mode  WriteMode  // How the write affects the two buffers
 }
 
 // Velocity calculates the MIDI velocity byte for this LED state
 func (s LEDState) Velocity() byte {
-	flags := velocityFlagsNormal
+	flags := s.Mode.flags()
 	if s.Flash {
 		flags = velocityFlagsFlash
 	}
@@ -194,6 +278,30 @@ func (s LEDState) Velocity() byte {
 	return byte((16 * int(s.Green)) + int(s.Red) + flags)
 }
 
+// LEDStateFromVelocity decodes a MIDI velocity byte into an LED state
+// Flags other than the flash combination are reported as the matching write mode
+func LEDStateFromVelocity(velocity byte) LEDState {
+	state := LEDState{
+		Red:   Brightness(velocity & 0x03),
+		Green: Brightness((velocity >> 4) & 0x03),
+	}
+	switch int(velocity) & velocityFlagsNormal {
+	case velocityFlagsNone:
+		state.Mode = WriteUpdateOnly
+	case velocityFlagsCopy:
+		state.Mode = WriteCopy
+	case velocityFlagsFlash:
+		state.Flash = true
+	}
+	return state
+}
+
+// WithMode returns a copy of the LED state using the given write mode
+func (s LEDState) WithMode(mode WriteMode) LEDState {
+	s.Mode = mode
+	return s
+}
+
 // NewLEDState creates an LEDState from a color and brightness
 func NewLEDState(color Color, brightness Brightness) LEDState {
 	state := LEDState{Flash: false}
@@ -225,9 +333,11 @@ func NewLEDState(color Color, brightness Brightness) LEDState {
 
 // String returns the string representation of an LEDState
 func (s LEDState) String() string {
-	flash := ""
+	suffix := ""
 	if s.Flash {
-		flash = " (flash)"
+		suffix = " (flash)"
+	} else if s.Mode != WriteNormal {
+		suffix = fmt.Sprintf(" (%v)", s.Mode)
 	}
-	return fmt.Sprintf("R:%d G:%d%s", s.Red, s.Green, flash)
+	return fmt.Sprintf("R:%d G:%d%s", s.Red, s.Green, suffix)
 }
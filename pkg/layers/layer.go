@@ -0,0 +1,223 @@
+// Package layers composites multiple LED layers into a single Launchpad frame.
+//
+// Each Layer covers all 80 buttons. Cells that have not been set are transparent,
+// so a background layer shows through wherever an overlay has nothing to draw.
+// Layers are stacked by priority and combined with a per-layer BlendMode:
+//
+//	stack := layers.NewStack()
+//	grid := stack.Add(layers.NewLayer("steps", 0))
+//	playhead := stack.Add(layers.NewLayer("playhead", 10))
+//	playhead.SetBlend(layers.BlendMax)
+//
+//	grid.SetLED(2, 3, launchpad.ColorGreen, launchpad.BrightnessLow)
+//	playhead.SetColumn(2, launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessFull))
+//
+//	stack.Render(lp)
+package layers
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// BlendMode controls how a layer is combined with the layers beneath it
+type BlendMode int
+
+const (
+	BlendReplace BlendMode = iota // Opaque cells replace what is beneath
+	BlendMax                      // Per-component maximum brightness
+	BlendAdd                      // Per-component sum, clamped to full brightness
+)
+
+// String returns the string representation of a BlendMode
+func (m BlendMode) String() string {
+	switch m {
+	case BlendReplace:
+		return "Replace"
+	case BlendMax:
+		return "Max"
+	case BlendAdd:
+		return "Add"
+	default:
+		return fmt.Sprintf("BlendMode(%d)", m)
+	}
+}
+
+// Cell is a single LED within a layer
+type Cell struct {
+	State  launchpad.LEDState // LED state drawn by this cell
+	Opaque bool               // False if the cell is transparent
+}
+
+// Layer is an 80-cell frame with per-cell transparency
+type Layer struct {
+	mu       sync.Mutex
+	name     string
+	priority int
+	blend    BlendMode
+	visible  bool
+	cells    [launchpad.FrameSize]Cell
+}
+
+// NewLayer creates a visible, fully transparent layer
+// Layers with a higher priority are drawn on top of lower ones
+func NewLayer(name string, priority int) *Layer {
+	return &Layer{
+		name:     name,
+		priority: priority,
+		blend:    BlendReplace,
+		visible:  true,
+	}
+}
+
+// Name returns the layer name
+func (l *Layer) Name() string {
+	return l.name
+}
+
+// Priority returns the layer priority
+func (l *Layer) Priority() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.priority
+}
+
+// SetPriority changes the layer priority
+func (l *Layer) SetPriority(priority int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.priority = priority
+}
+
+// Blend returns the layer blend mode
+func (l *Layer) Blend() BlendMode {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.blend
+}
+
+// SetBlend sets how the layer is combined with the layers beneath it
+func (l *Layer) SetBlend(mode BlendMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.blend = mode
+}
+
+// Visible returns whether the layer is included when compositing
+func (l *Layer) Visible() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.visible
+}
+
+// SetVisible shows or hides the layer
+func (l *Layer) SetVisible(visible bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.visible = visible
+}
+
+// Set makes a button's cell opaque with the given LED state
+func (l *Layer) Set(btn launchpad.Button, state launchpad.LEDState) {
+	i := launchpad.FrameIndex(btn)
+	if i < 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cells[i] = Cell{State: state, Opaque: true}
+}
+
+// SetLED makes a grid cell opaque with the given color and brightness
+func (l *Layer) SetLED(x, y int, color launchpad.Color, brightness launchpad.Brightness) {
+	l.Set(launchpad.NewGridButton(x, y), launchpad.NewLEDState(color, brightness))
+}
+
+// SetRow makes every cell of a grid row opaque with the given state
+func (l *Layer) SetRow(y int, state launchpad.LEDState) {
+	for x := 0; x < launchpad.GridWidth; x++ {
+		l.Set(launchpad.NewGridButton(x, y), state)
+	}
+}
+
+// SetColumn makes every cell of a grid column opaque with the given state
+func (l *Layer) SetColumn(x int, state launchpad.LEDState) {
+	for y := 0; y < launchpad.GridHeight; y++ {
+		l.Set(launchpad.NewGridButton(x, y), state)
+	}
+}
+
+// Get returns a button's cell
+func (l *Layer) Get(btn launchpad.Button) Cell {
+	i := launchpad.FrameIndex(btn)
+	if i < 0 {
+		return Cell{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cells[i]
+}
+
+// Clear makes a button's cell transparent
+func (l *Layer) Clear(btn launchpad.Button) {
+	i := launchpad.FrameIndex(btn)
+	if i < 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cells[i] = Cell{}
+}
+
+// ClearAll makes every cell transparent
+func (l *Layer) ClearAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cells = [launchpad.FrameSize]Cell{}
+}
+
+// SetFrame makes every cell opaque with the states of a frame
+func (l *Layer) SetFrame(frame *launchpad.Frame) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, state := range frame {
+		l.cells[i] = Cell{State: state, Opaque: true}
+	}
+}
+
+// blendOnto combines the layer's opaque cells onto a frame
+func (l *Layer) blendOnto(frame *launchpad.Frame) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, cell := range l.cells {
+		if !cell.Opaque {
+			continue
+		}
+		frame[i] = blend(l.blend, frame[i], cell.State)
+	}
+}
+
+// blend combines a top LED state onto a bottom one
+func blend(mode BlendMode, bottom, top launchpad.LEDState) launchpad.LEDState {
+	switch mode {
+	case BlendMax:
+		return launchpad.LEDState{
+			Red:   max(bottom.Red, top.Red),
+			Green: max(bottom.Green, top.Green),
+			Flash: bottom.Flash || top.Flash,
+			Mode:  top.Mode,
+		}
+	case BlendAdd:
+		return launchpad.LEDState{
+			Red:   min(bottom.Red+top.Red, launchpad.BrightnessFull),
+			Green: min(bottom.Green+top.Green, launchpad.BrightnessFull),
+			Flash: bottom.Flash || top.Flash,
+			Mode:  top.Mode,
+		}
+	default:
+		return top
+	}
+}
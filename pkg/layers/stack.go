@@ -0,0 +1,127 @@
+package layers
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Stack is an ordered set of layers composited into a single frame
+type Stack struct {
+	mu     sync.Mutex
+	layers []*Layer
+	target *launchpad.Launchpad   // Launchpad of the last render
+	screen *launchpad.FrameWriter // Draws on target, nil before the first render
+}
+
+// NewStack creates an empty layer stack
+func NewStack() *Stack {
+	return &Stack{}
+}
+
+// Add adds a layer to the stack and returns it
+func (s *Stack) Add(layer *Layer) *Layer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.layers = append(s.layers, layer)
+	return layer
+}
+
+// Remove removes a layer from the stack
+func (s *Stack) Remove(layer *Layer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, l := range s.layers {
+		if l == layer {
+			s.layers = append(s.layers[:i], s.layers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Layers returns the layers from bottom to top
+func (s *Stack) Layers() []*Layer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sorted()
+}
+
+// sorted returns the layers ordered by priority, keeping insertion order
+// for equal priorities (caller must hold the lock)
+func (s *Stack) sorted() []*Layer {
+	layers := make([]*Layer, len(s.layers))
+	copy(layers, s.layers)
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].Priority() < layers[j].Priority()
+	})
+	return layers
+}
+
+// Composite blends all visible layers from bottom to top into a frame
+// Cells not covered by any layer are off
+func (s *Stack) Composite() launchpad.Frame {
+	s.mu.Lock()
+	layers := s.sorted()
+	s.mu.Unlock()
+
+	var frame launchpad.Frame
+	for _, layer := range layers {
+		if layer.Visible() {
+			layer.blendOnto(&frame)
+		}
+	}
+	return frame
+}
+
+// Render composites the stack and sends the result to the Launchpad
+// Only LEDs that changed since the previous render are sent
+func (s *Stack) Render(lp *launchpad.Launchpad) error {
+	frame := s.Composite()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.screen == nil || s.target != lp {
+		s.target = lp
+		s.screen = launchpad.NewFrameWriter(lp)
+	}
+	return s.screen.Draw(&frame)
+}
+
+// Invalidate forces the next Render to redraw every LED
+func (s *Stack) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.screen != nil {
+		s.screen.Invalidate()
+	}
+}
+
+// Run renders the stack to the Launchpad at a fixed interval until stop is called
+// Render errors are passed to onError if it is not nil
+func (s *Stack) Run(lp *launchpad.Launchpad, interval time.Duration, onError func(error)) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := s.Render(lp)
+				if err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
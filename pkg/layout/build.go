@@ -0,0 +1,380 @@
+package layout
+
+import (
+	"fmt"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/pages"
+	"github.com/inegm/golp/pkg/widget"
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// defaultVelocity is the note-on velocity of mappings that do not set one
+const defaultVelocity = 100
+
+// page is a built page
+type page struct {
+	name      string
+	switchTo  launchpad.Button
+	hasSwitch bool
+	surface   *widget.Surface
+	controls  []control
+	view      *pages.Page // Shown by the controller's page manager
+}
+
+// control is the reload state of a named widget
+type control struct {
+	key     string // Widget type and name
+	value   func() int
+	restore func(int)
+}
+
+// mapping is a resolved MIDI mapping
+type mapping struct {
+	kind     string
+	channel  uint8
+	number   uint8
+	velocity uint8
+}
+
+// gate returns the message sent when a button-like widget turns on or off, or nil
+func (m *mapping) gate(offset int, on bool) midi.Message {
+	if m == nil {
+		return nil
+	}
+	number := m.number + uint8(offset)
+	switch m.kind {
+	case "note":
+		if on {
+			return midi.NoteOn(m.channel, number, m.velocity)
+		}
+		return midi.NoteOff(m.channel, number)
+	case "cc":
+		if on {
+			return midi.ControlChange(m.channel, number, 127)
+		}
+		return midi.ControlChange(m.channel, number, 0)
+	case "program":
+		if on {
+			return midi.ProgramChange(m.channel, number)
+		}
+	}
+	return nil
+}
+
+// builder turns a configuration into pages
+type builder struct {
+	cfg  *Config
+	send func(midi.Message)
+	keys map[string]bool // Named widget keys already used
+}
+
+// build checks a configuration and creates its pages
+// Nothing is sent to the device or the MIDI output
+func (c *Controller) build(cfg *Config) ([]*page, widget.Style, error) {
+	b := &builder{cfg: cfg, send: c.send, keys: make(map[string]bool)}
+
+	indicator, err := cfg.style(cfg.Indicator, defaultIndicator)
+	if err != nil {
+		return nil, indicator, fmt.Errorf("indicator: %w", err)
+	}
+	if cfg.Channel < 0 || cfg.Channel > 15 {
+		return nil, indicator, fmt.Errorf("invalid MIDI channel: %d", cfg.Channel)
+	}
+	if len(cfg.Pages) == 0 {
+		return nil, indicator, fmt.Errorf("layout has no pages")
+	}
+
+	// Switch buttons are shared by all pages, so widgets cannot use them
+	switches := make(map[launchpad.Button]string)
+	pages := make([]*page, len(cfg.Pages))
+	for i, pc := range cfg.Pages {
+		if pc.Name == "" {
+			return nil, indicator, fmt.Errorf("page %d has no name", i)
+		}
+		for _, other := range pages[:i] {
+			if other.name == pc.Name {
+				return nil, indicator, fmt.Errorf("duplicate page name %q", pc.Name)
+			}
+		}
+
+		p := &page{name: pc.Name, surface: widget.NewSurface()}
+		if pc.Switch != "" {
+			buttons, err := cfg.region(pc.Switch)
+			if err != nil {
+				return nil, indicator, fmt.Errorf("page %q switch: %w", pc.Name, err)
+			}
+			if len(buttons) != 1 {
+				return nil, indicator, fmt.Errorf("page %q switch must be a single button", pc.Name)
+			}
+			if other, taken := switches[buttons[0]]; taken {
+				return nil, indicator, fmt.Errorf("page %q switch %v already switches to page %q", pc.Name, buttons[0], other)
+			}
+			switches[buttons[0]] = pc.Name
+			p.switchTo = buttons[0]
+			p.hasSwitch = true
+		}
+		pages[i] = p
+	}
+
+	for i, pc := range cfg.Pages {
+		for j, wc := range pc.Widgets {
+			err := b.widget(pages[i], wc, switches)
+			if err != nil {
+				return nil, indicator, fmt.Errorf("page %q widget %d (%s): %w", pc.Name, j, wc.Type, err)
+			}
+		}
+	}
+	return pages, indicator, nil
+}
+
+// widget creates a widget and places it on a page
+func (b *builder) widget(p *page, wc WidgetConfig, switches map[launchpad.Button]string) error {
+	buttons, err := b.cfg.region(wc.Region)
+	if err != nil {
+		return err
+	}
+	for _, btn := range buttons {
+		if _, taken := switches[btn]; taken {
+			return fmt.Errorf("%v is a page switch", btn)
+		}
+	}
+
+	style, err := b.cfg.style(wc.Style, widget.DefaultStyle)
+	if err != nil {
+		return err
+	}
+
+	m, err := b.mapping(wc.MIDI)
+	if err != nil {
+		return err
+	}
+
+	var ctl *control
+	var widgets []widget.Widget
+	switch wc.Type {
+	case "toggle":
+		if len(buttons) != 1 {
+			return fmt.Errorf("toggle needs a single button")
+		}
+		if err := m.only("note", "cc", "program"); err != nil {
+			return err
+		}
+		t := widget.NewToggle(buttons[0])
+		t.SetStyle(style)
+		if wc.Value != nil {
+			t.SetOn(*wc.Value != 0)
+		}
+		t.OnChange(func(e widget.ToggleEvent) { b.send(m.gate(0, e.On)) })
+		ctl = &control{
+			value: func() int {
+				if t.On() {
+					return 1
+				}
+				return 0
+			},
+			restore: func(v int) { t.SetOn(v != 0) },
+		}
+		widgets = append(widgets, t)
+
+	case "momentary", "pads":
+		if wc.Type == "momentary" && len(buttons) != 1 {
+			return fmt.Errorf("momentary needs a single button")
+		}
+		if err := m.only("note", "cc", "program"); err != nil {
+			return err
+		}
+		if err := m.span(len(buttons)); err != nil {
+			return err
+		}
+		for i, btn := range buttons {
+			pad := widget.NewMomentary(btn)
+			pad.SetStyle(style)
+			pad.OnChange(func(e widget.MomentaryEvent) { b.send(m.gate(i, e.Active)) })
+			widgets = append(widgets, pad)
+		}
+
+	case "fader":
+		f, err := fader(buttons)
+		if err != nil {
+			return err
+		}
+		if err := m.only("cc"); err != nil {
+			return err
+		}
+		f.SetStyle(style)
+		f.SetFine(wc.Fine)
+		if wc.Value != nil {
+			f.SetValue(*wc.Value)
+		}
+		f.OnChange(func(e widget.FaderEvent) {
+			if m != nil {
+				b.send(midi.ControlChange(m.channel, m.number, uint8(e.Value*127/f.Max())))
+			}
+		})
+		ctl = &control{value: f.Value, restore: f.SetValue}
+		widgets = append(widgets, f)
+
+	case "knob":
+		if len(buttons) != 2 {
+			return fmt.Errorf("knob needs two buttons")
+		}
+		if err := m.only("cc"); err != nil {
+			return err
+		}
+		lo, hi := wc.Min, wc.Max
+		if lo == 0 && hi == 0 {
+			hi = 127
+		}
+		if m != nil && (min(lo, hi) < 0 || max(lo, hi) > 127) {
+			return fmt.Errorf("knob range %d-%d does not fit a controller", lo, hi)
+		}
+		k := widget.NewKnob(buttons[0], buttons[1], lo, hi)
+		k.SetStyle(style)
+		k.SetStep(wc.Step)
+		if wc.Value != nil {
+			k.SetValue(*wc.Value)
+		}
+		k.OnChange(func(e widget.KnobEvent) {
+			if m != nil {
+				b.send(midi.ControlChange(m.channel, m.number, uint8(e.Value)))
+			}
+		})
+		ctl = &control{value: k.Value, restore: k.SetValue}
+		widgets = append(widgets, k)
+
+	case "radio":
+		if err := m.only("cc", "program"); err != nil {
+			return err
+		}
+		if m != nil && m.kind == "program" {
+			if err := m.span(len(buttons)); err != nil {
+				return err
+			}
+		}
+		r := widget.NewRadioGroup(buttons...)
+		r.SetStyle(style)
+		if wc.Value != nil {
+			r.SetSelected(*wc.Value)
+		}
+		r.OnChange(func(e widget.RadioEvent) {
+			switch {
+			case m == nil:
+			case m.kind == "program":
+				b.send(midi.ProgramChange(m.channel, m.number+uint8(e.Selected)))
+			default:
+				b.send(midi.ControlChange(m.channel, m.number, uint8(e.Selected)))
+			}
+		})
+		ctl = &control{value: r.Selected, restore: r.SetSelected}
+		widgets = append(widgets, r)
+
+	default:
+		return fmt.Errorf("unknown widget type %q", wc.Type)
+	}
+
+	for _, w := range widgets {
+		if err := p.surface.Add(w); err != nil {
+			return err
+		}
+	}
+
+	if wc.Name != "" && ctl != nil {
+		ctl.key = wc.Type + ":" + wc.Name
+		if b.keys[ctl.key] {
+			return fmt.Errorf("duplicate %s name %q", wc.Type, wc.Name)
+		}
+		b.keys[ctl.key] = true
+		p.controls = append(p.controls, *ctl)
+	}
+	return nil
+}
+
+// fader creates a fader over a full grid row or column
+func fader(buttons []launchpad.Button) (*widget.Fader, error) {
+	if len(buttons) > 0 && !buttons[0].IsScene && !buttons[0].IsTop {
+		for _, f := range []*widget.Fader{
+			widget.NewFader(widget.Vertical, buttons[0].X),
+			widget.NewFader(widget.Horizontal, buttons[0].Y),
+		} {
+			if sameButtons(f.Buttons(), buttons) {
+				return f, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("fader needs a grid row or column")
+}
+
+// sameButtons returns true if both lists hold the same buttons in the same order
+func sameButtons(a, b []launchpad.Button) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mapping resolves a MIDI mapping, or returns nil if there is none
+func (b *builder) mapping(mc *MappingConfig) (*mapping, error) {
+	if mc == nil {
+		return nil, nil
+	}
+
+	channel := b.cfg.Channel
+	if mc.Channel != nil {
+		channel = *mc.Channel
+	}
+	if channel < 0 || channel > 15 {
+		return nil, fmt.Errorf("invalid MIDI channel: %d", channel)
+	}
+
+	velocity := mc.Velocity
+	if velocity == 0 {
+		velocity = defaultVelocity
+	}
+	if velocity < 1 || velocity > 127 {
+		return nil, fmt.Errorf("invalid velocity: %d", velocity)
+	}
+
+	switch mc.Type {
+	case "note", "cc", "program":
+	default:
+		return nil, fmt.Errorf("unknown MIDI mapping type %q", mc.Type)
+	}
+
+	if mc.Number < 0 || mc.Number > 127 {
+		return nil, fmt.Errorf("invalid MIDI %s number: %d", mc.Type, mc.Number)
+	}
+
+	return &mapping{
+		kind:     mc.Type,
+		channel:  uint8(channel),
+		number:   uint8(mc.Number),
+		velocity: uint8(velocity),
+	}, nil
+}
+
+// span returns an error if n consecutive numbers from the mapping's number do not fit in MIDI
+func (m *mapping) span(n int) error {
+	if m == nil || int(m.number)+n-1 <= 127 {
+		return nil
+	}
+	return fmt.Errorf("MIDI %s numbers %d-%d out of range", m.kind, m.number, int(m.number)+n-1)
+}
+
+// only returns an error if the mapping is not one of the given kinds
+func (m *mapping) only(kinds ...string) error {
+	if m == nil {
+		return nil
+	}
+	for _, kind := range kinds {
+		if m.kind == kind {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported MIDI mapping type %q", m.kind)
+}
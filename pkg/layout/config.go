@@ -0,0 +1,270 @@
+package layout
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/widget"
+)
+
+// Config is a layout file
+type Config struct {
+	Output    string            `json:"output,omitempty"`    // MIDI output port name, used by tools opening the port
+	Channel   int               `json:"channel,omitempty"`   // Default MIDI channel (0-15)
+	Colors    map[string]string `json:"colors,omitempty"`    // Named colors
+	Regions   map[string]string `json:"regions,omitempty"`   // Named regions
+	Indicator *StyleConfig      `json:"indicator,omitempty"` // Page switch button colors
+	Pages     []PageConfig      `json:"pages"`
+}
+
+// PageConfig describes a page of widgets
+type PageConfig struct {
+	Name    string         `json:"name"`
+	Switch  string         `json:"switch,omitempty"` // Button that shows the page
+	Widgets []WidgetConfig `json:"widgets"`
+}
+
+// WidgetConfig describes a widget and the MIDI it sends
+type WidgetConfig struct {
+	Type   string         `json:"type"`           // toggle, momentary, pads, fader, knob or radio
+	Name   string         `json:"name,omitempty"` // Named widgets keep their value across reloads
+	Region string         `json:"region"`         // Region name or spec
+	Style  *StyleConfig   `json:"style,omitempty"`
+	Fine   bool           `json:"fine,omitempty"` // Fader fine mode
+	Min    int            `json:"min,omitempty"`  // Knob range
+	Max    int            `json:"max,omitempty"`
+	Step   int            `json:"step,omitempty"` // Knob step
+	Value  *int           `json:"value,omitempty"`
+	MIDI   *MappingConfig `json:"midi,omitempty"`
+}
+
+// StyleConfig holds two colors, each a color name or spec
+type StyleConfig struct {
+	On  string `json:"on,omitempty"`
+	Off string `json:"off,omitempty"`
+}
+
+// MappingConfig describes the MIDI message a widget sends
+type MappingConfig struct {
+	Type     string `json:"type"`              // note, cc or program
+	Channel  *int   `json:"channel,omitempty"` // Overrides the default channel
+	Number   int    `json:"number"`            // Note, controller or program of the first button
+	Velocity int    `json:"velocity,omitempty"`
+}
+
+// ReadFile loads a layout file
+func ReadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse reads a layout in JSON
+// Unknown fields are rejected so that typos are not silently ignored
+func Parse(r io.Reader) (*Config, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid layout: %w", err)
+	}
+	return &cfg, nil
+}
+
+// color resolves a color name or spec
+//
+// A spec is a color and an optional brightness and flash flag separated by colons,
+// such as "red", "green:low", "amber:2:flash", or raw components such as "r3g1"
+func (c *Config) color(s string) (launchpad.LEDState, error) {
+	if named, ok := c.Colors[s]; ok {
+		s = named
+	}
+
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), ":")
+	state, err := parseColor(parts[0])
+	if err != nil {
+		return state, err
+	}
+
+	rest := parts[1:]
+	if len(rest) > 0 && rest[len(rest)-1] == "flash" {
+		state.Flash = true
+		rest = rest[:len(rest)-1]
+	}
+	switch len(rest) {
+	case 0:
+	case 1:
+		b, err := parseBrightness(rest[0])
+		if err != nil {
+			return state, err
+		}
+		state = scaleState(state, b)
+	default:
+		return state, fmt.Errorf("invalid color: %q", s)
+	}
+	return state, nil
+}
+
+// parseColor parses a color name at full brightness, or raw components
+func parseColor(s string) (launchpad.LEDState, error) {
+	var color launchpad.Color
+	switch s {
+	case "off":
+		color = launchpad.ColorOff
+	case "red":
+		color = launchpad.ColorRed
+	case "green":
+		color = launchpad.ColorGreen
+	case "amber":
+		color = launchpad.ColorAmber
+	case "yellow":
+		color = launchpad.ColorYellow
+	default:
+		var r, g int
+		if n, _ := fmt.Sscanf(s, "r%dg%d", &r, &g); n != 2 || s != fmt.Sprintf("r%dg%d", r, g) {
+			return launchpad.LEDState{}, fmt.Errorf("invalid color: %q", s)
+		}
+		state := launchpad.LEDState{Red: launchpad.Brightness(r), Green: launchpad.Brightness(g)}
+		if !state.Red.Valid() || !state.Green.Valid() {
+			return launchpad.LEDState{}, fmt.Errorf("invalid color: %q", s)
+		}
+		return state, nil
+	}
+	return launchpad.NewLEDState(color, launchpad.BrightnessFull), nil
+}
+
+// parseBrightness parses a brightness name or level
+func parseBrightness(s string) (launchpad.Brightness, error) {
+	switch s {
+	case "off", "0":
+		return launchpad.BrightnessOff, nil
+	case "low", "1":
+		return launchpad.BrightnessLow, nil
+	case "medium", "2":
+		return launchpad.BrightnessMedium, nil
+	case "full", "3":
+		return launchpad.BrightnessFull, nil
+	default:
+		return 0, fmt.Errorf("invalid brightness: %q", s)
+	}
+}
+
+// scaleState sets the brightness of a full-brightness state, keeping its hue
+func scaleState(state launchpad.LEDState, b launchpad.Brightness) launchpad.LEDState {
+	dim := func(c launchpad.Brightness) launchpad.Brightness {
+		return c * b / launchpad.BrightnessFull
+	}
+	state.Red = dim(state.Red)
+	state.Green = dim(state.Green)
+	return state
+}
+
+// style resolves a style, keeping the colors of def that it does not set
+func (c *Config) style(s *StyleConfig, def widget.Style) (widget.Style, error) {
+	style := def
+	if s == nil {
+		return style, nil
+	}
+
+	var err error
+	if s.On != "" {
+		if style.On, err = c.color(s.On); err != nil {
+			return style, err
+		}
+	}
+	if s.Off != "" {
+		if style.Off, err = c.color(s.Off); err != nil {
+			return style, err
+		}
+	}
+	return style, nil
+}
+
+// region resolves a region name or spec into buttons
+//
+// Specs are:
+//
+//	grid:x,y        one grid button
+//	scene:y         one scene button
+//	top:x           one top button
+//	row:y           a grid row, left to right
+//	col:x           a grid column, bottom to top
+//	rect:x,y,w,h    a grid rectangle, left to right from the bottom row up
+//	scene           all scene buttons, top to bottom
+//	top             all top buttons, left to right
+func (c *Config) region(s string) ([]launchpad.Button, error) {
+	if named, ok := c.Regions[s]; ok {
+		s = named
+	}
+
+	invalid := fmt.Errorf("invalid region: %q", s)
+	kind, args, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	var nums []int
+	if args != "" {
+		for _, field := range strings.Split(args, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return nil, invalid
+			}
+			nums = append(nums, n)
+		}
+	}
+
+	var buttons []launchpad.Button
+	switch {
+	case kind == "grid" && len(nums) == 2:
+		buttons = append(buttons, launchpad.NewGridButton(nums[0], nums[1]))
+	case kind == "scene" && len(nums) == 1:
+		buttons = append(buttons, launchpad.NewSceneButton(nums[0]))
+	case kind == "top" && len(nums) == 1:
+		buttons = append(buttons, launchpad.NewTopButton(nums[0]))
+	case kind == "scene" && len(nums) == 0:
+		for y := 0; y < launchpad.SceneButtons; y++ {
+			buttons = append(buttons, launchpad.NewSceneButton(y))
+		}
+	case kind == "top" && len(nums) == 0:
+		for x := 0; x < launchpad.TopButtons; x++ {
+			buttons = append(buttons, launchpad.NewTopButton(x))
+		}
+	case kind == "row" && len(nums) == 1:
+		for x := 0; x < launchpad.GridWidth; x++ {
+			buttons = append(buttons, launchpad.NewGridButton(x, nums[0]))
+		}
+	case kind == "col" && len(nums) == 1:
+		for y := launchpad.GridHeight - 1; y >= 0; y-- {
+			buttons = append(buttons, launchpad.NewGridButton(nums[0], y))
+		}
+	case kind == "rect" && len(nums) == 4:
+		x, y, w, h := nums[0], nums[1], nums[2], nums[3]
+		if w <= 0 || h <= 0 {
+			return nil, invalid
+		}
+		for row := y + h - 1; row >= y; row-- {
+			for col := x; col < x+w; col++ {
+				buttons = append(buttons, launchpad.NewGridButton(col, row))
+			}
+		}
+	default:
+		return nil, invalid
+	}
+
+	for _, btn := range buttons {
+		if !btn.Valid() {
+			return nil, fmt.Errorf("region %q is outside the Launchpad", s)
+		}
+	}
+	return buttons, nil
+}
@@ -0,0 +1,157 @@
+package layout
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+func TestRegion(t *testing.T) {
+	grid := launchpad.NewGridButton
+	cfg := &Config{Regions: map[string]string{"faders": "rect:0,6,8,2"}}
+
+	tests := []struct {
+		spec string
+		want []launchpad.Button
+	}{
+		{"grid:3,4", []launchpad.Button{grid(3, 4)}},
+		{" Grid: 7 , 0 ", []launchpad.Button{grid(7, 0)}},
+		{"scene:2", []launchpad.Button{launchpad.NewSceneButton(2)}},
+		{"top:5", []launchpad.Button{launchpad.NewTopButton(5)}},
+		{"row:1", []launchpad.Button{grid(0, 1), grid(1, 1), grid(2, 1), grid(3, 1), grid(4, 1), grid(5, 1), grid(6, 1), grid(7, 1)}},
+		{"col:2", []launchpad.Button{grid(2, 7), grid(2, 6), grid(2, 5), grid(2, 4), grid(2, 3), grid(2, 2), grid(2, 1), grid(2, 0)}},
+		{"rect:1,2,3,2", []launchpad.Button{grid(1, 3), grid(2, 3), grid(3, 3), grid(1, 2), grid(2, 2), grid(3, 2)}},
+		{"rect:7,7,1,1", []launchpad.Button{grid(7, 7)}},
+		{"scene", []launchpad.Button{
+			launchpad.NewSceneButton(0), launchpad.NewSceneButton(1), launchpad.NewSceneButton(2), launchpad.NewSceneButton(3),
+			launchpad.NewSceneButton(4), launchpad.NewSceneButton(5), launchpad.NewSceneButton(6), launchpad.NewSceneButton(7),
+		}},
+		{"top", []launchpad.Button{
+			launchpad.NewTopButton(0), launchpad.NewTopButton(1), launchpad.NewTopButton(2), launchpad.NewTopButton(3),
+			launchpad.NewTopButton(4), launchpad.NewTopButton(5), launchpad.NewTopButton(6), launchpad.NewTopButton(7),
+		}},
+		{"faders", []launchpad.Button{
+			grid(0, 7), grid(1, 7), grid(2, 7), grid(3, 7), grid(4, 7), grid(5, 7), grid(6, 7), grid(7, 7),
+			grid(0, 6), grid(1, 6), grid(2, 6), grid(3, 6), grid(4, 6), grid(5, 6), grid(6, 6), grid(7, 6),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := cfg.region(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("region(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegionErrors(t *testing.T) {
+	cfg := &Config{}
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"grid:8,0", "outside the Launchpad"},
+		{"grid:0,-1", "outside the Launchpad"},
+		{"scene:8", "outside the Launchpad"},
+		{"top:-1", "outside the Launchpad"},
+		{"row:8", "outside the Launchpad"},
+		{"col:9", "outside the Launchpad"},
+		{"rect:6,6,3,1", "outside the Launchpad"},
+		{"rect:0,0,0,2", "invalid region"},
+		{"rect:0,0,2,-1", "invalid region"},
+		{"rect:0,0,2", "invalid region"},
+		{"grid:3", "invalid region"},
+		{"grid:a,b", "invalid region"},
+		{"row", "invalid region"},
+		{"circle:1", "invalid region"},
+		{"", "invalid region"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := cfg.region(tt.spec)
+			if err == nil {
+				t.Fatalf("region(%q) = %v, want an error", tt.spec, got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestColor(t *testing.T) {
+	cfg := &Config{Colors: map[string]string{"warn": "amber:low:flash"}}
+
+	tests := []struct {
+		spec string
+		want launchpad.LEDState
+	}{
+		{"off", launchpad.LEDState{}},
+		{"red", launchpad.LEDState{Red: 3}},
+		{"Green", launchpad.LEDState{Green: 3}},
+		{"amber", launchpad.LEDState{Red: 3, Green: 3}},
+		{"yellow", launchpad.LEDState{Red: 2, Green: 3}},
+		{"green:low", launchpad.LEDState{Green: 1}},
+		{"red:medium", launchpad.LEDState{Red: 2}},
+		{"amber:2:flash", launchpad.LEDState{Red: 2, Green: 2, Flash: true}},
+		{"red:0", launchpad.LEDState{}},
+		{"red:flash", launchpad.LEDState{Red: 3, Flash: true}},
+		{"r3g1", launchpad.LEDState{Red: 3, Green: 1}},
+		{"r0g2:flash", launchpad.LEDState{Green: 2, Flash: true}},
+		{"r3g3:1", launchpad.LEDState{Red: 1, Green: 1}},
+		{"warn", launchpad.LEDState{Red: 1, Green: 1, Flash: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := cfg.color(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("color(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorErrors(t *testing.T) {
+	cfg := &Config{}
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"blue", "invalid color"},
+		{"r4g0", "invalid color"},
+		{"r3", "invalid color"},
+		{"r3g1x", "invalid color"},
+		{"r03g1", "invalid color"},
+		{"red:bright", "invalid brightness"},
+		{"red:4", "invalid brightness"},
+		{"red:2:3", "invalid color"},
+		{"red:flash:2", "invalid color"},
+		{"", "invalid color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := cfg.color(tt.spec)
+			if err == nil {
+				t.Fatalf("color(%q) = %v, want an error", tt.spec, got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Package layout builds Launchpad controllers from declarative layout files.
+//
+// A layout file is JSON describing pages of widgets, the regions of buttons
+// they use, their colors and the MIDI messages they send, so a setup can be
+// changed without writing Go code:
+//
+//	{
+//	    "channel": 0,
+//	    "colors": {"hot": "red:full", "dim": "amber:low"},
+//	    "regions": {"drums": "rect:0,0,4,4"},
+//	    "pages": [
+//	        {"name": "mixer", "switch": "top:0", "widgets": [
+//	            {"type": "fader", "name": "volume", "region": "col:0", "midi": {"type": "cc", "number": 7}},
+//	            {"type": "toggle", "name": "mute", "region": "scene:0", "style": {"on": "hot", "off": "dim"},
+//	             "midi": {"type": "cc", "number": 20}}
+//	        ]},
+//	        {"name": "drums", "switch": "top:1", "widgets": [
+//	            {"type": "pads", "region": "drums", "midi": {"type": "note", "number": 36, "channel": 9}}
+//	        ]}
+//	    ]
+//	}
+//
+// A Controller draws the active page and sends the widgets' MIDI. Pages are
+// shown with a pages.Manager, so switch buttons, their indicators and held
+// buttons behave as in the pages package. Watch reloads the file whenever it
+// changes, keeping the Launchpad open:
+//
+//	c := layout.New(lp, out)
+//	if err := c.Load("gig.json"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	c.Start()
+//	stop := c.Watch("gig.json", layout.DefaultWatchInterval, func(err error) { log.Print(err) })
+//	defer stop()
+//
+// Widget types are toggle, momentary, pads (one momentary per button on
+// consecutive numbers), fader (a full grid row or column), knob (two
+// buttons) and radio. Their MIDI mappings are note, cc or program.
+package layout
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/internal/watch"
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/pages"
+	"github.com/inegm/golp/pkg/widget"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// DefaultWatchInterval is a suitable interval for checking a layout file for changes
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// defaultIndicator is drawn on page switch buttons when the layout does not set it
+var defaultIndicator = widget.Style{
+	On:  launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull),
+	Off: launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessLow),
+}
+
+// noteKey identifies a sounding note
+type noteKey struct {
+	channel, note uint8
+}
+
+// Controller runs a layout on a Launchpad
+// Its pages are shown by a pages.Manager, which switches pages, draws the
+// indicators and sends each release to the page that received the press
+type Controller struct {
+	mu         sync.Mutex
+	lp         *launchpad.Launchpad
+	out        drivers.Out
+	pages      []*page
+	mgr        *pages.Manager // Shows the pages of the current layout, nil if none is loaded
+	sounding   map[noteKey]bool
+	running    bool
+	registered bool // True once the button handler has been registered
+}
+
+// New creates a controller sending MIDI to an open output
+// The output may be nil to use the layout without MIDI
+func New(lp *launchpad.Launchpad, out drivers.Out) *Controller {
+	return &Controller{
+		lp:       lp,
+		out:      out,
+		sounding: make(map[noteKey]bool),
+	}
+}
+
+// Load reads a layout file and applies it
+func (c *Controller) Load(path string) error {
+	cfg, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := c.Apply(cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Apply replaces the running layout
+//
+// The new layout is checked completely first; if it is invalid, the error is
+// returned and the current layout keeps running. Sounding notes are stopped,
+// named widgets keep their values and the active page stays shown if the new
+// layout has a page with the same name.
+func (c *Controller) Apply(cfg *Config) error {
+	built, indicator, err := c.build(cfg)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make(map[string]int)
+	for _, p := range c.pages {
+		for _, ctl := range p.controls {
+			values[ctl.key] = ctl.value()
+		}
+	}
+	active := ""
+	if c.mgr != nil {
+		active = c.mgr.Active().Name()
+	}
+
+	// The new pages are prepared out of sight and shown in one redraw
+	mgr := pages.NewDetachedManager(c.lp)
+	mgr.SetIndicator(indicator.On, indicator.Off)
+	for _, p := range built {
+		for _, ctl := range p.controls {
+			if v, ok := values[ctl.key]; ok {
+				ctl.restore(v)
+			}
+		}
+
+		p.view = mgr.NewPage(p.name)
+		if p.hasSwitch {
+			if err := mgr.Bind(p.switchTo, p.view); err != nil {
+				return err
+			}
+		}
+		p.view.OnButton(c.pageHandler(p))
+		if err := renderPage(p); err != nil {
+			return err
+		}
+		if p.name == active {
+			mgr.Switch(p.view)
+		}
+	}
+
+	c.allNotesOffLocked()
+	if c.mgr != nil {
+		// Pages of the old layout may still be handling an event
+		c.mgr.Hide()
+	}
+	c.pages = built
+	c.mgr = mgr
+	if !c.running {
+		return nil
+	}
+	return mgr.Redraw()
+}
+
+// Start begins handling button events and draws the active page
+func (c *Controller) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+	if !c.registered {
+		c.lp.OnButton(c.handleButton)
+		c.registered = true
+	}
+	c.running = true
+	if c.mgr == nil {
+		return nil
+	}
+	return c.mgr.Redraw()
+}
+
+// Stop stops handling button events and releases sounding notes
+func (c *Controller) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.allNotesOffLocked()
+	c.running = false
+}
+
+// Pages returns the page names in layout order
+func (c *Controller) Pages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, len(c.pages))
+	for i, p := range c.pages {
+		names[i] = p.name
+	}
+	return names
+}
+
+// Page returns the name of the active page, or "" if no layout is loaded
+func (c *Controller) Page() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mgr == nil {
+		return ""
+	}
+	return c.mgr.Active().Name()
+}
+
+// Switch shows a page by name
+func (c *Controller) Switch(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range c.pages {
+		if p.name == name {
+			return c.mgr.Switch(p.view)
+		}
+	}
+	return fmt.Errorf("no page named %q", name)
+}
+
+// Watch checks a layout file at a fixed interval and applies it whenever it changes,
+// until stop is called
+// Load errors, including invalid layouts, are passed to onError if it is not nil;
+// the previous layout keeps running until the file is fixed
+func (c *Controller) Watch(path string, interval time.Duration, onError func(error)) (stop func()) {
+	return watch.File(path, interval, func() {
+		if err := c.Load(path); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
+
+// handleButton passes button events to the page manager while the controller is running
+func (c *Controller) handleButton(event launchpad.ButtonEvent) {
+	c.mu.Lock()
+	mgr := c.mgr
+	running := c.running
+	c.mu.Unlock()
+
+	if running && mgr != nil {
+		mgr.Handle(event)
+	}
+}
+
+// pageHandler returns the button handler of a page, which updates its widgets
+// and redraws it
+func (c *Controller) pageHandler(p *page) launchpad.ButtonHandler {
+	return func(event launchpad.ButtonEvent) {
+		// Widget change handlers send MIDI, which takes the lock
+		p.surface.Handle(event)
+		renderPage(p)
+	}
+}
+
+// renderPage draws a page's widgets into its view, which updates the device
+// if the page is shown
+func renderPage(p *page) error {
+	var frame launchpad.Frame
+	p.surface.Render(&frame)
+	return p.view.SetFrame(&frame)
+}
+
+// send sends a widget's MIDI message, ignoring nil messages
+func (c *Controller) send(msg midi.Message) {
+	if msg == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sendLocked(msg)
+}
+
+// sendLocked sends a MIDI message and tracks sounding notes (caller must hold the lock)
+func (c *Controller) sendLocked(msg midi.Message) {
+	if c.out == nil {
+		return
+	}
+
+	var channel, note, velocity uint8
+	switch {
+	case msg.GetNoteStart(&channel, &note, &velocity):
+		c.sounding[noteKey{channel, note}] = true
+	case msg.GetNoteEnd(&channel, &note):
+		delete(c.sounding, noteKey{channel, note})
+	}
+	c.out.Send(msg)
+}
+
+// allNotesOffLocked stops every sounding note (caller must hold the lock)
+func (c *Controller) allNotesOffLocked() {
+	for key := range c.sounding {
+		c.sendLocked(midi.NoteOff(key.channel, key.note))
+	}
+}
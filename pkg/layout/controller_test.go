@@ -0,0 +1,51 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/simulator"
+)
+
+func mustParse(t *testing.T, s string) *Config {
+	t.Helper()
+	cfg, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestApplyHidesPreviousLayout(t *testing.T) {
+	lp := launchpad.New()
+	sim := simulator.New()
+	if err := lp.OpenPorts(sim.In(), sim.Out()); err != nil {
+		t.Fatal(err)
+	}
+	defer lp.Close()
+
+	c := New(lp, nil)
+	if err := c.Apply(mustParse(t, `{"pages": [{"name": "a", "widgets": [{"type": "toggle", "region": "grid:0,0"}]}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	old := c.pages[0]
+
+	if err := c.Apply(mustParse(t, `{"pages": [{"name": "b", "widgets": [{"type": "toggle", "region": "grid:7,7"}]}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	before := sim.Frame()
+
+	// An event the old page was handling during the reload must not draw
+	btn := launchpad.NewGridButton(0, 0)
+	c.pageHandler(old)(launchpad.ButtonEvent{Button: btn, Pressed: true})
+	c.pageHandler(old)(launchpad.ButtonEvent{Button: btn, Pressed: false})
+
+	if after := sim.Frame(); after != before {
+		t.Fatalf("old layout drew %v on grid[0,0] after the reload", after.Get(btn))
+	}
+}
@@ -0,0 +1,565 @@
+package macro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/internal/playback"
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/recording"
+	"github.com/inegm/golp/pkg/show"
+)
+
+// Slots is the number of macros in a bank, one per scene button
+const Slots = launchpad.SceneButtons
+
+// HoldToClear is how long a stored macro's scene button must be held to erase it
+const HoldToClear = time.Second
+
+// Replay selects what playback reproduces
+type Replay int
+
+const (
+	ReplayPresses Replay = iota // Inject the recorded presses into the button handlers (default)
+	ReplayLights                // Set the recorded light changes directly
+	ReplayBoth                  // Inject presses and set light changes
+)
+
+// String returns the string representation of a Replay
+func (r Replay) String() string {
+	switch r {
+	case ReplayPresses:
+		return "Presses"
+	case ReplayLights:
+		return "Lights"
+	case ReplayBoth:
+		return "Both"
+	default:
+		return fmt.Sprintf("Replay(%d)", r)
+	}
+}
+
+// Colors of the scene buttons
+var (
+	colorStored    = launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessLow)
+	colorPlaying   = launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull)
+	colorRecording = launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessFull)
+)
+
+// Bank records and plays macros from the scene buttons
+type Bank struct {
+	mu         sync.Mutex
+	lp         *launchpad.Launchpad
+	macros     [Slots]*Macro
+	playing    [Slots]*playback.Control // Playback of each playing slot
+	pressedAt  [Slots]time.Time
+	clearable  [Slots]bool // Held down after starting or stopping playback
+	replay     Replay
+	recording  int // Slot being recorded, -1 if none
+	running    bool
+	registered bool // True once the handlers have been registered
+
+	// Traffic is reported while the Launchpad holds its lock, so captured
+	// input has its own lock and the Launchpad is never called under mu
+	capture capture
+
+	drawMu sync.Mutex
+	shown  *[Slots]launchpad.LEDState // Scene LEDs on the device, nil if unknown
+}
+
+// NewBank creates an empty macro bank
+func NewBank(lp *launchpad.Launchpad) *Bank {
+	return &Bank{
+		lp:        lp,
+		recording: -1,
+	}
+}
+
+// Start begins handling the scene buttons and capturing input
+func (b *Bank) Start() {
+	b.mu.Lock()
+	if !b.registered {
+		b.lp.OnButton(b.handleButton)
+		b.lp.OnTraffic(b.capture.traffic)
+		b.registered = true
+	}
+	b.running = true
+	b.mu.Unlock()
+
+	b.drawMu.Lock()
+	b.shown = nil
+	b.drawMu.Unlock()
+	b.draw()
+}
+
+// Stop stores any recording in progress, stops playback and stops handling the scene buttons
+func (b *Bank) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stopRecordingLocked()
+	for slot := range b.playing {
+		b.stopPlayingLocked(slot)
+	}
+	b.running = false
+}
+
+// SetReplay selects what playback reproduces; it applies to playback started afterwards
+func (b *Bank) SetReplay(replay Replay) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.replay = replay
+}
+
+// Macro returns a copy of a slot's macro, or nil if the slot is empty
+func (b *Bank) Macro(slot int) *Macro {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !validSlot(slot) || b.macros[slot] == nil {
+		return nil
+	}
+	m := *b.macros[slot]
+	return &m
+}
+
+// SetMacro stores a macro in a slot, stopping the slot's playback
+// A nil macro empties the slot
+func (b *Bank) SetMacro(slot int, m *Macro) error {
+	if !validSlot(slot) {
+		return fmt.Errorf("invalid slot: %d", slot)
+	}
+
+	b.mu.Lock()
+	b.stopPlayingLocked(slot)
+	b.macros[slot] = nil
+	if m != nil {
+		stored := *m
+		b.macros[slot] = &stored
+	}
+	b.mu.Unlock()
+
+	b.draw()
+	return nil
+}
+
+// Clear empties a slot
+func (b *Bank) Clear(slot int) error {
+	return b.SetMacro(slot, nil)
+}
+
+// SetLoop sets whether a stored macro restarts at its end
+func (b *Bank) SetLoop(slot int, loop bool) error {
+	return b.update(slot, func(m *Macro) { m.Loop = loop })
+}
+
+// SetSpeed sets a stored macro's playback speed: 2 plays twice as fast, 0.5 at half speed
+// It applies the next time the macro starts
+func (b *Bank) SetSpeed(slot int, speed float64) error {
+	if speed <= 0 {
+		return fmt.Errorf("invalid speed: %v", speed)
+	}
+	return b.update(slot, func(m *Macro) { m.Speed = speed })
+}
+
+// update changes a stored macro
+func (b *Bank) update(slot int, fn func(m *Macro)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !validSlot(slot) {
+		return fmt.Errorf("invalid slot: %d", slot)
+	}
+	if b.macros[slot] == nil {
+		return fmt.Errorf("slot %d is empty", slot)
+	}
+	fn(b.macros[slot])
+	return nil
+}
+
+// Record starts recording into a slot, replacing its macro when the recording stops
+func (b *Bank) Record(slot int) error {
+	if !validSlot(slot) {
+		return fmt.Errorf("invalid slot: %d", slot)
+	}
+
+	b.mu.Lock()
+	if b.recording >= 0 {
+		b.mu.Unlock()
+		return fmt.Errorf("already recording slot %d", b.recording)
+	}
+	b.recordLocked(slot)
+	b.mu.Unlock()
+
+	b.draw()
+	return nil
+}
+
+// StopRecording stores the recording in progress, if any
+// An empty recording leaves the slot unchanged
+func (b *Bank) StopRecording() {
+	b.mu.Lock()
+	b.stopRecordingLocked()
+	b.mu.Unlock()
+
+	b.draw()
+}
+
+// Recording returns the slot being recorded, or -1
+func (b *Bank) Recording() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.recording
+}
+
+// Play starts playing a stored macro in the background
+// Several slots can play at once; playing a slot that is already playing does nothing
+func (b *Bank) Play(slot int) error {
+	if !validSlot(slot) {
+		return fmt.Errorf("invalid slot: %d", slot)
+	}
+
+	b.mu.Lock()
+	if b.macros[slot] == nil {
+		b.mu.Unlock()
+		return fmt.Errorf("slot %d is empty", slot)
+	}
+	b.playLocked(slot)
+	b.mu.Unlock()
+
+	b.draw()
+	return nil
+}
+
+// StopPlaying stops a slot's playback
+func (b *Bank) StopPlaying(slot int) {
+	if !validSlot(slot) {
+		return
+	}
+
+	b.mu.Lock()
+	b.stopPlayingLocked(slot)
+	b.mu.Unlock()
+
+	b.draw()
+}
+
+// Playing returns true if a slot is playing
+func (b *Bank) Playing(slot int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return validSlot(slot) && b.playing[slot] != nil
+}
+
+// bankJSON is the JSON format of a bank; empty slots are null
+type bankJSON struct {
+	Macros [Slots]*Macro `json:"macros"`
+}
+
+// Save writes the stored macros as JSON
+func (b *Bank) Save(w io.Writer) error {
+	b.mu.Lock()
+	v := bankJSON{Macros: b.macros}
+	data, err := json.MarshalIndent(v, "", "  ")
+	b.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Load replaces all macros with ones written by Save, stopping playback
+func (b *Bank) Load(r io.Reader) error {
+	var v bankJSON
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return fmt.Errorf("invalid macro bank: %w", err)
+	}
+
+	b.mu.Lock()
+	for slot := range b.playing {
+		b.stopPlayingLocked(slot)
+	}
+	b.macros = v.Macros
+	b.mu.Unlock()
+
+	b.draw()
+	return nil
+}
+
+// SaveFile writes the stored macros to a file
+func (b *Bank) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := b.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadFile replaces all macros with ones saved to a file
+func (b *Bank) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return b.Load(f)
+}
+
+// handleButton controls the macros from the scene buttons and captures other presses
+func (b *Bank) handleButton(event launchpad.ButtonEvent) {
+	if !event.Button.IsScene {
+		b.capture.press(event)
+		return
+	}
+
+	slot := event.Button.Y
+	b.mu.Lock()
+	if !b.running {
+		b.mu.Unlock()
+		return
+	}
+
+	if event.Pressed {
+		b.pressedAt[slot] = time.Now()
+		b.clearable[slot] = false
+		switch {
+		case b.recording == slot:
+			b.stopRecordingLocked()
+		case b.recording >= 0:
+			// Another slot is recording
+		case b.macros[slot] == nil:
+			b.recordLocked(slot)
+		case b.playing[slot] != nil:
+			b.stopPlayingLocked(slot)
+			b.clearable[slot] = true
+		default:
+			b.playLocked(slot)
+			b.clearable[slot] = true
+		}
+	} else if b.clearable[slot] && time.Since(b.pressedAt[slot]) >= HoldToClear {
+		b.stopPlayingLocked(slot)
+		b.macros[slot] = nil
+	}
+	b.mu.Unlock()
+
+	b.draw()
+}
+
+// recordLocked starts recording a slot (caller must hold the lock)
+func (b *Bank) recordLocked(slot int) {
+	b.stopPlayingLocked(slot)
+	b.recording = slot
+	b.capture.begin()
+}
+
+// stopRecordingLocked stores the recording in progress (caller must hold the lock)
+func (b *Bank) stopRecordingLocked() {
+	if b.recording < 0 {
+		return
+	}
+
+	m := b.capture.end()
+	if len(m.Presses) > 0 || len(m.Lights) > 0 {
+		if old := b.macros[b.recording]; old != nil {
+			m.Loop, m.Speed = old.Loop, old.Speed
+		}
+		b.macros[b.recording] = m
+	}
+	b.recording = -1
+}
+
+// playLocked starts a slot's playback (caller must hold the lock)
+func (b *Bank) playLocked(slot int) {
+	if b.playing[slot] != nil {
+		return
+	}
+	ctl := &playback.Control{}
+	ctl.SetSpeed(b.macros[slot].speed())
+	b.playing[slot] = ctl
+	go b.play(slot, *b.macros[slot], b.replay, ctl)
+}
+
+// stopPlayingLocked stops a slot's playback (caller must hold the lock)
+func (b *Bank) stopPlayingLocked(slot int) {
+	if b.playing[slot] != nil {
+		b.playing[slot].Stop()
+		b.playing[slot] = nil
+	}
+}
+
+// play runs a macro until it ends or its playback is stopped
+// Buttons still held when playback stops are released
+func (b *Bank) play(slot int, m Macro, replay Replay, ctl *playback.Control) {
+	held := make(map[launchpad.Button]bool)
+	defer func() {
+		for btn := range held {
+			b.lp.InjectButtonEvent(launchpad.ButtonEvent{Button: btn, Pressed: false})
+		}
+
+		b.mu.Lock()
+		if b.playing[slot] == ctl {
+			b.playing[slot] = nil
+		}
+		b.mu.Unlock()
+		b.draw()
+	}()
+
+	steps := m.timeline(replay)
+	length := m.Length
+	if len(steps) > 0 {
+		length = max(length, steps[len(steps)-1].at)
+	}
+	if length <= 0 {
+		return
+	}
+
+	// The first time checks that the slot still plays this macro, since a
+	// Stop before the Control starts playing has no effect; a single pass
+	// ends with a time at the end of the macro
+	times := []time.Duration{0}
+	for _, s := range steps {
+		times = append(times, s.at)
+	}
+	fire := func(i int) error {
+		if i == 0 {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if b.playing[slot] != ctl {
+				return playback.ErrStopped
+			}
+			return nil
+		}
+		if i > len(steps) {
+			return nil
+		}
+
+		s := steps[i-1]
+		if s.press != nil {
+			b.lp.InjectButtonEvent(*s.press)
+			if s.press.Pressed {
+				held[s.press.Button] = true
+			} else {
+				delete(held, s.press.Button)
+			}
+		} else {
+			b.lp.SetButtonLEDState(s.light.Button, s.light.State())
+		}
+		return nil
+	}
+
+	if m.Loop {
+		ctl.Loop(times, length, fire)
+	} else {
+		ctl.Play(append(times, length), fire)
+	}
+}
+
+// draw shows the state of each slot on its scene button
+func (b *Bank) draw() {
+	b.mu.Lock()
+	running := b.running
+	var states [Slots]launchpad.LEDState
+	for slot := range states {
+		switch {
+		case b.recording == slot:
+			states[slot] = colorRecording
+		case b.playing[slot] != nil:
+			states[slot] = colorPlaying
+		case b.macros[slot] != nil:
+			states[slot] = colorStored
+		}
+	}
+	b.mu.Unlock()
+
+	if !running {
+		return
+	}
+
+	b.drawMu.Lock()
+	defer b.drawMu.Unlock()
+
+	for slot, state := range states {
+		if b.shown != nil && b.shown[slot] == state {
+			continue
+		}
+		if err := b.lp.SetButtonLEDState(launchpad.NewSceneButton(slot), state); err != nil {
+			b.shown = nil
+			return
+		}
+	}
+	b.shown = &states
+}
+
+// validSlot returns true if slot is a scene button index
+func validSlot(slot int) bool {
+	return slot >= 0 && slot < Slots
+}
+
+// capture collects presses and light changes while recording
+// Scene buttons are left out: they control the bank
+type capture struct {
+	mu      sync.Mutex
+	decoder launchpad.Decoder
+	active  bool
+	start   time.Time
+	presses []recording.Event
+	lights  []show.Cue
+}
+
+// begin starts a new recording
+func (c *capture) begin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active = true
+	c.start = time.Now()
+	c.presses = nil
+	c.lights = nil
+}
+
+// end stops recording and returns the captured macro
+func (c *capture) end() *Macro {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active = false
+	return &Macro{
+		Presses: c.presses,
+		Lights:  c.lights,
+		Length:  time.Since(c.start),
+	}
+}
+
+// press captures a button event
+func (c *capture) press(event launchpad.ButtonEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active && !event.Button.IsScene {
+		c.presses = append(c.presses, recording.Event{Time: time.Since(c.start), Event: event})
+	}
+}
+
+// traffic captures the LED writes of outgoing traffic
+// Every message goes through the decoder so it keeps track of rapid updates
+func (c *capture) traffic(t launchpad.Traffic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, w := range c.decoder.LEDWrites(t) {
+		if c.active && !w.Button.IsScene {
+			c.lights = append(c.lights, show.Cue{
+				Time:     max(0, t.Time.Sub(c.start)),
+				Button:   w.Button,
+				Velocity: w.Velocity,
+			})
+		}
+	}
+}
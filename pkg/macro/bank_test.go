@@ -0,0 +1,175 @@
+package macro
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/recording"
+	"github.com/inegm/golp/pkg/simulator"
+)
+
+// events collects the button events injected by playback
+type events struct {
+	mu   sync.Mutex
+	list []launchpad.ButtonEvent
+}
+
+func (e *events) handle(event launchpad.ButtonEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, event)
+}
+
+func (e *events) get() []launchpad.ButtonEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]launchpad.ButtonEvent(nil), e.list...)
+}
+
+// waitFor waits until at least n events were injected
+func (e *events) waitFor(t *testing.T, n int) []launchpad.ButtonEvent {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if got := e.get(); len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d events, want %d", len(e.get()), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// newBank opens a Launchpad on a simulator and returns a bank and the events it injects
+func newBank(t *testing.T) (*Bank, *events) {
+	t.Helper()
+
+	lp := launchpad.New()
+	sim := simulator.New()
+	if err := lp.OpenPorts(sim.In(), sim.Out()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lp.Close() })
+
+	var e events
+	lp.OnButton(e.handle)
+	b := NewBank(lp)
+	t.Cleanup(b.Stop)
+	return b, &e
+}
+
+// tap returns a macro pressing and releasing a button
+func tap(btn launchpad.Button, at, hold, length time.Duration) *Macro {
+	return &Macro{
+		Presses: []recording.Event{
+			{Time: at, Event: launchpad.ButtonEvent{Button: btn, Pressed: true}},
+			{Time: at + hold, Event: launchpad.ButtonEvent{Button: btn, Pressed: false}},
+		},
+		Length: length,
+	}
+}
+
+func TestBankLoop(t *testing.T) {
+	b, e := newBank(t)
+	btn := launchpad.NewGridButton(2, 3)
+
+	m := tap(btn, 0, 2*time.Millisecond, 5*time.Millisecond)
+	m.Loop = true
+	if err := b.SetMacro(0, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Play(0); err != nil {
+		t.Fatal(err)
+	}
+
+	got := e.waitFor(t, 6)
+	for i, event := range got[:6] {
+		if event.Button != btn || event.Pressed != (i%2 == 0) {
+			t.Fatalf("event %d is %v, want alternating presses of %v", i, event, btn)
+		}
+	}
+	if !b.Playing(0) {
+		t.Fatal("looping macro stopped by itself")
+	}
+
+	b.StopPlaying(0)
+	if b.Playing(0) {
+		t.Fatal("slot still playing after StopPlaying")
+	}
+	n := len(e.get())
+	time.Sleep(20 * time.Millisecond)
+
+	// Only the release of a held button may follow
+	after := e.get()[n:]
+	if len(after) > 1 || len(after) == 1 && after[0].Pressed {
+		t.Fatalf("events after StopPlaying: %v", after)
+	}
+}
+
+func TestBankPlayOnce(t *testing.T) {
+	b, e := newBank(t)
+	if err := b.SetMacro(1, tap(launchpad.NewGridButton(0, 0), 0, time.Millisecond, 10*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Play(1); err != nil {
+		t.Fatal(err)
+	}
+
+	e.waitFor(t, 2)
+	if !b.Playing(1) {
+		t.Fatal("macro ended before its length")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for b.Playing(1) {
+		if time.Now().After(deadline) {
+			t.Fatal("macro did not end")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := e.get(); len(got) != 2 {
+		t.Fatalf("got events %v, want one tap", got)
+	}
+}
+
+func TestBankStopReleasesHeldButtons(t *testing.T) {
+	b, e := newBank(t)
+	btn := launchpad.NewGridButton(7, 7)
+
+	if err := b.SetMacro(2, tap(btn, 0, time.Hour, time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Play(2); err != nil {
+		t.Fatal(err)
+	}
+	e.waitFor(t, 1)
+
+	b.StopPlaying(2)
+	got := e.waitFor(t, 2)
+	if got[1] != (launchpad.ButtonEvent{Button: btn, Pressed: false}) {
+		t.Fatalf("event after stop is %v, want a release of %v", got[1], btn)
+	}
+}
+
+func TestBankStopRightAfterPlay(t *testing.T) {
+	b, e := newBank(t)
+	if err := b.SetMacro(3, tap(launchpad.NewGridButton(1, 1), 20*time.Millisecond, time.Millisecond, 30*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stopping before the playback goroutine starts must still stop it
+	for i := 0; i < 50; i++ {
+		if err := b.Play(3); err != nil {
+			t.Fatal(err)
+		}
+		b.StopPlaying(3)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := e.get(); len(got) != 0 {
+		t.Fatalf("stopped playback injected %v", got)
+	}
+}
@@ -0,0 +1,149 @@
+// Package macro records pad presses and the light changes they cause, and
+// replays them from the scene buttons.
+//
+// A Bank keeps one Macro per scene button. Pressing an empty scene button
+// starts recording, pressing it again stores the macro, and pressing a stored
+// one starts or stops its playback; holding it for HoldToClear erases it:
+//
+//	bank := macro.NewBank(lp)
+//	bank.Start()
+//	bank.SetLoop(0, true)
+//	bank.SetSpeed(0, 1.5)
+//
+//	// Keep macros between sessions
+//	bank.LoadFile("macros.json")
+//	defer bank.SaveFile("macros.json")
+//
+// Playback injects the recorded presses, so the handlers that lit the pads
+// during recording light them again; SetReplay can instead replay the
+// recorded light changes directly, or both.
+package macro
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/recording"
+	"github.com/inegm/golp/pkg/show"
+)
+
+// Macro is a recorded sequence of presses and light changes
+type Macro struct {
+	Presses []recording.Event // Button events, in time order
+	Lights  []show.Cue        // LED changes, in time order
+	Length  time.Duration     // Time from the start to the end of the recording
+	Loop    bool              // Restart at the end until stopped
+	Speed   float64           // Playback speed, 1 if zero
+}
+
+// step is one press or light change of a macro's timeline
+type step struct {
+	at    time.Duration
+	press *launchpad.ButtonEvent
+	light *show.Cue
+}
+
+// timeline merges the presses and light changes selected by replay in time order
+// At equal times, presses come first, as they caused the light changes
+func (m *Macro) timeline(replay Replay) []step {
+	var steps []step
+	if replay != ReplayLights {
+		for i := range m.Presses {
+			steps = append(steps, step{at: m.Presses[i].Time, press: &m.Presses[i].Event})
+		}
+	}
+	if replay != ReplayPresses {
+		for i := range m.Lights {
+			steps = append(steps, step{at: m.Lights[i].Time, light: &m.Lights[i]})
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].at < steps[j].at
+	})
+	return steps
+}
+
+// speed returns the playback speed
+func (m *Macro) speed() float64 {
+	if m.Speed <= 0 {
+		return 1
+	}
+	return m.Speed
+}
+
+// macroJSON is the JSON format of a Macro
+type macroJSON struct {
+	Presses []recording.Event `json:"presses"`
+	Lights  []lightJSON       `json:"lights"`
+	Length  int64             `json:"length_us"`
+	Loop    bool              `json:"loop,omitempty"`
+	Speed   float64           `json:"speed,omitempty"`
+}
+
+// lightJSON is the JSON format of a light change
+// Coordinates follow Button.X and Button.Y (x=8 for scene buttons, y=-1 for top buttons)
+type lightJSON struct {
+	Micros   int64 `json:"t_us"`
+	X        int   `json:"x"`
+	Y        int   `json:"y"`
+	Velocity byte  `json:"velocity"`
+}
+
+// MarshalJSON encodes a macro; light changes hold the LED velocity byte
+func (m *Macro) MarshalJSON() ([]byte, error) {
+	v := macroJSON{
+		Presses: m.Presses,
+		Lights:  make([]lightJSON, len(m.Lights)),
+		Length:  m.Length.Microseconds(),
+		Loop:    m.Loop,
+		Speed:   m.Speed,
+	}
+	if v.Presses == nil {
+		v.Presses = []recording.Event{}
+	}
+	for i, cue := range m.Lights {
+		v.Lights[i] = lightJSON{
+			Micros:   cue.Time.Microseconds(),
+			X:        cue.Button.X,
+			Y:        cue.Button.Y,
+			Velocity: cue.Velocity,
+		}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a macro
+func (m *Macro) UnmarshalJSON(data []byte) error {
+	var v macroJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Speed < 0 {
+		return fmt.Errorf("invalid speed: %v", v.Speed)
+	}
+
+	lights := make([]show.Cue, len(v.Lights))
+	for i, l := range v.Lights {
+		btn := launchpad.ButtonAt(l.X, l.Y)
+		if !btn.Valid() {
+			return fmt.Errorf("invalid button position: (%d, %d)", l.X, l.Y)
+		}
+		lights[i] = show.Cue{
+			Time:     time.Duration(l.Micros) * time.Microsecond,
+			Button:   btn,
+			Velocity: l.Velocity,
+		}
+	}
+
+	*m = Macro{
+		Presses: v.Presses,
+		Lights:  lights,
+		Length:  time.Duration(v.Length) * time.Microsecond,
+		Loop:    v.Loop,
+		Speed:   v.Speed,
+	}
+	return nil
+}
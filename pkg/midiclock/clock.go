@@ -0,0 +1,346 @@
+// Package midiclock provides musical time from MIDI clock.
+//
+// A Clock either follows MIDI clock received on an input port (timing clock,
+// start, stop, continue and song position pointer) or leads by sending clock to
+// an output port at a set tempo. Either way it counts pulses, reports beats and
+// bars to handlers and estimates the tempo, smoothing out timing jitter:
+//
+//	clk := midiclock.New()
+//	clk.OnBeat(func(e midiclock.BeatEvent) {
+//	    fmt.Printf("bar %d beat %d at %.1f BPM\n", e.Bar, e.BeatInBar, e.Tempo)
+//	})
+//	err := clk.FollowPort("IAC Driver Bus 1")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer clk.Close()
+package midiclock
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// PulsesPerQuarter is the MIDI clock resolution (24 pulses per quarter note)
+const PulsesPerQuarter = 24
+
+// pulsesPerSixteenth is the resolution of the song position pointer
+const pulsesPerSixteenth = PulsesPerQuarter / 4
+
+// DefaultBeatsPerBar is the default time signature numerator
+const DefaultBeatsPerBar = 4
+
+// Tempo limits accepted by SetTempo
+const (
+	MinTempo = 20.0  // Slowest tempo in beats per minute
+	MaxTempo = 300.0 // Fastest tempo in beats per minute
+)
+
+// smoothing is the weight of each new pulse interval in the tempo estimate
+// Lower values reject more jitter but follow tempo changes more slowly
+const smoothing = 0.08
+
+// MIDI real-time and system common status bytes
+const (
+	statusTimingClock  = 0xF8
+	statusStart        = 0xFA
+	statusContinue     = 0xFB
+	statusStop         = 0xFC
+	statusSongPosition = 0xF2
+)
+
+// Mode is the clock's role
+type Mode int
+
+const (
+	ModeIdle   Mode = iota // Neither following nor leading
+	ModeFollow             // Following incoming MIDI clock
+	ModeLead               // Sending MIDI clock
+)
+
+// String returns the string representation of a Mode
+func (m Mode) String() string {
+	switch m {
+	case ModeIdle:
+		return "Idle"
+	case ModeFollow:
+		return "Follow"
+	case ModeLead:
+		return "Lead"
+	default:
+		return fmt.Sprintf("Mode(%d)", m)
+	}
+}
+
+// TransportKind is the kind of a transport change
+type TransportKind int
+
+const (
+	TransportStart    TransportKind = iota // Playback started from the beginning
+	TransportStop                          // Playback stopped
+	TransportContinue                      // Playback resumed from the current position
+	TransportLocate                        // Position changed by a song position pointer
+)
+
+// String returns the string representation of a TransportKind
+func (k TransportKind) String() string {
+	switch k {
+	case TransportStart:
+		return "Start"
+	case TransportStop:
+		return "Stop"
+	case TransportContinue:
+		return "Continue"
+	case TransportLocate:
+		return "Locate"
+	default:
+		return fmt.Sprintf("TransportKind(%d)", k)
+	}
+}
+
+// TransportEvent reports a start, stop, continue or position change
+type TransportEvent struct {
+	Kind     TransportKind // What changed
+	Position int64         // Position in pulses after the change
+}
+
+// BeatEvent reports the start of a beat
+type BeatEvent struct {
+	Beat      int64     // Beats since the start of the song
+	Bar       int64     // Bars since the start of the song
+	BeatInBar int       // Beat within the bar (0 is the downbeat)
+	Tempo     float64   // Tempo estimate in beats per minute
+	Time      time.Time // Arrival time of the pulse starting the beat
+}
+
+// Clock tracks musical time from MIDI clock
+type Clock struct {
+	mu          sync.Mutex
+	mode        Mode
+	playing     bool
+	position    int64 // Pulses since the start of the song
+	beatsPerBar int
+
+	// Tempo estimation
+	lastPulse time.Time
+	interval  float64 // Smoothed pulse interval in seconds, 0 if unknown
+
+	// Handlers
+	pulseHandlers     []func(position int64)
+	beatHandlers      []func(BeatEvent)
+	transportHandlers []func(TransportEvent)
+
+	// Following
+	in         drivers.In
+	ownsIn     bool
+	stopListen func()
+
+	// Leading
+	outMu    sync.Mutex
+	out      drivers.Out
+	tempo    float64
+	stopLead chan struct{}
+	leadDone chan struct{}
+}
+
+// New creates an idle clock
+func New() *Clock {
+	return &Clock{
+		beatsPerBar: DefaultBeatsPerBar,
+		tempo:       120,
+	}
+}
+
+// Mode returns whether the clock is idle, following or leading
+func (c *Clock) Mode() Mode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mode
+}
+
+// SetBeatsPerBar sets the time signature numerator used to count bars
+func (c *Clock) SetBeatsPerBar(beats int) error {
+	if beats < 1 {
+		return fmt.Errorf("invalid beats per bar: %d", beats)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.beatsPerBar = beats
+	return nil
+}
+
+// Playing returns whether the transport is playing
+func (c *Clock) Playing() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.playing
+}
+
+// Position returns the song position in pulses (24 per beat)
+func (c *Clock) Position() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.position
+}
+
+// Tempo returns the tempo in beats per minute
+// When following, this is the smoothed estimate (0 until two pulses have arrived)
+func (c *Clock) Tempo() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tempoLocked()
+}
+
+// tempoLocked returns the tempo (caller must hold the lock)
+func (c *Clock) tempoLocked() float64 {
+	if c.mode == ModeLead {
+		return c.tempo
+	}
+	if c.interval == 0 {
+		return 0
+	}
+	return 60 / (c.interval * PulsesPerQuarter)
+}
+
+// PulseDuration returns the current duration of one pulse (0 if unknown)
+func (c *Clock) PulseDuration() time.Duration {
+	tempo := c.Tempo()
+	if tempo == 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Minute) / (tempo * PulsesPerQuarter))
+}
+
+// OnPulse registers a handler called for every pulse while playing
+// Handlers are called from the listening or sending goroutine and should return quickly
+func (c *Clock) OnPulse(handler func(position int64)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pulseHandlers = append(c.pulseHandlers, handler)
+}
+
+// OnBeat registers a handler called at the start of every beat while playing
+func (c *Clock) OnBeat(handler func(BeatEvent)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.beatHandlers = append(c.beatHandlers, handler)
+}
+
+// OnStep registers a handler called stepsPerBeat times per beat while playing,
+// e.g. 4 for sixteenth notes; step counts from the start of the song
+// stepsPerBeat must divide 24 evenly
+func (c *Clock) OnStep(stepsPerBeat int, handler func(step int64)) error {
+	if stepsPerBeat < 1 || PulsesPerQuarter%stepsPerBeat != 0 {
+		return fmt.Errorf("invalid steps per beat: %d", stepsPerBeat)
+	}
+	pulses := int64(PulsesPerQuarter / stepsPerBeat)
+	c.OnPulse(func(position int64) {
+		if position%pulses == 0 {
+			handler(position / pulses)
+		}
+	})
+	return nil
+}
+
+// OnTransport registers a handler called on start, stop, continue and position changes
+func (c *Clock) OnTransport(handler func(TransportEvent)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transportHandlers = append(c.transportHandlers, handler)
+}
+
+// Close stops following or leading
+func (c *Clock) Close() error {
+	c.mu.Lock()
+	mode := c.mode
+	c.mu.Unlock()
+
+	switch mode {
+	case ModeFollow:
+		return c.stopFollowing()
+	case ModeLead:
+		c.stopLeading()
+	}
+	return nil
+}
+
+// pulse advances the clock by one pulse received or sent at a time
+func (c *Clock) pulse(at time.Time) {
+	c.mu.Lock()
+
+	// Update the tempo estimate, ignoring gaps and bursts far from the estimate
+	if !c.lastPulse.IsZero() {
+		interval := at.Sub(c.lastPulse).Seconds()
+		switch {
+		case c.interval == 0:
+			c.interval = interval
+		case interval > c.interval*4 || interval < c.interval/4:
+			// Outlier: probably a pause in the clock stream, start over
+			c.interval = interval
+		default:
+			c.interval += smoothing * (interval - c.interval)
+		}
+	}
+	c.lastPulse = at
+
+	if !c.playing {
+		c.mu.Unlock()
+		return
+	}
+
+	position := c.position
+	c.position++
+
+	pulseHandlers := make([]func(int64), len(c.pulseHandlers))
+	copy(pulseHandlers, c.pulseHandlers)
+
+	var beatHandlers []func(BeatEvent)
+	var beat BeatEvent
+	if position%PulsesPerQuarter == 0 {
+		beatHandlers = make([]func(BeatEvent), len(c.beatHandlers))
+		copy(beatHandlers, c.beatHandlers)
+		n := position / PulsesPerQuarter
+		beat = BeatEvent{
+			Beat:      n,
+			Bar:       n / int64(c.beatsPerBar),
+			BeatInBar: int(n % int64(c.beatsPerBar)),
+			Tempo:     c.tempoLocked(),
+			Time:      at,
+		}
+	}
+	c.mu.Unlock()
+
+	for _, handler := range pulseHandlers {
+		handler(position)
+	}
+	for _, handler := range beatHandlers {
+		handler(beat)
+	}
+}
+
+// transport applies a transport change and notifies handlers
+func (c *Clock) transport(kind TransportKind, position int64) {
+	c.mu.Lock()
+	switch kind {
+	case TransportStart:
+		c.playing = true
+		c.position = 0
+	case TransportStop:
+		c.playing = false
+	case TransportContinue:
+		c.playing = true
+	case TransportLocate:
+		c.position = position
+	}
+	e := TransportEvent{Kind: kind, Position: c.position}
+	handlers := make([]func(TransportEvent), len(c.transportHandlers))
+	copy(handlers, c.transportHandlers)
+	c.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+}
@@ -0,0 +1,105 @@
+package midiclock
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// FollowPort opens the first MIDI input port whose name contains name and follows its clock
+// The port is closed by Close
+func (c *Clock) FollowPort(name string) error {
+	in, err := midi.FindInPort(name)
+	if err != nil {
+		return fmt.Errorf("failed to find MIDI input %q: %w", name, err)
+	}
+	err = in.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open MIDI input %q: %w", name, err)
+	}
+
+	err = c.Follow(in)
+	if err != nil {
+		in.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	c.ownsIn = true
+	c.mu.Unlock()
+	return nil
+}
+
+// Follow follows the MIDI clock received on an open input port
+// The port is left open by Close
+func (c *Clock) Follow(in drivers.In) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.mode != ModeIdle {
+		return fmt.Errorf("clock already in %v mode", c.mode)
+	}
+
+	stop, err := midi.ListenTo(in, func(msg midi.Message, timestampms int32) {
+		c.Handle(msg, time.Now())
+	}, midi.UseTimeCode())
+	if err != nil {
+		return fmt.Errorf("failed to start MIDI listener: %w", err)
+	}
+
+	c.mode = ModeFollow
+	c.in = in
+	c.stopListen = stop
+	c.lastPulse = time.Time{}
+	c.interval = 0
+	return nil
+}
+
+// Handle processes a raw MIDI message that arrived at a given time
+// It is called for every message received by Follow, and can also be fed
+// directly, e.g. from another listener on the same port
+func (c *Clock) Handle(msg []byte, at time.Time) {
+	if len(msg) == 0 {
+		return
+	}
+
+	switch msg[0] {
+	case statusTimingClock:
+		c.pulse(at)
+	case statusStart:
+		c.transport(TransportStart, 0)
+	case statusContinue:
+		c.transport(TransportContinue, 0)
+	case statusStop:
+		c.transport(TransportStop, 0)
+	case statusSongPosition:
+		if len(msg) < 3 {
+			return
+		}
+		// 14-bit count of sixteenth notes, least significant byte first
+		sixteenths := int64(msg[1]&0x7F) | int64(msg[2]&0x7F)<<7
+		c.transport(TransportLocate, sixteenths*pulsesPerSixteenth)
+	}
+}
+
+// stopFollowing stops listening and closes the input port if it was opened by FollowPort
+func (c *Clock) stopFollowing() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopListen != nil {
+		c.stopListen()
+		c.stopListen = nil
+	}
+
+	var err error
+	if c.ownsIn {
+		err = c.in.Close()
+		c.ownsIn = false
+	}
+	c.in = nil
+	c.mode = ModeIdle
+	return err
+}
@@ -0,0 +1,137 @@
+package midiclock
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// Lead sends MIDI clock to an open output port at a tempo
+// Clock pulses are sent continuously; use Start, Stop, Continue and Locate to
+// control the transport of the receiving devices
+func (c *Clock) Lead(out drivers.Out, bpm float64) error {
+	if bpm < MinTempo || bpm > MaxTempo {
+		return fmt.Errorf("invalid tempo: %v", bpm)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.mode != ModeIdle {
+		return fmt.Errorf("clock already in %v mode", c.mode)
+	}
+
+	c.outMu.Lock()
+	c.out = out
+	c.outMu.Unlock()
+
+	c.mode = ModeLead
+	c.tempo = bpm
+	c.lastPulse = time.Time{}
+	c.interval = 0
+	c.stopLead = make(chan struct{})
+	c.leadDone = make(chan struct{})
+	go c.runLead(c.stopLead, c.leadDone)
+	return nil
+}
+
+// SetTempo changes the tempo sent while leading, from the next pulse
+func (c *Clock) SetTempo(bpm float64) error {
+	if bpm < MinTempo || bpm > MaxTempo {
+		return fmt.Errorf("invalid tempo: %v", bpm)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tempo = bpm
+	return nil
+}
+
+// Start sends a start message and plays from the beginning
+func (c *Clock) Start() error {
+	return c.sendTransport(midi.Start(), TransportStart, 0)
+}
+
+// Stop sends a stop message and stops playing
+func (c *Clock) Stop() error {
+	return c.sendTransport(midi.Stop(), TransportStop, 0)
+}
+
+// Continue sends a continue message and plays from the current position
+func (c *Clock) Continue() error {
+	return c.sendTransport(midi.Continue(), TransportContinue, 0)
+}
+
+// Locate sends a song position pointer and moves to a position in sixteenth notes
+func (c *Clock) Locate(sixteenths uint16) error {
+	if sixteenths > 0x3FFF {
+		return fmt.Errorf("invalid song position: %d", sixteenths)
+	}
+	return c.sendTransport(midi.SPP(sixteenths), TransportLocate, int64(sixteenths)*pulsesPerSixteenth)
+}
+
+// sendTransport sends a transport message while leading and applies it locally
+func (c *Clock) sendTransport(msg midi.Message, kind TransportKind, position int64) error {
+	if c.Mode() != ModeLead {
+		return fmt.Errorf("transport control requires %v mode", ModeLead)
+	}
+
+	c.outMu.Lock()
+	err := c.out.Send(msg)
+	c.outMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to send %v: %w", kind, err)
+	}
+
+	c.transport(kind, position)
+	return nil
+}
+
+// runLead sends pulses until stop is closed
+// Pulses are scheduled against absolute times so they do not drift
+func (c *Clock) runLead(stop, done chan struct{}) {
+	defer close(done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	next := time.Now()
+	for {
+		timer.Reset(time.Until(next))
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+
+		c.outMu.Lock()
+		c.out.Send(midi.TimingClock())
+		c.outMu.Unlock()
+
+		c.pulse(next)
+
+		c.mu.Lock()
+		interval := time.Duration(float64(time.Minute) / (c.tempo * PulsesPerQuarter))
+		c.mu.Unlock()
+		next = next.Add(interval)
+	}
+}
+
+// stopLeading stops sending pulses
+func (c *Clock) stopLeading() {
+	c.mu.Lock()
+	stop, done := c.stopLead, c.leadDone
+	c.stopLead, c.leadDone = nil, nil
+	c.mode = ModeIdle
+	c.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	c.outMu.Lock()
+	c.out = nil
+	c.outMu.Unlock()
+}
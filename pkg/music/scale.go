@@ -0,0 +1,107 @@
+// Package music provides scales and note helpers shared by the melodic
+// controllers (bridge layouts and keyboard mode).
+package music
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scale is a set of intervals in semitones above a root note
+type Scale struct {
+	Name      string // Display name
+	Intervals []int  // Ascending semitone offsets within an octave, starting with 0
+}
+
+// Common scales
+var (
+	Chromatic       = Scale{"Chromatic", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}
+	Major           = Scale{"Major", []int{0, 2, 4, 5, 7, 9, 11}}
+	NaturalMinor    = Scale{"Minor", []int{0, 2, 3, 5, 7, 8, 10}}
+	HarmonicMinor   = Scale{"Harmonic Minor", []int{0, 2, 3, 5, 7, 8, 11}}
+	MelodicMinor    = Scale{"Melodic Minor", []int{0, 2, 3, 5, 7, 9, 11}}
+	Dorian          = Scale{"Dorian", []int{0, 2, 3, 5, 7, 9, 10}}
+	Phrygian        = Scale{"Phrygian", []int{0, 1, 3, 5, 7, 8, 10}}
+	Lydian          = Scale{"Lydian", []int{0, 2, 4, 6, 7, 9, 11}}
+	Mixolydian      = Scale{"Mixolydian", []int{0, 2, 4, 5, 7, 9, 10}}
+	Locrian         = Scale{"Locrian", []int{0, 1, 3, 5, 6, 8, 10}}
+	MajorPentatonic = Scale{"Major Pentatonic", []int{0, 2, 4, 7, 9}}
+	MinorPentatonic = Scale{"Minor Pentatonic", []int{0, 3, 5, 7, 10}}
+	Blues           = Scale{"Blues", []int{0, 3, 5, 6, 7, 10}}
+)
+
+// Scales lists the predefined scales
+var Scales = []Scale{
+	Chromatic, Major, NaturalMinor, HarmonicMinor, MelodicMinor,
+	Dorian, Phrygian, Lydian, Mixolydian, Locrian,
+	MajorPentatonic, MinorPentatonic, Blues,
+}
+
+// ScaleByName returns a predefined scale by case-insensitive name
+func ScaleByName(name string) (Scale, error) {
+	for _, s := range Scales {
+		if strings.EqualFold(s.Name, name) {
+			return s, nil
+		}
+	}
+	return Scale{}, fmt.Errorf("unknown scale: %q", name)
+}
+
+// String returns the scale name
+func (s Scale) String() string {
+	return s.Name
+}
+
+// Len returns the number of notes per octave
+func (s Scale) Len() int {
+	return len(s.Intervals)
+}
+
+// Note returns the MIDI note of a scale degree above a root
+// Degrees beyond the scale wrap into higher (or, when negative, lower) octaves
+func (s Scale) Note(root, degree int) int {
+	n := len(s.Intervals)
+	if n == 0 {
+		return root
+	}
+	octave := degree / n
+	step := degree % n
+	if step < 0 {
+		step += n
+		octave--
+	}
+	return root + (12 * octave) + s.Intervals[step]
+}
+
+// Contains returns true if a note belongs to the scale built on root
+func (s Scale) Contains(root, note int) bool {
+	pc := PitchClass(note - root)
+	for _, interval := range s.Intervals {
+		if interval == pc {
+			return true
+		}
+	}
+	return false
+}
+
+// PitchClass returns a note's position within its octave (0-11)
+func PitchClass(note int) int {
+	pc := note % 12
+	if pc < 0 {
+		pc += 12
+	}
+	return pc
+}
+
+// noteNames are the pitch class names using sharps
+var noteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// NoteName returns the name of a MIDI note, e.g. 60 is "C3" as in the Launchpad reference
+func NoteName(note int) string {
+	return fmt.Sprintf("%s%d", noteNames[PitchClass(note)], (note/12)-2)
+}
+
+// ValidNote returns true if a note is in the MIDI range (0-127)
+func ValidNote(note int) bool {
+	return note >= 0 && note <= 127
+}
@@ -0,0 +1,279 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// bundleTag starts every OSC bundle
+const bundleTag = "#bundle"
+
+// Message is an OSC message: an address pattern and a list of arguments
+//
+// Supported argument types are int32 ('i'), float32 ('f'), string ('s'),
+// []byte ('b'), int64 ('h'), float64 ('d'), bool ('T' and 'F') and nil ('N').
+type Message struct {
+	Address string
+	Args    []any
+}
+
+// NewMessage creates a message
+func NewMessage(address string, args ...any) Message {
+	return Message{Address: address, Args: args}
+}
+
+// String returns a readable representation of the message
+func (m Message) String() string {
+	parts := []string{m.Address}
+	for _, arg := range m.Args {
+		parts = append(parts, fmt.Sprint(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Int returns argument i as an int, converting from any numeric type
+func (m Message) Int(i int) (int, error) {
+	if i >= len(m.Args) {
+		return 0, fmt.Errorf("%s: missing argument %d", m.Address, i)
+	}
+	switch v := m.Args[i].(type) {
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case float32:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%s: argument %d is not a number: %T", m.Address, i, v)
+	}
+}
+
+// MarshalBinary encodes the message in OSC wire format
+func (m Message) MarshalBinary() ([]byte, error) {
+	if !strings.HasPrefix(m.Address, "/") {
+		return nil, fmt.Errorf("invalid OSC address: %q", m.Address)
+	}
+
+	var buf bytes.Buffer
+	writeString(&buf, m.Address)
+
+	tags := []byte{','}
+	var args bytes.Buffer
+	for _, arg := range m.Args {
+		switch v := arg.(type) {
+		case int32:
+			tags = append(tags, 'i')
+			binary.Write(&args, binary.BigEndian, v)
+		case int:
+			tags = append(tags, 'i')
+			binary.Write(&args, binary.BigEndian, int32(v))
+		case float32:
+			tags = append(tags, 'f')
+			binary.Write(&args, binary.BigEndian, math.Float32bits(v))
+		case string:
+			tags = append(tags, 's')
+			writeString(&args, v)
+		case []byte:
+			tags = append(tags, 'b')
+			binary.Write(&args, binary.BigEndian, int32(len(v)))
+			args.Write(v)
+			pad(&args)
+		case int64:
+			tags = append(tags, 'h')
+			binary.Write(&args, binary.BigEndian, v)
+		case float64:
+			tags = append(tags, 'd')
+			binary.Write(&args, binary.BigEndian, math.Float64bits(v))
+		case bool:
+			if v {
+				tags = append(tags, 'T')
+			} else {
+				tags = append(tags, 'F')
+			}
+		case nil:
+			tags = append(tags, 'N')
+		default:
+			return nil, fmt.Errorf("unsupported OSC argument type: %T", arg)
+		}
+	}
+
+	writeString(&buf, string(tags))
+	buf.Write(args.Bytes())
+	return buf.Bytes(), nil
+}
+
+// Parse decodes an OSC packet, which is either a single message or a bundle
+// Bundles are flattened into their messages; time tags are ignored
+func Parse(packet []byte) ([]Message, error) {
+	if len(packet) == 0 || len(packet)%4 != 0 {
+		return nil, fmt.Errorf("invalid OSC packet size: %d", len(packet))
+	}
+
+	if packet[0] == '#' {
+		return parseBundle(packet)
+	}
+
+	msg, err := parseMessage(packet)
+	if err != nil {
+		return nil, err
+	}
+	return []Message{msg}, nil
+}
+
+// parseBundle decodes a bundle's elements
+func parseBundle(packet []byte) ([]Message, error) {
+	r := bytes.NewReader(packet)
+	tag, err := readString(r)
+	if err != nil || tag != bundleTag {
+		return nil, fmt.Errorf("invalid OSC bundle")
+	}
+
+	var timeTag uint64
+	if err := binary.Read(r, binary.BigEndian, &timeTag); err != nil {
+		return nil, fmt.Errorf("invalid OSC bundle: %w", err)
+	}
+
+	var messages []Message
+	for r.Len() > 0 {
+		var size int32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return nil, fmt.Errorf("invalid OSC bundle element: %w", err)
+		}
+		if size < 0 || int(size) > r.Len() {
+			return nil, fmt.Errorf("invalid OSC bundle element size: %d", size)
+		}
+		element := make([]byte, size)
+		r.Read(element)
+
+		inner, err := Parse(element)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, inner...)
+	}
+	return messages, nil
+}
+
+// parseMessage decodes a single message
+func parseMessage(packet []byte) (Message, error) {
+	r := bytes.NewReader(packet)
+	address, err := readString(r)
+	if err != nil || !strings.HasPrefix(address, "/") {
+		return Message{}, fmt.Errorf("invalid OSC address")
+	}
+
+	msg := Message{Address: address}
+	if r.Len() == 0 {
+		return msg, nil // Type tags are optional in old implementations
+	}
+
+	tags, err := readString(r)
+	if err != nil || !strings.HasPrefix(tags, ",") {
+		return Message{}, fmt.Errorf("%s: invalid OSC type tags", address)
+	}
+
+	for _, tag := range tags[1:] {
+		var arg any
+		switch tag {
+		case 'i':
+			var v int32
+			err = binary.Read(r, binary.BigEndian, &v)
+			arg = v
+		case 'f':
+			var v uint32
+			err = binary.Read(r, binary.BigEndian, &v)
+			arg = math.Float32frombits(v)
+		case 's', 'S':
+			arg, err = readString(r)
+		case 'b':
+			arg, err = readBlob(r)
+		case 'h', 't':
+			var v int64
+			err = binary.Read(r, binary.BigEndian, &v)
+			arg = v
+		case 'd':
+			var v uint64
+			err = binary.Read(r, binary.BigEndian, &v)
+			arg = math.Float64frombits(v)
+		case 'T':
+			arg = true
+		case 'F':
+			arg = false
+		case 'N', 'I':
+			arg = nil
+		default:
+			return Message{}, fmt.Errorf("%s: unsupported OSC type tag %q", address, tag)
+		}
+		if err != nil {
+			return Message{}, fmt.Errorf("%s: truncated OSC argument: %w", address, err)
+		}
+		msg.Args = append(msg.Args, arg)
+	}
+	return msg, nil
+}
+
+// writeString writes a null-terminated string padded to 4 bytes
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+	buf.WriteByte(0)
+	pad(buf)
+}
+
+// pad writes zero bytes up to the next multiple of 4
+func pad(buf *bytes.Buffer) {
+	for buf.Len()%4 != 0 {
+		buf.WriteByte(0)
+	}
+}
+
+// readString reads a null-terminated string padded to 4 bytes
+func readString(r *bytes.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", errors.New("unterminated OSC string")
+		}
+		if c == 0 {
+			break
+		}
+		b.WriteByte(c)
+	}
+	// Skip padding: the string and its terminator occupy a multiple of 4 bytes
+	for n := b.Len() + 1; n%4 != 0; n++ {
+		if _, err := r.ReadByte(); err != nil {
+			return "", errors.New("truncated OSC string padding")
+		}
+	}
+	return b.String(), nil
+}
+
+// readBlob reads a size-prefixed blob padded to 4 bytes
+func readBlob(r *bytes.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size < 0 || int(size) > r.Len() {
+		return nil, fmt.Errorf("invalid OSC blob size: %d", size)
+	}
+	blob := make([]byte, size)
+	r.Read(blob)
+	for n := int(size); n%4 != 0; n++ {
+		if _, err := r.ReadByte(); err != nil {
+			return nil, errors.New("truncated OSC blob padding")
+		}
+	}
+	return blob, nil
+}
@@ -0,0 +1,192 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"no arguments", NewMessage("/clear")},
+		{"int32", NewMessage("/led", int32(3), int32(-1), int32(3), int32(0))},
+		{"float32", NewMessage("/fader", float32(0.5))},
+		{"string padded", NewMessage("/name", "abc")},
+		{"string aligned", NewMessage("/name", "abcd")},
+		{"empty string", NewMessage("/name", "")},
+		{"blob", NewMessage("/frame", []byte{1, 2, 3, 4, 5})},
+		{"empty blob", NewMessage("/frame", []byte{})},
+		{"int64", NewMessage("/big", int64(math.MaxInt64))},
+		{"float64", NewMessage("/precise", math.Pi)},
+		{"bools and nil", NewMessage("/flags", true, false, nil)},
+		{"mixed", NewMessage("/a/b/c", int32(1), "two", float32(3), []byte{4}, true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet, err := tt.msg.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(packet)%4 != 0 {
+				t.Fatalf("packet is %d bytes, not a multiple of 4", len(packet))
+			}
+
+			messages, err := Parse(packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(messages))
+			}
+			if !reflect.DeepEqual(messages[0], tt.msg) {
+				t.Fatalf("round trip = %#v, want %#v", messages[0], tt.msg)
+			}
+		})
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		want []byte
+	}{
+		{
+			"int becomes int32",
+			NewMessage("/x", 7),
+			[]byte{'/', 'x', 0, 0, ',', 'i', 0, 0, 0, 0, 0, 7},
+		},
+		{
+			"string padding",
+			NewMessage("/led", "ab"),
+			[]byte{'/', 'l', 'e', 'd', 0, 0, 0, 0, ',', 's', 0, 0, 'a', 'b', 0, 0},
+		},
+		{
+			"blob padding",
+			NewMessage("/b", []byte{9}),
+			[]byte{'/', 'b', 0, 0, ',', 'b', 0, 0, 0, 0, 0, 1, 9, 0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.msg.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalBinaryErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"address without slash", NewMessage("led")},
+		{"unsupported type", NewMessage("/x", struct{}{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.msg.MarshalBinary(); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+// bundle wraps encoded messages in an OSC bundle
+func bundle(t *testing.T, messages ...Message) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writeString(&buf, bundleTag)
+	binary.Write(&buf, binary.BigEndian, uint64(1)) // Immediately
+	for _, msg := range messages {
+		packet, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		binary.Write(&buf, binary.BigEndian, int32(len(packet)))
+		buf.Write(packet)
+	}
+	return buf.Bytes()
+}
+
+func TestParseBundle(t *testing.T) {
+	want := []Message{
+		NewMessage("/led", int32(0), int32(0), int32(3), int32(0)),
+		NewMessage("/clear"),
+	}
+
+	got, err := Parse(bundle(t, want...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Nested bundles are flattened
+	inner := bundle(t, want[1])
+	var outer bytes.Buffer
+	writeString(&outer, bundleTag)
+	binary.Write(&outer, binary.BigEndian, uint64(1))
+	binary.Write(&outer, binary.BigEndian, int32(len(inner)))
+	outer.Write(inner)
+
+	got, err = Parse(outer.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want[1:]) {
+		t.Fatalf("nested bundle = %v, want %v", got, want[1:])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid, _ := NewMessage("/led", int32(1), "abc").MarshalBinary()
+
+	tests := []struct {
+		name   string
+		packet []byte
+	}{
+		{"empty", nil},
+		{"not aligned", []byte{'/', 'x', 0}},
+		{"no slash", []byte{'x', 0, 0, 0}},
+		{"unterminated address", []byte{'/', 'a', 'b', 'c'}},
+		{"bad type tags", []byte{'/', 'x', 0, 0, 'i', 0, 0, 0}},
+		{"unknown type tag", []byte{'/', 'x', 0, 0, ',', 'z', 0, 0}},
+		{"truncated argument", valid[:len(valid)-4]},
+		{"blob too long", []byte{'/', 'b', 0, 0, ',', 'b', 0, 0, 0, 0, 0, 8, 1, 2, 3, 4}},
+		{"bundle element too long", append([]byte("#bundle\x00"), 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if messages, err := Parse(tt.packet); err == nil {
+				t.Fatalf("parsed % X as %v, want an error", tt.packet, messages)
+			}
+		})
+	}
+}
+
+func TestParseWithoutTypeTags(t *testing.T) {
+	got, err := Parse([]byte{'/', 'c', 'l', 'r', 0, 0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Address != "/clr" || len(got[0].Args) != 0 {
+		t.Fatalf("got %v, want /clr without arguments", got)
+	}
+}
@@ -0,0 +1,299 @@
+// Package osc exposes a Launchpad over Open Sound Control.
+//
+// The server listens for OSC messages on a UDP address and drives the LEDs,
+// and forwards button presses as OSC messages to any number of targets, so
+// the Launchpad can be used from Max, Pure Data, TouchDesigner and similar:
+//
+//	srv := osc.NewServer(lp)
+//	srv.AddTarget("127.0.0.1:9001")
+//	err := srv.Listen("127.0.0.1:9000")
+//	defer srv.Close()
+//
+// Incoming messages:
+//
+//	/led x y red green [flash]  set one LED (x=8 for scene buttons, y=-1 for top buttons)
+//	/frame <blob>               set all 80 LEDs from velocity bytes in frame order
+//	/clear                      turn all LEDs off
+//
+// Outgoing messages:
+//
+//	/press x y 1|0              a button was pressed (1) or released (0)
+//
+// The codec (Message, Parse) is self-contained and also works for bundles.
+package osc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// maxPacketSize is the largest UDP datagram the server reads
+const maxPacketSize = 65507
+
+// Device is the part of the Launchpad API the server uses
+// *launchpad.Launchpad implements it; a fake can be used to test without hardware
+type Device interface {
+	SetButtonLEDState(btn launchpad.Button, state launchpad.LEDState) error
+	SetFrame(frame *launchpad.Frame) error
+	Clear() error
+	OnButton(handler launchpad.ButtonHandler)
+}
+
+// Server bridges OSC over UDP to a Launchpad
+type Server struct {
+	dev Device
+
+	mu       sync.Mutex
+	conn     *net.UDPConn
+	targets  []*net.UDPAddr
+	onError  func(error)
+	attached bool
+	done     chan struct{}
+}
+
+// NewServer creates a server for a device
+func NewServer(dev Device) *Server {
+	return &Server{dev: dev}
+}
+
+// OnError sets a callback for errors raised while handling incoming packets
+// Without a callback such errors are dropped
+func (s *Server) OnError(fn func(error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onError = fn
+}
+
+// AddTarget adds a UDP address that receives button messages
+func (s *Server) AddTarget(addr string) error {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return fmt.Errorf("invalid OSC target %q: %w", addr, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.targets = append(s.targets, udpAddr)
+	return nil
+}
+
+// Listen starts serving on a UDP address such as "127.0.0.1:9000"
+// Use port 0 to pick a free port and read it back with Addr
+func (s *Server) Listen(addr string) error {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return fmt.Errorf("invalid OSC address %q: %w", addr, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		return errors.New("OSC server already listening")
+	}
+
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.conn = conn
+	s.done = make(chan struct{})
+
+	// Handlers cannot be removed, so register only once and check the
+	// connection on every event
+	if !s.attached {
+		s.dev.OnButton(s.handleButton)
+		s.attached = true
+	}
+
+	go s.serve(conn, s.done)
+	return nil
+}
+
+// Addr returns the address the server is listening on, or nil
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.LocalAddr()
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	s.mu.Lock()
+	conn, done := s.conn, s.done
+	s.conn = nil
+	s.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	err := conn.Close()
+	<-done
+	return err
+}
+
+// Handle applies a single OSC message to the device
+func (s *Server) Handle(msg Message) error {
+	switch msg.Address {
+	case "/led":
+		return s.handleLED(msg)
+	case "/frame":
+		return s.handleFrame(msg)
+	case "/clear":
+		return s.dev.Clear()
+	default:
+		return fmt.Errorf("unknown OSC address: %s", msg.Address)
+	}
+}
+
+// Send sends a message to every target
+func (s *Server) Send(msg Message) error {
+	packet, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return errors.New("OSC server not listening")
+	}
+
+	for _, target := range s.targets {
+		if _, err := s.conn.WriteToUDP(packet, target); err != nil {
+			return fmt.Errorf("failed to send to %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// serve reads packets until the connection is closed
+func (s *Server) serve(conn *net.UDPConn, done chan struct{}) {
+	defer close(done)
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.reportError(err)
+			continue
+		}
+
+		messages, err := Parse(buf[:n])
+		if err != nil {
+			s.reportError(err)
+			continue
+		}
+		for _, msg := range messages {
+			if err := s.Handle(msg); err != nil {
+				s.reportError(err)
+			}
+		}
+	}
+}
+
+// handleButton forwards a button event to the targets
+func (s *Server) handleButton(event launchpad.ButtonEvent) {
+	s.mu.Lock()
+	listening := s.conn != nil
+	s.mu.Unlock()
+	if !listening {
+		return
+	}
+
+	pressed := int32(0)
+	if event.Pressed {
+		pressed = 1
+	}
+	msg := NewMessage("/press", int32(event.Button.X), int32(event.Button.Y), pressed)
+	if err := s.Send(msg); err != nil {
+		s.reportError(err)
+	}
+}
+
+// handleLED applies "/led x y red green [flash]"
+func (s *Server) handleLED(msg Message) error {
+	if len(msg.Args) < 4 {
+		return fmt.Errorf("/led: expected x y red green, got %d arguments", len(msg.Args))
+	}
+
+	var v [5]int
+	for i := range msg.Args {
+		if i >= len(v) {
+			break
+		}
+		n, err := msg.Int(i)
+		if err != nil {
+			return err
+		}
+		v[i] = n
+	}
+
+	btn := launchpad.ButtonAt(v[0], v[1])
+	if !btn.Valid() {
+		return fmt.Errorf("invalid button position: (%d, %d)", v[0], v[1])
+	}
+
+	state := launchpad.LEDState{
+		Red:   launchpad.Brightness(v[2]),
+		Green: launchpad.Brightness(v[3]),
+		Flash: v[4] != 0,
+	}
+	if !state.Red.Valid() || !state.Green.Valid() {
+		return fmt.Errorf("/led: brightness out of range: red=%d green=%d", v[2], v[3])
+	}
+	return s.dev.SetButtonLEDState(btn, state)
+}
+
+// handleFrame applies "/frame <blob>", also accepting 80 integer arguments
+func (s *Server) handleFrame(msg Message) error {
+	var data []byte
+	if len(msg.Args) == 1 {
+		blob, ok := msg.Args[0].([]byte)
+		if !ok {
+			return fmt.Errorf("/frame: expected a blob, got %T", msg.Args[0])
+		}
+		data = blob
+	} else {
+		for i := range msg.Args {
+			n, err := msg.Int(i)
+			if err != nil {
+				return err
+			}
+			data = append(data, byte(n))
+		}
+	}
+
+	if len(data) != launchpad.FrameSize {
+		return fmt.Errorf("/frame: expected %d values, got %d", launchpad.FrameSize, len(data))
+	}
+
+	var frame launchpad.Frame
+	for i, velocity := range data {
+		frame[i] = launchpad.LEDStateFromVelocity(velocity)
+	}
+	return s.dev.SetFrame(&frame)
+}
+
+// reportError passes an error to the error callback, if any
+func (s *Server) reportError(err error) {
+	s.mu.Lock()
+	fn := s.onError
+	s.mu.Unlock()
+
+	if fn != nil {
+		fn(err)
+	}
+}
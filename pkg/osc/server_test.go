@@ -0,0 +1,220 @@
+package osc
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// fakeDevice records LED updates and lets tests press buttons
+type fakeDevice struct {
+	mu       sync.Mutex
+	frame    launchpad.Frame
+	updates  chan struct{}
+	handlers []launchpad.ButtonHandler
+}
+
+func newFakeDevice() *fakeDevice {
+	return &fakeDevice{updates: make(chan struct{}, 16)}
+}
+
+func (d *fakeDevice) SetButtonLEDState(btn launchpad.Button, state launchpad.LEDState) error {
+	d.mu.Lock()
+	d.frame.Set(btn, state)
+	d.mu.Unlock()
+	d.updates <- struct{}{}
+	return nil
+}
+
+func (d *fakeDevice) SetFrame(frame *launchpad.Frame) error {
+	d.mu.Lock()
+	d.frame = *frame
+	d.mu.Unlock()
+	d.updates <- struct{}{}
+	return nil
+}
+
+func (d *fakeDevice) Clear() error {
+	return d.SetFrame(&launchpad.Frame{})
+}
+
+func (d *fakeDevice) OnButton(handler launchpad.ButtonHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers = append(d.handlers, handler)
+}
+
+// press delivers a button event as the device would
+func (d *fakeDevice) press(btn launchpad.Button, pressed bool) {
+	d.mu.Lock()
+	handlers := append([]launchpad.ButtonHandler(nil), d.handlers...)
+	d.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(launchpad.ButtonEvent{Button: btn, Pressed: pressed})
+	}
+}
+
+// get returns the LED state of a button
+func (d *fakeDevice) get(btn launchpad.Button) launchpad.LEDState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.frame.Get(btn)
+}
+
+// wait waits for the device to receive an update
+func (d *fakeDevice) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-d.updates:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an LED update")
+	}
+}
+
+// loopback starts a server on 127.0.0.1 and a client socket it sends presses to
+func loopback(t *testing.T) (*fakeDevice, *Server, *net.UDPConn) {
+	t.Helper()
+
+	dev := newFakeDevice()
+	srv := NewServer(dev)
+	srv.OnError(func(err error) { t.Errorf("server error: %v", err) })
+
+	// Port 0 picks free ports for both ends
+	if err := srv.Listen("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	client, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	if err := srv.AddTarget(client.LocalAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	return dev, srv, client
+}
+
+// send sends a message from the client to the server
+func send(t *testing.T, client *net.UDPConn, srv *Server, msg Message) {
+	t.Helper()
+
+	packet, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.WriteToUDP(packet, srv.Addr().(*net.UDPAddr)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerLED(t *testing.T) {
+	dev, srv, client := loopback(t)
+
+	tests := []struct {
+		msg  Message
+		btn  launchpad.Button
+		want launchpad.LEDState
+	}{
+		{NewMessage("/led", int32(3), int32(4), int32(3), int32(0)), launchpad.NewGridButton(3, 4), launchpad.LEDState{Red: launchpad.BrightnessFull}},
+		{NewMessage("/led", int32(8), int32(2), int32(0), int32(2)), launchpad.NewSceneButton(2), launchpad.LEDState{Green: launchpad.BrightnessMedium}},
+		{NewMessage("/led", int32(5), int32(-1), int32(1), int32(1), int32(1)), launchpad.NewTopButton(5), launchpad.LEDState{Red: launchpad.BrightnessLow, Green: launchpad.BrightnessLow, Flash: true}},
+		{NewMessage("/led", float32(0), float32(0), float32(3), float32(3)), launchpad.NewGridButton(0, 0), launchpad.LEDState{Red: launchpad.BrightnessFull, Green: launchpad.BrightnessFull}},
+	}
+
+	for _, tt := range tests {
+		send(t, client, srv, tt.msg)
+		dev.wait(t)
+		if got := dev.get(tt.btn); got != tt.want {
+			t.Errorf("%v: %v is %v, want %v", tt.msg, tt.btn, got, tt.want)
+		}
+	}
+}
+
+func TestServerFrameAndClear(t *testing.T) {
+	dev, srv, client := loopback(t)
+
+	var want launchpad.Frame
+	blob := make([]byte, launchpad.FrameSize)
+	for i := range blob {
+		want[i] = launchpad.LEDState{Red: launchpad.Brightness(i % 4)}
+		blob[i] = want[i].Velocity()
+	}
+
+	send(t, client, srv, NewMessage("/frame", blob))
+	dev.wait(t)
+	for i := range want {
+		if got := dev.get(launchpad.FrameButton(i)); got.Red != want[i].Red || got.Green != 0 {
+			t.Fatalf("LED %d is %v, want %v", i, got, want[i])
+		}
+	}
+
+	send(t, client, srv, NewMessage("/clear"))
+	dev.wait(t)
+	if got := dev.get(launchpad.FrameButton(1)); got != (launchpad.LEDState{}) {
+		t.Fatalf("LED 1 is still %v after /clear", got)
+	}
+}
+
+func TestServerPress(t *testing.T) {
+	dev, _, client := loopback(t)
+
+	tests := []struct {
+		btn     launchpad.Button
+		pressed bool
+		want    string
+	}{
+		{launchpad.NewSceneButton(2), true, "/press 8 2 1"},
+		{launchpad.NewGridButton(0, 7), false, "/press 0 7 0"},
+		{launchpad.NewTopButton(3), true, "/press 3 -1 1"},
+	}
+
+	buf := make([]byte, 1024)
+	for _, tt := range tests {
+		dev.press(tt.btn, tt.pressed)
+
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := client.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatalf("no /press message received: %v", err)
+		}
+		messages, err := Parse(buf[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 1 || messages[0].String() != tt.want {
+			t.Fatalf("got %v, want %s", messages, tt.want)
+		}
+	}
+}
+
+func TestServerHandleErrors(t *testing.T) {
+	srv := NewServer(newFakeDevice())
+
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"unknown address", NewMessage("/nope")},
+		{"too few arguments", NewMessage("/led", int32(0), int32(0), int32(3))},
+		{"invalid button", NewMessage("/led", int32(9), int32(0), int32(3), int32(0))},
+		{"brightness out of range", NewMessage("/led", int32(0), int32(0), int32(4), int32(0))},
+		{"not a number", NewMessage("/led", "a", int32(0), int32(3), int32(0))},
+		{"short frame", NewMessage("/frame", make([]byte, 79))},
+		{"frame not a blob", NewMessage("/frame", "x")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := srv.Handle(tt.msg); err == nil {
+				t.Fatalf("%v accepted, want an error", tt.msg)
+			}
+		})
+	}
+}
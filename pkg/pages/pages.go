@@ -0,0 +1,326 @@
+// Package pages provides virtual screens for the Launchpad.
+//
+// Each Page owns a full 80-LED frame and its own button handlers. Only the active
+// page is shown on the device and receives button events, but every page keeps
+// accepting LED updates, so switching back shows its current state.
+// Top row or scene buttons can be bound to switch pages:
+//
+//	mgr := pages.NewManager(lp)
+//	steps := mgr.NewPage("steps")
+//	mixer := mgr.NewPage("mixer")
+//	mgr.BindTopRow() // Top[0] shows "steps", Top[1] shows "mixer"
+//
+//	steps.OnButton(func(event launchpad.ButtonEvent) { ... })
+//	mixer.SetLED(0, 7, launchpad.ColorGreen, launchpad.BrightnessFull)
+//
+// A button release goes to the page that received the press, even if another
+// page was switched to in between, so no page is left with a held button.
+package pages
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Page is a virtual screen with its own frame and button handlers
+type Page struct {
+	name     string
+	manager  *Manager
+	frame    launchpad.Frame
+	handlers []launchpad.ButtonHandler
+}
+
+// Name returns the page name
+func (p *Page) Name() string {
+	return p.name
+}
+
+// Set sets the LED state of a button on the page
+// The device is only updated if the page is active
+func (p *Page) Set(btn launchpad.Button, state launchpad.LEDState) error {
+	if !btn.Valid() {
+		return &launchpad.InvalidButtonError{Button: btn}
+	}
+
+	m := p.manager
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p.frame.Set(btn, state)
+	if m.active != p {
+		return nil
+	}
+	return m.drawLocked()
+}
+
+// SetLED sets a grid LED on the page from a color and brightness
+func (p *Page) SetLED(x, y int, color launchpad.Color, brightness launchpad.Brightness) error {
+	return p.Set(launchpad.NewGridButton(x, y), launchpad.NewLEDState(color, brightness))
+}
+
+// SetFrame replaces the whole page frame
+func (p *Page) SetFrame(frame *launchpad.Frame) error {
+	m := p.manager
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p.frame = *frame
+	if m.active != p {
+		return nil
+	}
+	return m.drawLocked()
+}
+
+// Frame returns a copy of the page frame
+func (p *Page) Frame() launchpad.Frame {
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+	return p.frame
+}
+
+// OnButton registers a handler for button events while the page is active,
+// including releases of buttons pressed while it was active
+func (p *Page) OnButton(handler launchpad.ButtonHandler) {
+	p.manager.mu.Lock()
+	defer p.manager.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+// Manager switches between pages on a Launchpad
+type Manager struct {
+	mu       sync.Mutex
+	pages    []*Page
+	active   *Page
+	bindings map[launchpad.Button]*Page
+	held     map[launchpad.Button]*Page // Page that received each held button
+	screen   *launchpad.FrameWriter     // Draws the active page on the device
+	hidden   bool                       // Nothing is drawn until Redraw
+	onError  func(error)
+
+	// Indicator LEDs drawn on bound switch buttons
+	activeIndicator   launchpad.LEDState
+	inactiveIndicator launchpad.LEDState
+}
+
+// NewManager creates a page manager and registers it for the Launchpad's button events
+func NewManager(lp *launchpad.Launchpad) *Manager {
+	m := newManager(lp)
+	lp.OnButton(m.Handle)
+	return m
+}
+
+// NewDetachedManager creates a page manager that neither registers for button
+// events nor draws until Redraw is called
+// Pass it events with Handle, e.g. to prepare pages before showing them or to
+// replace one set of pages with another
+func NewDetachedManager(lp *launchpad.Launchpad) *Manager {
+	m := newManager(lp)
+	m.hidden = true
+	return m
+}
+
+// newManager creates a page manager with the default indicators
+func newManager(lp *launchpad.Launchpad) *Manager {
+	return &Manager{
+		screen:            launchpad.NewFrameWriter(lp),
+		bindings:          make(map[launchpad.Button]*Page),
+		held:              make(map[launchpad.Button]*Page),
+		activeIndicator:   launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull),
+		inactiveIndicator: launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessLow),
+	}
+}
+
+// OnError sets a callback for errors raised while switching pages from a
+// bound button; without a callback such errors are dropped
+// The callback runs on the MIDI input goroutine with the manager locked, so it
+// must not call Manager or Page methods
+func (m *Manager) OnError(fn func(error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onError = fn
+}
+
+// NewPage creates a page; the first page created becomes active
+func (m *Manager) NewPage(name string) *Page {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	page := &Page{name: name, manager: m}
+	m.pages = append(m.pages, page)
+	if m.active == nil {
+		m.active = page
+	}
+	return page
+}
+
+// Pages returns all pages in creation order
+func (m *Manager) Pages() []*Page {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pages := make([]*Page, len(m.pages))
+	copy(pages, m.pages)
+	return pages
+}
+
+// Active returns the active page
+func (m *Manager) Active() *Page {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+// Bind makes a button switch to a page when pressed
+// Bound buttons show the page indicator instead of the page's own LED state
+func (m *Manager) Bind(btn launchpad.Button, page *Page) error {
+	if !btn.Valid() {
+		return &launchpad.InvalidButtonError{Button: btn}
+	}
+	if page == nil {
+		return fmt.Errorf("nil page")
+	}
+	if page.manager != m {
+		return fmt.Errorf("page %q belongs to another manager", page.name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bindings[btn] = page
+	return nil
+}
+
+// BindTopRow binds the top row buttons to the first eight pages in order
+func (m *Manager) BindTopRow() error {
+	for i, page := range m.Pages() {
+		if i >= launchpad.TopButtons {
+			break
+		}
+		err := m.Bind(launchpad.NewTopButton(i), page)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BindSceneColumn binds the scene buttons to the first eight pages in order
+func (m *Manager) BindSceneColumn() error {
+	for i, page := range m.Pages() {
+		if i >= launchpad.SceneButtons {
+			break
+		}
+		err := m.Bind(launchpad.NewSceneButton(i), page)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetIndicator sets the LED states of bound buttons for the active and inactive pages
+func (m *Manager) SetIndicator(active, inactive launchpad.LEDState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.activeIndicator = active
+	m.inactiveIndicator = inactive
+	return m.drawLocked()
+}
+
+// Switch makes a page active and redraws the device
+func (m *Manager) Switch(page *Page) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if page == nil {
+		return fmt.Errorf("nil page")
+	}
+	if page.manager != m {
+		return fmt.Errorf("page %q belongs to another manager", page.name)
+	}
+
+	m.active = page
+	return m.drawLocked()
+}
+
+// Redraw sends the active page to the device in full
+// It also starts drawing for a manager created with NewDetachedManager
+func (m *Manager) Redraw() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hidden = false
+	m.screen.Invalidate()
+	return m.drawLocked()
+}
+
+// Hide stops drawing on the device until Redraw is called, for example
+// before another manager takes over the device
+// Pages keep their frames and events are still handled while hidden
+func (m *Manager) Hide() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hidden = true
+}
+
+// composeLocked returns the active page frame with indicators drawn over
+// the bound buttons (caller must hold the lock)
+func (m *Manager) composeLocked() launchpad.Frame {
+	var frame launchpad.Frame
+	if m.active != nil {
+		frame = m.active.frame
+	}
+	for btn, page := range m.bindings {
+		if page == m.active {
+			frame.Set(btn, m.activeIndicator)
+		} else {
+			frame.Set(btn, m.inactiveIndicator)
+		}
+	}
+	return frame
+}
+
+// drawLocked brings the device up to date with the active page,
+// sending only changes or a rapid update, whichever is faster (caller must hold the lock)
+func (m *Manager) drawLocked() error {
+	if m.hidden {
+		return nil
+	}
+	frame := m.composeLocked()
+	return m.screen.Draw(&frame)
+}
+
+// Handle switches pages on bound buttons and forwards other events to the active page
+// NewManager registers it with the Launchpad
+func (m *Manager) Handle(event launchpad.ButtonEvent) {
+	m.mu.Lock()
+	target, bound := m.bindings[event.Button]
+	if bound {
+		if event.Pressed && target != m.active {
+			m.active = target
+			if err := m.drawLocked(); err != nil && m.onError != nil {
+				m.onError(fmt.Errorf("failed to switch to page %q: %w", target.name, err))
+			}
+		}
+		m.mu.Unlock()
+		return
+	}
+
+	target = m.active
+	if event.Pressed {
+		m.held[event.Button] = target
+	} else if page, ok := m.held[event.Button]; ok {
+		target = page
+		delete(m.held, event.Button)
+	}
+
+	var handlers []launchpad.ButtonHandler
+	if target != nil {
+		handlers = make([]launchpad.ButtonHandler, len(target.handlers))
+		copy(handlers, target.handlers)
+	}
+	m.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
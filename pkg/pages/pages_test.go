@@ -0,0 +1,23 @@
+package pages
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+func TestInvalidButton(t *testing.T) {
+	m := NewDetachedManager(launchpad.New())
+	page := m.NewPage("main")
+	btn := launchpad.NewGridButton(8, 0)
+
+	var badButton *launchpad.InvalidButtonError
+	if err := page.Set(btn, launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessFull)); !errors.As(err, &badButton) || badButton.Button != btn {
+		t.Fatalf("Set returned %v, want an InvalidButtonError for %v", err, btn)
+	}
+	badButton = nil
+	if err := m.Bind(btn, page); !errors.As(err, &badButton) || badButton.Button != btn {
+		t.Fatalf("Bind returned %v, want an InvalidButtonError for %v", err, btn)
+	}
+}
@@ -0,0 +1,80 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Event is a recorded button event
+type Event struct {
+	Time  time.Duration // Time since the recording started
+	Event launchpad.ButtonEvent
+}
+
+// eventJSON is the JSON line format of an Event
+// Coordinates follow Button.X and Button.Y (x=8 for scene buttons, y=-1 for top buttons)
+type eventJSON struct {
+	Micros  int64 `json:"t_us"`
+	X       int   `json:"x"`
+	Y       int   `json:"y"`
+	Pressed bool  `json:"pressed"`
+}
+
+// MarshalJSON encodes an event as {"t_us":1500000,"x":3,"y":4,"pressed":true}
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(eventJSON{
+		Micros:  e.Time.Microseconds(),
+		X:       e.Event.Button.X,
+		Y:       e.Event.Button.Y,
+		Pressed: e.Event.Pressed,
+	})
+}
+
+// UnmarshalJSON decodes an event
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var v eventJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	btn := launchpad.ButtonAt(v.X, v.Y)
+	if !btn.Valid() {
+		return fmt.Errorf("invalid button position: (%d, %d)", v.X, v.Y)
+	}
+
+	e.Time = time.Duration(v.Micros) * time.Microsecond
+	e.Event = launchpad.ButtonEvent{Button: btn, Pressed: v.Pressed}
+	return nil
+}
+
+// Read reads a recording written by a Recorder, one JSON event per line
+// Blank lines are skipped; events must be in time order
+func Read(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+
+		var e Event
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(events) > 0 && e.Time < events[len(events)-1].Time {
+			return nil, fmt.Errorf("line %d: event out of order", line)
+		}
+		events = append(events, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
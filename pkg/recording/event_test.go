@@ -0,0 +1,75 @@
+package recording
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+func TestReadRoundTrip(t *testing.T) {
+	events := []Event{
+		{0, launchpad.ButtonEvent{Button: launchpad.NewGridButton(3, 4), Pressed: true}},
+		{1500 * time.Millisecond, launchpad.ButtonEvent{Button: launchpad.NewGridButton(3, 4)}},
+		{1500 * time.Millisecond, launchpad.ButtonEvent{Button: launchpad.NewSceneButton(7), Pressed: true}},
+		{2 * time.Second, launchpad.ButtonEvent{Button: launchpad.NewTopButton(0), Pressed: true}},
+	}
+
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := e.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(data)
+		buf.WriteString("\n\n")
+	}
+
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Fatalf("Read = %v, want %v", got, events)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			"out of order",
+			`{"t_us":2000,"x":0,"y":0,"pressed":true}
+{"t_us":1000,"x":0,"y":0,"pressed":false}`,
+			"line 2: event out of order",
+		},
+		{
+			"out of order after blank line",
+			`{"t_us":0,"x":0,"y":0,"pressed":true}
+
+{"t_us":5,"x":8,"y":1,"pressed":true}
+{"t_us":4,"x":8,"y":1,"pressed":false}`,
+			"line 4: event out of order",
+		},
+		{"invalid button", `{"t_us":0,"x":8,"y":-1,"pressed":true}`, "line 1: invalid button position: (8, -1)"},
+		{"not JSON", `t_us=0`, "line 1:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Read(strings.NewReader(tt.data))
+			if err == nil {
+				t.Fatalf("read %v, want an error", events)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
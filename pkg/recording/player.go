@@ -0,0 +1,56 @@
+package recording
+
+import (
+	"time"
+
+	"github.com/inegm/golp/internal/playback"
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// ErrStopped is returned by Play when playback was stopped
+var ErrStopped = playback.ErrStopped
+
+// Player plays back recorded events
+type Player struct {
+	ctl    playback.Control
+	events []Event
+}
+
+// NewPlayer creates a player for a recording at the original speed
+func NewPlayer(events []Event) *Player {
+	return &Player{events: events}
+}
+
+// SetSpeed scales playback speed: 2 plays twice as fast, 0.5 at half speed
+// Values of 0 or less are ignored
+func (p *Player) SetSpeed(speed float64) {
+	p.ctl.SetSpeed(speed)
+}
+
+// Play delivers every event to target at its recorded time and blocks until
+// the recording ends or Stop is called
+// Use lp.InjectButtonEvent as the target to trigger the same handlers as live
+// input, or a function that presses buttons on a simulated device
+func (p *Player) Play(target func(launchpad.ButtonEvent)) error {
+	times := make([]time.Duration, len(p.events))
+	for i, e := range p.events {
+		times[i] = e.Time
+	}
+	return p.ctl.Play(times, func(i int) error {
+		target(p.events[i].Event)
+		return nil
+	})
+}
+
+// Stop ends playback early
+func (p *Player) Stop() {
+	p.ctl.Stop()
+}
+
+// Duration returns the length of the recording at the current speed
+func (p *Player) Duration() time.Duration {
+	if len(p.events) == 0 {
+		return 0
+	}
+	return p.ctl.Scale(p.events[len(p.events)-1].Time)
+}
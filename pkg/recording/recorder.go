@@ -0,0 +1,95 @@
+// Package recording captures button input sessions and plays them back.
+//
+// A Recorder writes every ButtonEvent with its time since the recording
+// started as a JSON line, and a Player feeds a recording back at the original
+// speed or scaled, through the same handlers as live input:
+//
+//	f, _ := os.Create("session.jsonl")
+//	rec := recording.NewRecorder(f)
+//	rec.Attach(lp)
+//	...
+//	rec.Stop()
+//
+//	f.Seek(0, io.SeekStart)
+//	events, err := recording.Read(f)
+//	player := recording.NewPlayer(events)
+//	player.SetSpeed(2) // Twice as fast
+//	err = player.Play(lp.InjectButtonEvent)
+package recording
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Recorder writes button events to a stream
+type Recorder struct {
+	mu       sync.Mutex
+	enc      *json.Encoder
+	start    time.Time
+	started  bool
+	stopped  bool
+	attached bool
+	err      error
+}
+
+// NewRecorder creates a recorder writing JSON lines to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Attach records the Launchpad's button events
+// The recording clock starts now
+func (r *Recorder) Attach(lp *launchpad.Launchpad) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.startClock()
+	if !r.attached {
+		lp.OnButton(r.Handle)
+		r.attached = true
+	}
+}
+
+// Handle records a button event; it can be used directly as a button handler
+// The recording clock starts with the first event if Attach was not called
+func (r *Recorder) Handle(event launchpad.ButtonEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped || r.err != nil {
+		return
+	}
+	r.startClock()
+
+	r.err = r.enc.Encode(Event{Time: time.Since(r.start), Event: event})
+}
+
+// Stop stops recording; later events are ignored
+// It returns the first write error, if any
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopped = true
+	return r.err
+}
+
+// Err returns the first write error, if any
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// startClock starts the recording clock once (caller must hold the lock)
+func (r *Recorder) startClock() {
+	if !r.started {
+		r.start = time.Now()
+		r.started = true
+	}
+}
@@ -0,0 +1,263 @@
+package script
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	lua "github.com/yuin/gopher-lua"
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// frameType is the Lua type name of frames
+const frameType = "frame"
+
+// registerAPI installs the lp and midi modules and print (caller must hold the lock)
+//
+//	lp.set(x, y, red, green [, flash])       set an LED from brightness levels 0-3
+//	lp.set_led(x, y, color, brightness)      set an LED from names such as "amber", "low"
+//	lp.clear()                               turn all LEDs off
+//	lp.frame()                               new empty frame
+//	lp.current()                             frame of the LEDs currently shown
+//	lp.show(frame)                           send a frame, only changed LEDs
+//	lp.on_button(fn(x, y, pressed))          handle button presses and releases
+//	lp.after(seconds, fn) -> id              call fn once
+//	lp.every(seconds, fn) -> id              call fn repeatedly
+//	lp.cancel(id)                            cancel a timer
+//	frame:set(x, y, red, green [, flash])    frame methods
+//	frame:get(x, y) -> red, green, flash
+//	frame:fill(red, green [, flash])
+//	midi.note_on(channel, note, velocity)    send to the MIDI output
+//	midi.note_off(channel, note)
+//	midi.cc(channel, controller, value)
+//	midi.program(channel, program)
+//	midi.send(byte, ...)
+func (h *Host) registerAPI(rt *runtime) {
+	L := rt.L
+
+	L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+		parts := make([]string, L.GetTop())
+		for i := range parts {
+			parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+		}
+		fmt.Fprintln(h.output, strings.Join(parts, "\t"))
+		return 0
+	}))
+
+	frames := L.NewTypeMetatable(frameType)
+	L.SetField(frames, "__index", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"set": func(L *lua.LState) int {
+			frame := checkFrame(L, 1)
+			frame.Set(checkButton(L, 2), checkState(L, 4))
+			return 0
+		},
+		"get": func(L *lua.LState) int {
+			frame := checkFrame(L, 1)
+			state := frame.Get(checkButton(L, 2))
+			L.Push(lua.LNumber(state.Red))
+			L.Push(lua.LNumber(state.Green))
+			L.Push(lua.LBool(state.Flash))
+			return 3
+		},
+		"fill": func(L *lua.LState) int {
+			frame := checkFrame(L, 1)
+			frame.Fill(checkState(L, 2))
+			return 0
+		},
+	}))
+
+	L.SetGlobal("lp", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"set": func(L *lua.LState) int {
+			check(L, h.lp.SetButtonLEDState(checkButton(L, 1), checkState(L, 3)))
+			return 0
+		},
+		"set_led": func(L *lua.LState) int {
+			color, ok := colors[strings.ToLower(L.CheckString(3))]
+			if !ok {
+				L.ArgError(3, "unknown color")
+			}
+			brightness, ok := brightnesses[strings.ToLower(L.CheckString(4))]
+			if !ok {
+				L.ArgError(4, "unknown brightness")
+			}
+			check(L, h.lp.SetButtonLEDState(checkButton(L, 1), launchpad.NewLEDState(color, brightness)))
+			return 0
+		},
+		"clear": func(L *lua.LState) int {
+			check(L, h.lp.Clear())
+			return 0
+		},
+		"frame": func(L *lua.LState) int {
+			L.Push(newFrame(L, launchpad.Frame{}))
+			return 1
+		},
+		"current": func(L *lua.LState) int {
+			L.Push(newFrame(L, h.lp.CurrentFrame()))
+			return 1
+		},
+		"show": func(L *lua.LState) int {
+			next := checkFrame(L, 1)
+			current := h.lp.CurrentFrame()
+			check(L, h.lp.UpdateFrame(&current, next))
+			return 0
+		},
+		"on_button": func(L *lua.LState) int {
+			rt.buttonHandlers = append(rt.buttonHandlers, L.CheckFunction(1))
+			return 0
+		},
+		"after": func(L *lua.LState) int {
+			L.Push(lua.LNumber(h.addTimer(L, rt, checkDuration(L, 1), L.CheckFunction(2), false)))
+			return 1
+		},
+		"every": func(L *lua.LState) int {
+			L.Push(lua.LNumber(h.addTimer(L, rt, checkDuration(L, 1), L.CheckFunction(2), true)))
+			return 1
+		},
+		"cancel": func(L *lua.LState) int {
+			id := L.CheckInt(1)
+			if t, ok := rt.timers[id]; ok {
+				t.timer.Stop()
+				delete(rt.timers, id)
+			}
+			return 0
+		},
+	}))
+
+	send := func(L *lua.LState, msg midi.Message) {
+		if h.out == nil {
+			L.RaiseError("no MIDI output")
+		}
+		check(L, h.out.Send(msg))
+	}
+	L.SetGlobal("midi", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"note_on": func(L *lua.LState) int {
+			send(L, midi.NoteOn(checkByte(L, 1, 15), checkByte(L, 2, 127), checkByte(L, 3, 127)))
+			return 0
+		},
+		"note_off": func(L *lua.LState) int {
+			send(L, midi.NoteOff(checkByte(L, 1, 15), checkByte(L, 2, 127)))
+			return 0
+		},
+		"cc": func(L *lua.LState) int {
+			send(L, midi.ControlChange(checkByte(L, 1, 15), checkByte(L, 2, 127), checkByte(L, 3, 127)))
+			return 0
+		},
+		"program": func(L *lua.LState) int {
+			send(L, midi.ProgramChange(checkByte(L, 1, 15), checkByte(L, 2, 127)))
+			return 0
+		},
+		"send": func(L *lua.LState) int {
+			msg := make([]byte, L.GetTop())
+			for i := range msg {
+				msg[i] = checkByte(L, i+1, 255)
+			}
+			if len(msg) == 0 {
+				L.RaiseError("empty MIDI message")
+			}
+			send(L, msg)
+			return 0
+		},
+	}))
+}
+
+// addTimer schedules a callback for a runtime and returns its id (caller must hold the lock)
+func (h *Host) addTimer(L *lua.LState, rt *runtime, delay time.Duration, fn *lua.LFunction, repeat bool) int {
+	if len(rt.timers) >= MaxTimers {
+		L.RaiseError("too many timers: at most %d may be pending", MaxTimers)
+	}
+	rt.nextTimer++
+	id := rt.nextTimer
+
+	t := &timer{fn: fn}
+	if repeat {
+		t.every = delay
+	}
+	t.timer = time.AfterFunc(delay, func() { h.fire(rt, id) })
+	rt.timers[id] = t
+	return id
+}
+
+// colors and brightnesses map names used by lp.set_led
+var (
+	colors = map[string]launchpad.Color{
+		"off":    launchpad.ColorOff,
+		"red":    launchpad.ColorRed,
+		"green":  launchpad.ColorGreen,
+		"amber":  launchpad.ColorAmber,
+		"yellow": launchpad.ColorYellow,
+	}
+	brightnesses = map[string]launchpad.Brightness{
+		"off":    launchpad.BrightnessOff,
+		"low":    launchpad.BrightnessLow,
+		"medium": launchpad.BrightnessMedium,
+		"full":   launchpad.BrightnessFull,
+	}
+)
+
+// check raises a Lua error if err is not nil
+func check(L *lua.LState, err error) {
+	if err != nil {
+		L.RaiseError("%v", err)
+	}
+}
+
+// checkButton reads x and y arguments starting at n
+func checkButton(L *lua.LState, n int) launchpad.Button {
+	btn := launchpad.ButtonAt(L.CheckInt(n), L.CheckInt(n+1))
+	if !btn.Valid() {
+		L.ArgError(n, fmt.Sprintf("invalid button: %v", btn))
+	}
+	return btn
+}
+
+// checkState reads red, green and optional flash arguments starting at n
+func checkState(L *lua.LState, n int) launchpad.LEDState {
+	state := launchpad.LEDState{
+		Red:   launchpad.Brightness(L.CheckInt(n)),
+		Green: launchpad.Brightness(L.CheckInt(n + 1)),
+		Flash: L.OptBool(n+2, false),
+	}
+	if !state.Red.Valid() {
+		L.ArgError(n, "brightness must be 0-3")
+	}
+	if !state.Green.Valid() {
+		L.ArgError(n+1, "brightness must be 0-3")
+	}
+	return state
+}
+
+// checkByte reads an integer argument from 0 to limit
+func checkByte(L *lua.LState, n int, limit int) uint8 {
+	v := L.CheckInt(n)
+	if v < 0 || v > limit {
+		L.ArgError(n, fmt.Sprintf("must be 0-%d", limit))
+	}
+	return uint8(v)
+}
+
+// checkDuration reads a number of seconds of at least MinInterval
+func checkDuration(L *lua.LState, n int) time.Duration {
+	d := time.Duration(float64(L.CheckNumber(n)) * float64(time.Second))
+	if d < MinInterval {
+		L.ArgError(n, fmt.Sprintf("must be at least %g", MinInterval.Seconds()))
+	}
+	return d
+}
+
+// newFrame wraps a frame in Lua userdata
+func newFrame(L *lua.LState, frame launchpad.Frame) *lua.LUserData {
+	ud := L.NewUserData()
+	ud.Value = &frame
+	L.SetMetatable(ud, L.GetTypeMetatable(frameType))
+	return ud
+}
+
+// checkFrame reads a frame argument
+func checkFrame(L *lua.LState, n int) *launchpad.Frame {
+	frame, ok := L.CheckUserData(n).Value.(*launchpad.Frame)
+	if !ok {
+		L.ArgError(n, "frame expected")
+	}
+	return frame
+}
@@ -0,0 +1,471 @@
+// Package script runs Lua scripts that define Launchpad controller behavior.
+//
+// Scripts use an embedded pure-Go Lua 5.1 interpreter with bindings for LEDs,
+// frames, button events, timers and MIDI output:
+//
+//	-- Light pressed pads, and blink the top-left pad every half second
+//	lp.on_button(function(x, y, pressed)
+//	    if pressed then lp.set_led(x, y, "green", "full") else lp.set(x, y, 0, 0) end
+//	end)
+//
+//	local on = false
+//	lp.every(0.5, function()
+//	    on = not on
+//	    lp.set(0, 0, on and 3 or 0, 0)
+//	    if on then midi.note_on(0, 36, 100) else midi.note_off(0, 36) end
+//	end)
+//
+// Coordinates follow the golp command: x is 0-7 on the grid and 8 for scene
+// buttons, y is 0-7 and -1 for top buttons.
+//
+// Scripts are sandboxed: only the base, table, string, math and coroutine
+// libraries are available, without file access, and every call into a script
+// is aborted after a timeout or once it grows the Go heap by more than
+// MaxMemory. Memory is sampled every few milliseconds, so a call can overshoot
+// the limit by what it allocates in that time, and data a script keeps between
+// calls, such as a table grown by a timer, is not limited. A single library
+// call cannot be interrupted, so calls nest at most MaxCallDepth deep, the Lua
+// stack holds at most MaxStackSize values and string.rep and table.concat
+// build at most MaxStringLength bytes; other functions, such as string.gsub
+// with a long replacement, can still build large strings in one call.
+// Timers fire at most every MinInterval and a script may have MaxTimers pending.
+//
+// Button handlers run on the host's own goroutine, so a slow script never
+// holds up the MIDI input or other button handlers; events that arrive while
+// its queue is full are dropped and reported. Errors are reported to the
+// OnError handler and never stop the host, and a script can be replaced while
+// it runs:
+//
+//	h := script.New(lp, out)
+//	h.OnError(func(err error) { log.Print(err) })
+//	if err := h.Load("controller.lua"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	stop := h.Watch("controller.lua", script.DefaultWatchInterval)
+//	defer stop()
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	goruntime "runtime"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/inegm/golp/internal/watch"
+	"github.com/inegm/golp/pkg/launchpad"
+	lua "github.com/yuin/gopher-lua"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// DefaultTimeout is the longest a script may run for a single load, event or timer
+const DefaultTimeout = time.Second
+
+// DefaultWatchInterval is a suitable interval for checking a script file for changes
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// Sandbox limits; exceeding one raises a Lua error
+const (
+	MaxCallDepth    = 200                   // Nested Lua function calls
+	MaxStackSize    = 1 << 18               // Values on the Lua stack, about 4 MB
+	MaxStringLength = 1 << 20               // Bytes in a string built by string.rep or table.concat
+	MaxMemory       = 64 << 20              // Bytes a single call may grow the Go heap by
+	MinInterval     = 10 * time.Millisecond // Shortest lp.after or lp.every delay
+	MaxTimers       = 256                   // Pending timers per script
+)
+
+// ErrMemoryLimit is reported when a call grows the heap by more than MaxMemory
+var ErrMemoryLimit = errors.New("script memory limit exceeded")
+
+// memoryCheckInterval is how often a running call's memory use is sampled
+const memoryCheckInterval = 5 * time.Millisecond
+
+// eventQueueSize is how many button events may wait for a busy script
+const eventQueueSize = 64
+
+// Host runs one script at a time on a Launchpad
+// All calls into the script are serialized, so scripts need no locking
+type Host struct {
+	mu         sync.Mutex
+	lp         *launchpad.Launchpad
+	out        drivers.Out
+	output     io.Writer
+	timeout    time.Duration
+	onError    func(error)
+	rt         *runtime // Running script, nil if none
+	registered bool     // True once the button handler has been registered
+
+	// Button events, handed from the MIDI input goroutine to the host's own
+	events  chan launchpad.ButtonEvent
+	dropped atomic.Int64  // Events dropped since the last one delivered
+	stop    chan struct{} // Stops the event goroutine, nil if not running
+}
+
+// New creates a host sending MIDI to an open output
+// The output may be nil; scripts then get an error when sending MIDI
+func New(lp *launchpad.Launchpad, out drivers.Out) *Host {
+	return &Host{
+		lp:      lp,
+		out:     out,
+		output:  os.Stderr,
+		timeout: DefaultTimeout,
+		events:  make(chan launchpad.ButtonEvent, eventQueueSize),
+	}
+}
+
+// SetOutput sets where the script's print function writes (os.Stderr by default)
+func (h *Host) SetOutput(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.output = w
+}
+
+// SetTimeout sets how long a single load, event or timer callback may run
+func (h *Host) SetTimeout(timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.timeout = timeout
+}
+
+// OnError sets a callback for errors raised by the running script and by Watch
+// Without one, errors are written to the script output
+func (h *Host) OnError(fn func(error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onError = fn
+}
+
+// Load runs a script file, replacing the running script
+func (h *Host) Load(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return h.LoadString(path, string(src))
+}
+
+// LoadString runs a script, replacing the running script
+//
+// The script's top level registers handlers and timers. If it fails, the
+// error is returned and the previous script keeps running; otherwise the
+// previous script's handlers and timers are removed.
+func (h *Host) LoadString(name, src string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.registered {
+		h.lp.OnButton(h.handleButton)
+		h.registered = true
+	}
+
+	rt := h.newRuntime(name)
+	fn, err := rt.L.Load(strings.NewReader(src), name)
+	if err == nil {
+		err = rt.call(fn, h.timeout)
+	}
+	if err != nil {
+		rt.close()
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if h.rt != nil {
+		h.rt.close()
+	}
+	h.rt = rt
+
+	if h.stop == nil {
+		// Events queued while no script ran are stale
+		for len(h.events) > 0 {
+			<-h.events
+		}
+		h.dropped.Store(0)
+		h.stop = make(chan struct{})
+		go h.dispatch(h.stop)
+	}
+	return nil
+}
+
+// Close stops the running script, removing its handlers and timers
+func (h *Host) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.rt != nil {
+		h.rt.close()
+		h.rt = nil
+	}
+	if h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+}
+
+// Watch checks a script file at a fixed interval and reloads it whenever it changes,
+// until stop is called
+// Load errors are passed to the OnError handler; the previous script keeps running
+func (h *Host) Watch(path string, interval time.Duration) (stop func()) {
+	return watch.File(path, interval, func() {
+		h.report(h.Load(path))
+	})
+}
+
+// handleButton queues a button event for the event goroutine
+// It runs on the MIDI input goroutine, so it never waits for the script
+func (h *Host) handleButton(event launchpad.ButtonEvent) {
+	select {
+	case h.events <- event:
+	default:
+		h.dropped.Add(1)
+	}
+}
+
+// dispatch delivers queued button events to the running script until stop is closed
+func (h *Host) dispatch(stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-h.events:
+			if n := h.dropped.Swap(0); n > 0 {
+				h.report(fmt.Errorf("script too slow: dropped %d button events", n))
+			}
+			h.callButtonHandlers(event)
+		}
+	}
+}
+
+// callButtonHandlers calls the running script's button handlers
+func (h *Host) callButtonHandlers(event launchpad.ButtonEvent) {
+	h.mu.Lock()
+	rt := h.rt
+	var err error
+	if rt != nil {
+		// Button coordinates already follow the script convention
+		x, y := lua.LNumber(event.Button.X), lua.LNumber(event.Button.Y)
+		for _, fn := range rt.buttonHandlers {
+			if err = rt.call(fn, h.timeout, x, y, lua.LBool(event.Pressed)); err != nil {
+				err = fmt.Errorf("%s: button handler: %w", rt.name, err)
+				break
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	h.report(err)
+}
+
+// fire runs a timer callback if its script is still running
+func (h *Host) fire(rt *runtime, id int) {
+	h.mu.Lock()
+	t, ok := rt.timers[id]
+	if h.rt != rt || !ok {
+		h.mu.Unlock()
+		return
+	}
+
+	if t.every > 0 {
+		t.timer.Reset(t.every)
+	} else {
+		delete(rt.timers, id)
+	}
+	err := rt.call(t.fn, h.timeout)
+	if err != nil {
+		err = fmt.Errorf("%s: timer: %w", rt.name, err)
+	}
+	h.mu.Unlock()
+
+	h.report(err)
+}
+
+// report passes an error to the OnError handler, or writes it to the script output
+func (h *Host) report(err error) {
+	if err == nil {
+		return
+	}
+
+	h.mu.Lock()
+	fn := h.onError
+	w := h.output
+	h.mu.Unlock()
+
+	if fn != nil {
+		fn(err)
+	} else {
+		fmt.Fprintln(w, err)
+	}
+}
+
+// runtime is a loaded script with its own Lua state
+type runtime struct {
+	name           string
+	L              *lua.LState
+	buttonHandlers []*lua.LFunction
+	timers         map[int]*timer
+	nextTimer      int
+}
+
+// timer is a pending after or every callback
+type timer struct {
+	fn    *lua.LFunction
+	timer *time.Timer
+	every time.Duration // Repeat interval, 0 for one-shot timers
+}
+
+// newRuntime creates a sandboxed Lua state with the golp bindings (caller must hold the lock)
+func (h *Host) newRuntime(name string) *runtime {
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   MaxCallDepth,
+		RegistryMaxSize: MaxStackSize,
+	})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+		{lua.CoroutineLibName, lua.OpenCoroutine},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	// Remove base functions that reach the file system
+	for _, unsafe := range []string{"dofile", "loadfile"} {
+		L.SetGlobal(unsafe, lua.LNil)
+	}
+
+	// Strings share the string table as their methods, so this also covers s:rep(n)
+	L.SetField(L.GetGlobal(lua.StringLibName), "rep", L.NewFunction(strRep))
+	tables := L.GetGlobal(lua.TabLibName)
+	concat := L.GetField(tables, "concat").(*lua.LFunction).GFunction
+	L.SetField(tables, "concat", L.NewFunction(limitConcat(concat)))
+
+	rt := &runtime{
+		name:   name,
+		L:      L,
+		timers: make(map[int]*timer),
+	}
+	h.registerAPI(rt)
+	return rt
+}
+
+// strRep is string.rep with the result limited to MaxStringLength bytes
+func strRep(L *lua.LState) int {
+	str := L.CheckString(1)
+	n := L.CheckInt(2)
+	if n < 0 {
+		n = 0
+	}
+	if len(str) > 0 && n > MaxStringLength/len(str) {
+		L.RaiseError("string.rep: result longer than %d bytes", MaxStringLength)
+		return 0
+	}
+	L.Push(lua.LString(strings.Repeat(str, n)))
+	return 1
+}
+
+// limitConcat wraps table.concat so the result is at most MaxStringLength bytes
+func limitConcat(concat lua.LGFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		tbl := L.CheckTable(1)
+		sep := L.OptString(2, "")
+		first := max(L.OptInt(3, 1), 1)
+		last := min(L.OptInt(4, tbl.Len()), tbl.Len())
+
+		size := 0
+		for i := first; i <= last; i++ {
+			size += len(lua.LVAsString(tbl.RawGetInt(i)))
+			if i < last {
+				size += len(sep)
+			}
+			if size > MaxStringLength {
+				L.RaiseError("table.concat: result longer than %d bytes", MaxStringLength)
+				return 0
+			}
+		}
+		return concat(L)
+	}
+}
+
+// call runs a Lua function, aborting it after the timeout or once it uses
+// more than MaxMemory
+func (rt *runtime) call(fn *lua.LFunction, timeout time.Duration, args ...lua.LValue) error {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	defer cancelTimeout()
+
+	stop := watchMemory(MaxMemory, func() { cancel(ErrMemoryLimit) })
+	defer stop()
+
+	rt.L.SetContext(ctx)
+	defer rt.L.RemoveContext()
+
+	err := rt.L.CallByParam(lua.P{Fn: fn, Protect: true}, args...)
+	if err != nil && errors.Is(context.Cause(ctx), ErrMemoryLimit) {
+		return fmt.Errorf("%w (%d MB)", ErrMemoryLimit, MaxMemory>>20)
+	}
+	return err
+}
+
+// watchMemory calls exceeded once the live Go heap has grown by more than
+// limit bytes, until stop is called
+// The heap is shared with the rest of the program, so garbage is collected
+// before giving up to make sure the growth is live data
+func watchMemory(limit uint64, exceeded func()) (stop func()) {
+	done := make(chan struct{})
+	base := readMetric("/gc/heap/live:bytes")
+
+	go func() {
+		ticker := time.NewTicker(memoryCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if readMetric(heapObjects) < base+limit {
+				continue
+			}
+			goruntime.GC()
+			if readMetric(heapObjects) >= base+limit {
+				exceeded()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// heapObjects is the metric of heap objects, including garbage not yet swept
+const heapObjects = "/memory/classes/heap/objects:bytes"
+
+// readMetric reads a runtime metric in bytes
+func readMetric(name string) uint64 {
+	sample := []metrics.Sample{{Name: name}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
+
+// close stops the runtime's timers and frees its Lua state
+func (rt *runtime) close() {
+	for id, t := range rt.timers {
+		t.timer.Stop()
+		delete(rt.timers, id)
+	}
+	rt.buttonHandlers = nil
+	rt.L.Close()
+}
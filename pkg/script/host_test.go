@@ -0,0 +1,163 @@
+package script
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"github.com/inegm/golp/pkg/simulator"
+)
+
+// newHost opens a Launchpad on a simulator and returns a host driving it
+func newHost(t *testing.T) (*Host, *launchpad.Launchpad, *simulator.Device) {
+	t.Helper()
+
+	sim := simulator.New()
+	lp := launchpad.New()
+	if err := lp.OpenPorts(sim.In(), sim.Out()); err != nil {
+		t.Fatal(err)
+	}
+	h := New(lp, nil)
+	h.SetOutput(io.Discard)
+	t.Cleanup(func() {
+		h.Close()
+		lp.Close()
+	})
+	return h, lp, sim
+}
+
+func TestSandboxLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"string.rep", `string.rep("x", 1e9)`, "string.rep: result longer than"},
+		{"method rep", `local s = ("x"):rep(1e9)`, "string.rep: result longer than"},
+		{"table.concat", `
+			local t = {}
+			local s = string.rep("x", 1000)
+			for i = 1, 10000 do t[i] = s end
+			table.concat(t)`, "table.concat: result longer than"},
+		{"concat doubling", `
+			local s = "x"
+			for i = 1, 40 do s = s .. s end`, ErrMemoryLimit.Error()},
+		{"table growth", `
+			local t = {}
+			for i = 1, 1e9 do t[i] = string.rep("x", 1e5) .. i end`, ErrMemoryLimit.Error()},
+		{"recursion", `local function f() return 1 + f() end f()`, "stack overflow"},
+		{"every interval", `lp.every(0.000000001, function() end)`, "must be at least"},
+		{"after interval", `lp.after(0, function() end)`, "must be at least"},
+		{"timer count", `for i = 1, 1000 do lp.after(60, function() end) end`, "too many timers"},
+		{"io", `io.open("/etc/passwd")`, "io"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _, _ := newHost(t)
+			h.SetTimeout(10 * time.Second)
+
+			err := h.LoadString(tt.name, tt.src)
+			if err == nil {
+				t.Fatalf("script ran without error, want %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+
+			// The host keeps working after the error
+			if err := h.LoadString("ok", `lp.set(0, 0, 3, 0)`); err != nil {
+				t.Fatalf("host unusable after error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryLimitError(t *testing.T) {
+	h, _, _ := newHost(t)
+	h.SetTimeout(10 * time.Second)
+	err := h.LoadString("grow", `local s = "x" for i = 1, 40 do s = s .. s end`)
+	if !errors.Is(err, ErrMemoryLimit) {
+		t.Fatalf("error = %v, want ErrMemoryLimit", err)
+	}
+}
+
+func TestSlowScriptDoesNotBlockInput(t *testing.T) {
+	h, lp, sim := newHost(t)
+
+	var mu sync.Mutex
+	var errs []error
+	h.OnError(func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+
+	// Each press keeps the script busy until the timeout
+	h.SetTimeout(200 * time.Millisecond)
+	if err := h.LoadString("slow", `lp.on_button(function() while true do end end)`); err != nil {
+		t.Fatal(err)
+	}
+
+	other := make(chan launchpad.ButtonEvent, 200)
+	lp.OnButton(func(event launchpad.ButtonEvent) { other <- event })
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		sim.Press(launchpad.NewGridButton(i%8, 0), i%2 == 0)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("presses took %v with a slow script", elapsed)
+	}
+	if len(other) != 100 {
+		t.Fatalf("other handler got %d events, want 100", len(other))
+	}
+
+	// Handler timeouts and dropped events are reported
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		var dropped, timedOut bool
+		for _, err := range errs {
+			dropped = dropped || strings.Contains(err.Error(), "dropped")
+			timedOut = timedOut || strings.Contains(err.Error(), "button handler")
+		}
+		mu.Unlock()
+		if dropped && timedOut {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("errors = %v, want a timeout and dropped events", errs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestButtonHandler(t *testing.T) {
+	h, lp, sim := newHost(t)
+	if err := h.LoadString("echo", `
+		lp.on_button(function(x, y, pressed)
+			if pressed then lp.set(x, y, 3, 0) end
+		end)`); err != nil {
+		t.Fatal(err)
+	}
+
+	btn := launchpad.NewSceneButton(2)
+	sim.Press(btn, true)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		frame := lp.CurrentFrame()
+		if frame.Get(btn) == (launchpad.LEDState{Red: launchpad.BrightnessFull}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Scene[2] is %v, want red", frame.Get(btn))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,172 @@
+package sequencer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Tempo limits accepted by Clock.SetTempo
+const (
+	MinTempo = 20.0  // Slowest tempo in beats per minute
+	MaxTempo = 300.0 // Fastest tempo in beats per minute
+)
+
+// MaxSwing is the largest swing amount accepted by Clock.SetSwing
+const MaxSwing = 0.5
+
+// DefaultStepsPerBeat gives sixteenth-note steps
+const DefaultStepsPerBeat = 4
+
+// Clock generates evenly spaced steps at a tempo, with optional swing
+//
+// Steps are scheduled against absolute times so they do not drift, and tempo or
+// swing changes take effect from the next step.
+type Clock struct {
+	mu           sync.Mutex
+	bpm          float64
+	swing        float64
+	stepsPerBeat int
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+// NewClock creates a stopped clock at the given tempo with sixteenth-note steps
+func NewClock(bpm float64) *Clock {
+	return &Clock{
+		bpm:          max(MinTempo, min(bpm, MaxTempo)),
+		stepsPerBeat: DefaultStepsPerBeat,
+	}
+}
+
+// Tempo returns the tempo in beats per minute
+func (c *Clock) Tempo() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bpm
+}
+
+// SetTempo sets the tempo in beats per minute
+func (c *Clock) SetTempo(bpm float64) error {
+	if bpm < MinTempo || bpm > MaxTempo {
+		return fmt.Errorf("invalid tempo: %v", bpm)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bpm = bpm
+	return nil
+}
+
+// Swing returns the swing amount
+func (c *Clock) Swing() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.swing
+}
+
+// SetSwing delays every odd step by a fraction of a step (0 for straight time)
+func (c *Clock) SetSwing(swing float64) error {
+	if swing < 0 || swing > MaxSwing {
+		return fmt.Errorf("invalid swing: %v", swing)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.swing = swing
+	return nil
+}
+
+// StepsPerBeat returns the number of steps per beat
+func (c *Clock) StepsPerBeat() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stepsPerBeat
+}
+
+// SetStepsPerBeat sets the number of steps per beat (4 for sixteenth notes)
+func (c *Clock) SetStepsPerBeat(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("invalid steps per beat: %d", steps)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stepsPerBeat = steps
+	return nil
+}
+
+// StepDuration returns the duration of one unswung step
+func (c *Clock) StepDuration() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stepDurationLocked()
+}
+
+// stepDurationLocked returns the step duration (caller must hold the lock)
+func (c *Clock) stepDurationLocked() time.Duration {
+	return time.Duration(float64(time.Minute) / (c.bpm * float64(c.stepsPerBeat)))
+}
+
+// Running returns whether the clock is running
+func (c *Clock) Running() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stop != nil
+}
+
+// Start runs the clock, calling onStep with a step counter starting at 0
+// onStep is called from the clock goroutine and should return quickly
+func (c *Clock) Start(onStep func(step int64)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stop != nil {
+		return fmt.Errorf("clock already running")
+	}
+
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	go c.run(onStep, c.stop, c.done)
+	return nil
+}
+
+// Stop stops the clock and waits for the current step callback to return
+func (c *Clock) Stop() {
+	c.mu.Lock()
+	stop, done := c.stop, c.done
+	c.stop, c.done = nil, nil
+	c.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// run schedules steps until stop is closed
+func (c *Clock) run(onStep func(step int64), stop, done chan struct{}) {
+	defer close(done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	grid := time.Now() // Unswung time of the current step
+	for step := int64(0); ; step++ {
+		c.mu.Lock()
+		duration := c.stepDurationLocked()
+		offset := time.Duration(0)
+		if step%2 == 1 {
+			offset = time.Duration(c.swing * float64(duration))
+		}
+		c.mu.Unlock()
+
+		timer.Reset(time.Until(grid.Add(offset)))
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+
+		onStep(step)
+		grid = grid.Add(duration)
+	}
+}
@@ -0,0 +1,351 @@
+// Package sequencer implements a step sequencer played on the Launchpad grid.
+//
+// A Sequencer holds up to eight tracks of 8 to 64 steps each, driven by a tempo
+// Clock with swing. The grid shows the selected track's pattern one step per pad
+// (left-to-right, top-to-bottom) with the playhead highlighted; pressing a pad
+// toggles its step, the scene buttons select tracks and the first top button
+// starts and stops playback. Triggered steps are reported to OnStep handlers
+// and, if a MIDI output is set, sent as notes:
+//
+//	seq := sequencer.New(120)
+//	kick, _ := sequencer.NewTrack("kick", 36, 16)
+//	seq.AddTrack(kick)
+//	seq.OnStep(func(e sequencer.StepEvent) { fmt.Println(e.Track.Name(), e.Step) })
+//	seq.Attach(lp)
+//	seq.Start()
+package sequencer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// MaxTracks is the number of tracks, one per scene button
+const MaxTracks = launchpad.SceneButtons
+
+// DefaultGate is the default note length as a fraction of a step
+const DefaultGate = 0.5
+
+// Colors used to draw the sequencer
+var (
+	colorStep         = launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull)
+	colorPlayhead     = launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessLow)
+	colorPlayheadStep = launchpad.NewLEDState(launchpad.ColorYellow, launchpad.BrightnessFull)
+	colorSelected     = launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull)
+	colorTrack        = launchpad.NewLEDState(launchpad.ColorAmber, launchpad.BrightnessLow)
+	colorPlaying      = launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull)
+	colorStopped      = launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessLow)
+)
+
+// transportButton starts and stops playback
+var transportButton = launchpad.NewTopButton(0)
+
+// StepEvent reports an active step reached by the playhead
+type StepEvent struct {
+	Track      *Track // The track containing the step
+	TrackIndex int    // Index of the track (scene button row)
+	Step       int    // Step index within the track
+	Note       uint8  // MIDI note of the track
+	Channel    uint8  // MIDI channel of the track (0-15)
+	Velocity   uint8  // MIDI velocity of the track
+}
+
+// StepHandler is a function that handles triggered steps
+type StepHandler func(StepEvent)
+
+// Sequencer plays tracks of steps against a clock
+type Sequencer struct {
+	mu       sync.Mutex
+	clock    *Clock
+	tracks   []*Track
+	selected int
+	position int64 // Global step counter, -1 before the first step
+	handlers []StepHandler
+
+	// MIDI output
+	outMu sync.Mutex
+	out   drivers.Out
+	gate  float64
+
+	// Display
+	screen *launchpad.FrameWriter // Draws on the attached Launchpad, nil until Attach
+}
+
+// New creates a sequencer with no tracks at the given tempo
+func New(bpm float64) *Sequencer {
+	return &Sequencer{
+		clock:    NewClock(bpm),
+		position: -1,
+		gate:     DefaultGate,
+	}
+}
+
+// Clock returns the sequencer's clock, for tempo and swing control
+func (s *Sequencer) Clock() *Clock {
+	return s.clock
+}
+
+// AddTrack appends a track; a sequencer holds at most MaxTracks tracks
+func (s *Sequencer) AddTrack(track *Track) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.tracks) >= MaxTracks {
+		return fmt.Errorf("too many tracks: maximum is %d", MaxTracks)
+	}
+	s.tracks = append(s.tracks, track)
+	return nil
+}
+
+// Tracks returns the tracks in scene button order
+func (s *Sequencer) Tracks() []*Track {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tracks := make([]*Track, len(s.tracks))
+	copy(tracks, s.tracks)
+	return tracks
+}
+
+// Select makes a track the one shown and edited on the grid
+func (s *Sequencer) Select(index int) error {
+	s.mu.Lock()
+	if index < 0 || index >= len(s.tracks) {
+		s.mu.Unlock()
+		return fmt.Errorf("invalid track: %d", index)
+	}
+	s.selected = index
+	s.mu.Unlock()
+
+	return s.Draw()
+}
+
+// Selected returns the index of the selected track
+func (s *Sequencer) Selected() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.selected
+}
+
+// OnStep registers a handler called for every active step the playhead reaches
+// Handlers are called from the clock goroutine, should return quickly and must not call Stop
+func (s *Sequencer) OnStep(handler StepHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+// SetMIDIOut sends a note for every triggered step to an open MIDI output (nil to disable)
+func (s *Sequencer) SetMIDIOut(out drivers.Out) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.out = out
+}
+
+// SetGate sets the note length as a fraction of a step (0 < gate <= 1)
+func (s *Sequencer) SetGate(gate float64) error {
+	if gate <= 0 || gate > 1 {
+		return fmt.Errorf("invalid gate: %v", gate)
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.gate = gate
+	return nil
+}
+
+// Start starts playback from the current position
+func (s *Sequencer) Start() error {
+	err := s.clock.Start(func(int64) { s.Advance() })
+	if err != nil {
+		return err
+	}
+	return s.Draw()
+}
+
+// Stop stops playback, keeping the current position
+func (s *Sequencer) Stop() error {
+	s.clock.Stop()
+	return s.Draw()
+}
+
+// Running returns whether the sequencer is playing
+func (s *Sequencer) Running() bool {
+	return s.clock.Running()
+}
+
+// Rewind moves the playhead back to the first step
+func (s *Sequencer) Rewind() error {
+	s.mu.Lock()
+	s.position = -1
+	s.mu.Unlock()
+	return s.Draw()
+}
+
+// Advance moves the playhead one step and triggers active steps
+// Start calls it from the sequencer's own clock; call it directly instead of
+// Start to follow an external clock, e.g. from midiclock.Clock.OnStep
+func (s *Sequencer) Advance() {
+	s.mu.Lock()
+	s.position++
+	position := s.position
+	var events []StepEvent
+	for i, track := range s.tracks {
+		step := int(position % int64(track.Length()))
+		if !track.Step(step) {
+			continue
+		}
+		note, channel, velocity := track.Note()
+		events = append(events, StepEvent{
+			Track:      track,
+			TrackIndex: i,
+			Step:       step,
+			Note:       note,
+			Channel:    channel,
+			Velocity:   velocity,
+		})
+	}
+	handlers := make([]StepHandler, len(s.handlers))
+	copy(handlers, s.handlers)
+	s.mu.Unlock()
+
+	for _, e := range events {
+		s.sendNote(e)
+		for _, handler := range handlers {
+			handler(e)
+		}
+	}
+
+	s.Draw()
+}
+
+// sendNote plays a triggered step on the MIDI output, if one is set
+func (s *Sequencer) sendNote(e StepEvent) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	if s.out == nil {
+		return
+	}
+
+	out := s.out
+	out.Send(midi.NoteOn(e.Channel, e.Note, e.Velocity))
+
+	length := time.Duration(s.gate * float64(s.clock.StepDuration()))
+	time.AfterFunc(length, func() {
+		s.outMu.Lock()
+		defer s.outMu.Unlock()
+		out.Send(midi.NoteOff(e.Channel, e.Note))
+	})
+}
+
+// Attach registers the sequencer for a Launchpad's button events and draws it
+func (s *Sequencer) Attach(lp *launchpad.Launchpad) error {
+	s.mu.Lock()
+	s.screen = launchpad.NewFrameWriter(lp)
+	s.mu.Unlock()
+
+	lp.OnButton(s.Handle)
+	return s.Draw()
+}
+
+// Handle toggles steps on pad presses, selects tracks on scene buttons and
+// starts or stops playback on the first top button
+// It can be registered directly with Launchpad.OnButton or a page's OnButton
+func (s *Sequencer) Handle(event launchpad.ButtonEvent) {
+	if !event.Pressed {
+		return
+	}
+
+	btn := event.Button
+	switch {
+	case btn == transportButton:
+		if s.Running() {
+			s.Stop()
+		} else {
+			s.Start()
+		}
+
+	case btn.IsScene:
+		s.Select(btn.Y)
+
+	case !btn.IsTop:
+		s.mu.Lock()
+		var track *Track
+		if s.selected < len(s.tracks) {
+			track = s.tracks[s.selected]
+		}
+		s.mu.Unlock()
+
+		if track == nil {
+			return
+		}
+		_, err := track.ToggleStep((btn.Y * launchpad.GridWidth) + btn.X)
+		if err == nil {
+			s.Draw()
+		}
+	}
+}
+
+// Render draws the selected track, playhead, track selector and transport into a frame
+func (s *Sequencer) Render(frame *launchpad.Frame) {
+	s.mu.Lock()
+	tracks := make([]*Track, len(s.tracks))
+	copy(tracks, s.tracks)
+	selected := s.selected
+	position := s.position
+	s.mu.Unlock()
+
+	for i := range tracks {
+		if i == selected {
+			frame.Set(launchpad.NewSceneButton(i), colorSelected)
+		} else {
+			frame.Set(launchpad.NewSceneButton(i), colorTrack)
+		}
+	}
+
+	if s.Running() {
+		frame.Set(transportButton, colorPlaying)
+	} else {
+		frame.Set(transportButton, colorStopped)
+	}
+
+	if selected >= len(tracks) {
+		return
+	}
+
+	steps := tracks[selected].Steps()
+	playhead := -1
+	if position >= 0 {
+		playhead = int(position % int64(len(steps)))
+	}
+	for i, on := range steps {
+		btn := launchpad.NewGridButton(i%launchpad.GridWidth, i/launchpad.GridWidth)
+		switch {
+		case i == playhead && on:
+			frame.Set(btn, colorPlayheadStep)
+		case i == playhead:
+			frame.Set(btn, colorPlayhead)
+		case on:
+			frame.Set(btn, colorStep)
+		}
+	}
+}
+
+// Draw sends the sequencer to the attached Launchpad, if any
+// Only LEDs that changed since the previous draw are sent
+func (s *Sequencer) Draw() error {
+	var frame launchpad.Frame
+	s.Render(&frame)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.screen == nil {
+		return nil
+	}
+	return s.screen.Draw(&frame)
+}
@@ -0,0 +1,128 @@
+package sequencer
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Pattern length limits
+const (
+	MinSteps = 8  // Shortest pattern (one grid row)
+	MaxSteps = 64 // Longest pattern (the whole grid)
+)
+
+// Track is a pattern of steps triggering one note
+type Track struct {
+	mu       sync.Mutex
+	name     string
+	note     uint8
+	channel  uint8
+	velocity uint8
+	steps    []bool
+}
+
+// NewTrack creates an empty track playing a note on MIDI channel 0 at full velocity
+func NewTrack(name string, note uint8, length int) (*Track, error) {
+	if length < MinSteps || length > MaxSteps {
+		return nil, fmt.Errorf("invalid track length: %d", length)
+	}
+	return &Track{
+		name:     name,
+		note:     note & 0x7F,
+		velocity: 127,
+		steps:    make([]bool, length),
+	}, nil
+}
+
+// Name returns the track name
+func (t *Track) Name() string {
+	return t.name
+}
+
+// Note returns the MIDI note, channel and velocity played by the track
+func (t *Track) Note() (note, channel, velocity uint8) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.note, t.channel, t.velocity
+}
+
+// SetNote sets the MIDI note, channel (0-15) and velocity played by the track
+func (t *Track) SetNote(note, channel, velocity uint8) error {
+	if note > 127 || channel > 15 || velocity > 127 {
+		return fmt.Errorf("invalid note %d, channel %d or velocity %d", note, channel, velocity)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.note, t.channel, t.velocity = note, channel, velocity
+	return nil
+}
+
+// Length returns the number of steps in the pattern
+func (t *Track) Length() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.steps)
+}
+
+// SetLength changes the number of steps, keeping existing steps that still fit
+func (t *Track) SetLength(length int) error {
+	if length < MinSteps || length > MaxSteps {
+		return fmt.Errorf("invalid track length: %d", length)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	steps := make([]bool, length)
+	copy(steps, t.steps)
+	t.steps = steps
+	return nil
+}
+
+// Step returns whether a step is active (false when out of range)
+func (t *Track) Step(index int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if index < 0 || index >= len(t.steps) {
+		return false
+	}
+	return t.steps[index]
+}
+
+// SetStep activates or clears a step
+func (t *Track) SetStep(index int, on bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if index < 0 || index >= len(t.steps) {
+		return fmt.Errorf("invalid step: %d", index)
+	}
+	t.steps[index] = on
+	return nil
+}
+
+// ToggleStep flips a step and returns its new state
+func (t *Track) ToggleStep(index int) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if index < 0 || index >= len(t.steps) {
+		return false, fmt.Errorf("invalid step: %d", index)
+	}
+	t.steps[index] = !t.steps[index]
+	return t.steps[index], nil
+}
+
+// Steps returns a copy of the pattern
+func (t *Track) Steps() []bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	steps := make([]bool, len(t.steps))
+	copy(steps, t.steps)
+	return steps
+}
+
+// Clear deactivates every step
+func (t *Track) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.steps {
+		t.steps[i] = false
+	}
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// ClipState is the state of a clip slot
+type ClipState int
+
+const (
+	ClipEmpty     ClipState = iota // No clip in the slot
+	ClipStopped                    // Clip present but not playing
+	ClipQueued                     // Clip will start playing at the next launch boundary
+	ClipPlaying                    // Clip playing
+	ClipRecording                  // Clip recording
+)
+
+// String returns the string representation of a ClipState
+func (s ClipState) String() string {
+	switch s {
+	case ClipEmpty:
+		return "Empty"
+	case ClipStopped:
+		return "Stopped"
+	case ClipQueued:
+		return "Queued"
+	case ClipPlaying:
+		return "Playing"
+	case ClipRecording:
+		return "Recording"
+	default:
+		return fmt.Sprintf("ClipState(%d)", s)
+	}
+}
+
+// Valid returns true if the state is a known clip state
+func (s ClipState) Valid() bool {
+	return s >= ClipEmpty && s <= ClipRecording
+}
+
+// active returns true if the clip is producing sound
+func (s ClipState) active() bool {
+	return s == ClipPlaying || s == ClipRecording
+}
+
+// Style holds the LED state of each clip state
+// Queued clips flash, which needs the Launchpad's flash mode (enabled by Attach)
+type Style map[ClipState]launchpad.LEDState
+
+// DefaultStyle is the style given to new sessions
+var DefaultStyle = Style{
+	ClipEmpty:     {},
+	ClipStopped:   launchpad.NewLEDState(launchpad.ColorAmber, launchpad.BrightnessLow),
+	ClipQueued:    {Green: launchpad.BrightnessFull, Flash: true},
+	ClipPlaying:   launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull),
+	ClipRecording: launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessFull),
+}
+
+// ClipEvent reports a clip slot changing state
+type ClipEvent struct {
+	Track    int       // Track (column) of the clip
+	Scene    int       // Scene (row) of the clip
+	State    ClipState // New state
+	Previous ClipState // State before the change
+}
+
+// ClipHandler is a function that handles clip state changes
+type ClipHandler func(ClipEvent)
+
+// Quantize is the boundary queued launches and stops wait for
+type Quantize int
+
+const (
+	QuantizeNone Quantize = iota // Launch immediately
+	QuantizeBeat                 // Launch on the next beat
+	QuantizeBar                  // Launch on the next bar
+)
+
+// String returns the string representation of a Quantize
+func (q Quantize) String() string {
+	switch q {
+	case QuantizeNone:
+		return "None"
+	case QuantizeBeat:
+		return "Beat"
+	case QuantizeBar:
+		return "Bar"
+	default:
+		return fmt.Sprintf("Quantize(%d)", q)
+	}
+}
@@ -0,0 +1,452 @@
+// Package session models a clip launcher: a matrix of tracks by scenes.
+//
+// The grid shows an 8×8 window onto the matrix with one track per column and one
+// scene per row; each clip state has its own color, and clips queued to start
+// or stop flash.
+// Pressing a clip queues it to play (or, if it is playing, to stop), pressing an
+// empty slot queues the track to stop, and the scene buttons launch whole rows.
+// The first four top buttons scroll the window up, down, left and right.
+//
+// Queued launches take effect at the next quantization boundary, signalled by
+// calling Beat from a clock:
+//
+//	s := session.New(8, 16)
+//	s.SetQuantize(session.QuantizeBar)
+//	s.SetClip(0, 0, session.ClipStopped)
+//	s.OnClip(func(e session.ClipEvent) { fmt.Println(e.Track, e.Scene, e.State) })
+//	clk.OnBeat(func(e midiclock.BeatEvent) { s.Beat(e.BeatInBar) })
+//	s.Attach(lp)
+package session
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Top row buttons used to scroll the view
+var (
+	buttonUp    = launchpad.NewTopButton(0)
+	buttonDown  = launchpad.NewTopButton(1)
+	buttonLeft  = launchpad.NewTopButton(2)
+	buttonRight = launchpad.NewTopButton(3)
+)
+
+// Colors used for the scene and scroll buttons
+var (
+	colorSceneClips   = launchpad.NewLEDState(launchpad.ColorAmber, launchpad.BrightnessLow)
+	colorScenePlaying = launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull)
+	colorScroll       = launchpad.NewLEDState(launchpad.ColorRed, launchpad.BrightnessLow)
+)
+
+// slot is a clip slot with a pending change
+type slot struct {
+	state  ClipState
+	queued bool      // True if a change is waiting for the next boundary
+	target ClipState // State applied at the boundary
+}
+
+// Session is a matrix of clip slots
+type Session struct {
+	mu       sync.Mutex
+	tracks   int
+	scenes   int
+	slots    [][]slot // Indexed [scene][track]
+	quantize Quantize
+	style    Style
+	handlers []ClipHandler
+
+	// View offset
+	trackOffset int
+	sceneOffset int
+
+	// Display
+	screen *launchpad.FrameWriter // Draws on the attached Launchpad, nil until Attach
+}
+
+// New creates a session of empty slots
+func New(tracks, scenes int) *Session {
+	tracks = max(1, tracks)
+	scenes = max(1, scenes)
+	slots := make([][]slot, scenes)
+	for i := range slots {
+		slots[i] = make([]slot, tracks)
+	}
+	return &Session{
+		tracks:   tracks,
+		scenes:   scenes,
+		slots:    slots,
+		quantize: QuantizeBar,
+		style:    DefaultStyle,
+	}
+}
+
+// Size returns the number of tracks and scenes
+func (s *Session) Size() (tracks, scenes int) {
+	return s.tracks, s.scenes
+}
+
+// SetQuantize sets the boundary queued launches wait for
+func (s *Session) SetQuantize(q Quantize) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quantize = q
+}
+
+// SetStyle sets the LED state of each clip state
+func (s *Session) SetStyle(style Style) error {
+	s.mu.Lock()
+	s.style = style
+	s.mu.Unlock()
+	return s.Draw()
+}
+
+// OnClip registers a handler called whenever a clip changes state, including
+// when it is queued and when the queued change is applied
+func (s *Session) OnClip(handler ClipHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+// Clip returns the state of a slot
+func (s *Session) Clip(track, scene int) (ClipState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.validLocked(track, scene) {
+		return ClipEmpty, fmt.Errorf("invalid clip slot: track %d, scene %d", track, scene)
+	}
+	return s.slots[scene][track].state, nil
+}
+
+// SetClip sets the state of a slot immediately, cancelling any queued change
+// Use it to mirror clips created, deleted or recorded elsewhere
+func (s *Session) SetClip(track, scene int, state ClipState) error {
+	if !state.Valid() {
+		return fmt.Errorf("invalid clip state: %v", state)
+	}
+
+	s.mu.Lock()
+	if !s.validLocked(track, scene) {
+		s.mu.Unlock()
+		return fmt.Errorf("invalid clip slot: track %d, scene %d", track, scene)
+	}
+	var events []ClipEvent
+	if state.active() {
+		events = s.stopTrackLocked(track, scene)
+	}
+	s.slots[scene][track].queued = false
+	events = append(events, s.setLocked(track, scene, state)...)
+	s.mu.Unlock()
+
+	s.notify(events)
+	return s.Draw()
+}
+
+// Launch queues a clip to play at the next boundary
+// Launching an empty slot queues its track to stop instead
+func (s *Session) Launch(track, scene int) error {
+	s.mu.Lock()
+	if !s.validLocked(track, scene) {
+		s.mu.Unlock()
+		return fmt.Errorf("invalid clip slot: track %d, scene %d", track, scene)
+	}
+	events := s.launchLocked(track, scene)
+	s.mu.Unlock()
+
+	s.notify(events)
+	return s.Draw()
+}
+
+// LaunchScene queues every slot of a scene to launch
+func (s *Session) LaunchScene(scene int) error {
+	s.mu.Lock()
+	if scene < 0 || scene >= s.scenes {
+		s.mu.Unlock()
+		return fmt.Errorf("invalid scene: %d", scene)
+	}
+	var events []ClipEvent
+	for track := 0; track < s.tracks; track++ {
+		events = append(events, s.launchLocked(track, scene)...)
+	}
+	s.mu.Unlock()
+
+	s.notify(events)
+	return s.Draw()
+}
+
+// StopTrack queues a track's playing clip to stop at the next boundary
+func (s *Session) StopTrack(track int) error {
+	s.mu.Lock()
+	if track < 0 || track >= s.tracks {
+		s.mu.Unlock()
+		return fmt.Errorf("invalid track: %d", track)
+	}
+	events := s.queueStopLocked(track)
+	s.mu.Unlock()
+
+	s.notify(events)
+	return s.Draw()
+}
+
+// Beat signals the start of a beat; beatInBar 0 is the downbeat of a bar
+// Queued changes are applied if the beat is a quantization boundary
+func (s *Session) Beat(beatInBar int) error {
+	s.mu.Lock()
+	boundary := s.quantize == QuantizeBeat || (s.quantize == QuantizeBar && beatInBar == 0)
+	s.mu.Unlock()
+
+	if !boundary {
+		return nil
+	}
+	return s.Flush()
+}
+
+// Flush applies every queued change immediately
+func (s *Session) Flush() error {
+	s.mu.Lock()
+	events := s.applyQueuedLocked()
+	s.mu.Unlock()
+
+	s.notify(events)
+	return s.Draw()
+}
+
+// validLocked returns true if a slot exists (caller must hold the lock)
+func (s *Session) validLocked(track, scene int) bool {
+	return track >= 0 && track < s.tracks && scene >= 0 && scene < s.scenes
+}
+
+// setLocked changes a slot's state and returns the resulting event, if any
+// (caller must hold the lock)
+func (s *Session) setLocked(track, scene int, state ClipState) []ClipEvent {
+	previous := s.slots[scene][track].state
+	if previous == state {
+		return nil
+	}
+	s.slots[scene][track].state = state
+	return []ClipEvent{{Track: track, Scene: scene, State: state, Previous: previous}}
+}
+
+// stopTrackLocked stops every active or queued clip of a track other than
+// the one at scene (caller must hold the lock)
+func (s *Session) stopTrackLocked(track, scene int) []ClipEvent {
+	var events []ClipEvent
+	for other := 0; other < s.scenes; other++ {
+		if other == scene {
+			continue
+		}
+		sl := &s.slots[other][track]
+		sl.queued = false
+		if sl.state.active() || sl.state == ClipQueued {
+			events = append(events, s.setLocked(track, other, ClipStopped)...)
+		}
+	}
+	return events
+}
+
+// launchLocked queues a slot to play, or its track to stop if it is empty
+// (caller must hold the lock)
+func (s *Session) launchLocked(track, scene int) []ClipEvent {
+	sl := &s.slots[scene][track]
+	switch {
+	case sl.state == ClipEmpty:
+		return s.queueStopLocked(track)
+	case sl.state.active():
+		// Launching a playing clip stops it
+		sl.queued = true
+		sl.target = ClipStopped
+		if s.quantize == QuantizeNone {
+			return s.applyQueuedLocked()
+		}
+		return nil
+	default:
+		sl.queued = true
+		sl.target = ClipPlaying
+		events := s.setLocked(track, scene, ClipQueued)
+		if s.quantize == QuantizeNone {
+			events = append(events, s.applyQueuedLocked()...)
+		}
+		return events
+	}
+}
+
+// queueStopLocked queues the active clips of a track to stop (caller must hold the lock)
+func (s *Session) queueStopLocked(track int) []ClipEvent {
+	for scene := 0; scene < s.scenes; scene++ {
+		sl := &s.slots[scene][track]
+		if sl.state.active() {
+			sl.queued = true
+			sl.target = ClipStopped
+		}
+	}
+	if s.quantize == QuantizeNone {
+		return s.applyQueuedLocked()
+	}
+	return nil
+}
+
+// applyQueuedLocked applies every queued change, stopping the other clips of
+// tracks that start playing (caller must hold the lock)
+func (s *Session) applyQueuedLocked() []ClipEvent {
+	var events []ClipEvent
+	for scene := 0; scene < s.scenes; scene++ {
+		for track := 0; track < s.tracks; track++ {
+			sl := &s.slots[scene][track]
+			if !sl.queued {
+				continue
+			}
+			sl.queued = false
+			if sl.target.active() {
+				events = append(events, s.stopTrackLocked(track, scene)...)
+			}
+			events = append(events, s.setLocked(track, scene, sl.target)...)
+		}
+	}
+	return events
+}
+
+// notify calls the clip handlers for each event
+func (s *Session) notify(events []ClipEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	handlers := make([]ClipHandler, len(s.handlers))
+	copy(handlers, s.handlers)
+	s.mu.Unlock()
+
+	for _, e := range events {
+		for _, handler := range handlers {
+			handler(e)
+		}
+	}
+}
+
+// Scroll moves the view by a number of tracks and scenes, clamped to the matrix
+func (s *Session) Scroll(tracks, scenes int) error {
+	s.mu.Lock()
+	s.trackOffset = max(0, min(s.trackOffset+tracks, s.tracks-launchpad.GridWidth))
+	s.sceneOffset = max(0, min(s.sceneOffset+scenes, s.scenes-launchpad.GridHeight))
+	s.mu.Unlock()
+	return s.Draw()
+}
+
+// Offset returns the first track and scene shown on the grid
+func (s *Session) Offset() (track, scene int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.trackOffset, s.sceneOffset
+}
+
+// Attach registers the session for a Launchpad's button events, enables flashing
+// for queued clips and draws the view
+func (s *Session) Attach(lp *launchpad.Launchpad) error {
+	s.mu.Lock()
+	s.screen = launchpad.NewFrameWriter(lp)
+	s.mu.Unlock()
+
+	lp.OnButton(s.Handle)
+	err := lp.EnableFlash(true)
+	if err != nil {
+		return err
+	}
+	return s.Draw()
+}
+
+// Handle launches clips and scenes and scrolls the view
+// It can be registered directly with Launchpad.OnButton or a page's OnButton
+func (s *Session) Handle(event launchpad.ButtonEvent) {
+	if !event.Pressed {
+		return
+	}
+
+	btn := event.Button
+	s.mu.Lock()
+	track := s.trackOffset + btn.X
+	scene := s.sceneOffset + btn.Y
+	s.mu.Unlock()
+
+	switch {
+	case btn == buttonUp:
+		s.Scroll(0, -1)
+	case btn == buttonDown:
+		s.Scroll(0, 1)
+	case btn == buttonLeft:
+		s.Scroll(-1, 0)
+	case btn == buttonRight:
+		s.Scroll(1, 0)
+	case btn.IsScene:
+		s.LaunchScene(scene)
+	case !btn.IsTop:
+		s.Launch(track, scene)
+	}
+}
+
+// Render draws the visible clips, scene buttons and scroll arrows into a frame
+func (s *Session) Render(frame *launchpad.Frame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for y := 0; y < launchpad.GridHeight; y++ {
+		scene := s.sceneOffset + y
+		if scene >= s.scenes {
+			break
+		}
+
+		hasClips, playing := false, false
+		for x := 0; x < launchpad.GridWidth; x++ {
+			track := s.trackOffset + x
+			if track >= s.tracks {
+				break
+			}
+			sl := s.slots[scene][track]
+			state := sl.state
+			led := s.style[state]
+			if sl.queued && sl.target == ClipStopped {
+				// Clips queued to stop flash in their current color
+				led.Flash = true
+			}
+			frame.Set(launchpad.NewGridButton(x, y), led)
+			hasClips = hasClips || state != ClipEmpty
+			playing = playing || state.active()
+		}
+
+		switch {
+		case playing:
+			frame.Set(launchpad.NewSceneButton(y), colorScenePlaying)
+		case hasClips:
+			frame.Set(launchpad.NewSceneButton(y), colorSceneClips)
+		}
+	}
+
+	if s.sceneOffset > 0 {
+		frame.Set(buttonUp, colorScroll)
+	}
+	if s.sceneOffset+launchpad.GridHeight < s.scenes {
+		frame.Set(buttonDown, colorScroll)
+	}
+	if s.trackOffset > 0 {
+		frame.Set(buttonLeft, colorScroll)
+	}
+	if s.trackOffset+launchpad.GridWidth < s.tracks {
+		frame.Set(buttonRight, colorScroll)
+	}
+}
+
+// Draw sends the session view to the attached Launchpad, if any
+// Only LEDs that changed since the previous draw are sent
+func (s *Session) Draw() error {
+	var frame launchpad.Frame
+	s.Render(&frame)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.screen == nil {
+		return nil
+	}
+	return s.screen.Draw(&frame)
+}
@@ -0,0 +1,58 @@
+package show
+
+import (
+	"time"
+
+	"github.com/inegm/golp/internal/playback"
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// ErrStopped is returned by Play when playback was stopped
+var ErrStopped = playback.ErrStopped
+
+// Player plays a show on a Launchpad
+type Player struct {
+	ctl  playback.Control
+	show *Show
+}
+
+// NewPlayer creates a player at the original speed
+func NewPlayer(s *Show) *Player {
+	return &Player{show: s}
+}
+
+// SetSpeed scales playback speed: 2 plays twice as fast, 0.5 at half speed
+// Values of 0 or less are ignored
+func (p *Player) SetSpeed(speed float64) {
+	p.ctl.SetSpeed(speed)
+}
+
+// Play clears the LEDs and plays the show, blocking until it ends or Stop is called
+// Cues at the same time are sent together, as a rapid update when that is cheaper
+func (p *Player) Play(lp *launchpad.Launchpad) error {
+	// The first step clears the LEDs; cues at the same time share a step
+	times := []time.Duration{0}
+	steps := [][]Cue{nil}
+	for _, cue := range p.show.Cues {
+		if n := len(times); n > 1 && times[n-1] == cue.Time {
+			steps[n-1] = append(steps[n-1], cue)
+			continue
+		}
+		times = append(times, cue.Time)
+		steps = append(steps, []Cue{cue})
+	}
+
+	screen := launchpad.NewFrameWriter(lp)
+	var frame launchpad.Frame
+	return p.ctl.Play(times, func(i int) error {
+		for _, cue := range steps[i] {
+			frame.Set(cue.Button, cue.State())
+		}
+		return screen.Draw(&frame)
+	})
+}
+
+// Stop ends playback early
+func (p *Player) Stop() {
+	p.ctl.Stop()
+}
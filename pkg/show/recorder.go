@@ -0,0 +1,53 @@
+package show
+
+import (
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Recorder captures LED writes from outgoing traffic as a show
+// Buffer commands are not interpreted: every LED write is recorded as if it
+// were displayed immediately
+type Recorder struct {
+	mu      sync.Mutex
+	decoder launchpad.Decoder
+	start   time.Time
+	started bool
+	cues    []Cue
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Handle records the LED writes of a message; it can be passed to OnTraffic
+// The show starts with the first LED write
+func (r *Recorder) Handle(t launchpad.Traffic) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, w := range r.decoder.LEDWrites(t) {
+		if !r.started {
+			r.start = t.Time
+			r.started = true
+		}
+		r.cues = append(r.cues, Cue{
+			Time:     t.Time.Sub(r.start),
+			Button:   w.Button,
+			Velocity: w.Velocity,
+		})
+	}
+}
+
+// Show returns the cues recorded so far
+func (r *Recorder) Show() *Show {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cues := make([]Cue, len(r.cues))
+	copy(cues, r.cues)
+	return &Show{Cues: cues}
+}
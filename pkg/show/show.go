@@ -0,0 +1,183 @@
+// Package show plays LED shows stored as Standard MIDI Files and records LED
+// output back to them.
+//
+// A show file uses the Launchpad's own encoding, so it can be drawn in any
+// DAW piano roll: note numbers are X-Y keys (16 × row + column, column 8 for
+// scene buttons), the velocity is the LED velocity (16 × green + red + flags)
+// and a note lasts as long as its LED is lit. Controllers 104-111 set the top
+// buttons. Channels are ignored and tempo changes are honored:
+//
+//	s, err := show.ReadFile("intro.mid")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	err = show.NewPlayer(s).Play(lp)
+//
+// A Recorder captures the LED writes of a running program and saves them in
+// the same format:
+//
+//	rec := show.NewRecorder()
+//	lp.OnTraffic(rec.Handle)
+//	...
+//	err := rec.Show().WriteFile("captured.mid")
+package show
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+// Tempo and resolution of written files
+const (
+	FileTempo      = 120
+	FileResolution = smf.MetricTicks(960)
+)
+
+// controllerTopButton0 is the controller of the leftmost top button
+const controllerTopButton0 = 104
+
+// offVelocity is the velocity used for notes that end
+var offVelocity = launchpad.LEDState{}.Velocity()
+
+// Cue is a timed LED change
+type Cue struct {
+	Time     time.Duration // Time since the start of the show
+	Button   launchpad.Button
+	Velocity byte // LED velocity, see launchpad.LEDStateFromVelocity
+}
+
+// State returns the LED state set by the cue
+func (c Cue) State() launchpad.LEDState {
+	return launchpad.LEDStateFromVelocity(c.Velocity)
+}
+
+// Show is a list of cues in time order
+type Show struct {
+	Cues []Cue
+}
+
+// Duration returns the time of the last cue
+func (s *Show) Duration() time.Duration {
+	if len(s.Cues) == 0 {
+		return 0
+	}
+	return s.Cues[len(s.Cues)-1].Time
+}
+
+// ReadFile loads a show from a Standard MIDI File
+func ReadFile(path string) (*Show, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read loads a show from a Standard MIDI File
+// Messages that do not map to an LED are skipped
+func Read(r io.Reader) (*Show, error) {
+	s := &Show{}
+	tracks := smf.ReadTracksFrom(r).Do(func(te smf.TrackEvent) {
+		btn, velocity, ok := decode(midi.Message(te.Message))
+		if ok {
+			s.Cues = append(s.Cues, Cue{
+				Time:     time.Duration(te.AbsMicroSeconds) * time.Microsecond,
+				Button:   btn,
+				Velocity: velocity,
+			})
+		}
+	})
+	if err := tracks.Error(); err != nil {
+		return nil, fmt.Errorf("failed to read MIDI file: %w", err)
+	}
+
+	// Tracks are read one after another; stable sorting keeps the order of
+	// cues at the same time within a track
+	sort.SliceStable(s.Cues, func(i, j int) bool {
+		return s.Cues[i].Time < s.Cues[j].Time
+	})
+	return s, nil
+}
+
+// decode maps a MIDI message to an LED change
+func decode(msg midi.Message) (launchpad.Button, byte, bool) {
+	var channel, key, velocity, controller, value uint8
+
+	switch {
+	case msg.GetNoteStart(&channel, &key, &velocity):
+		btn, ok := launchpad.ButtonForKey(int(key))
+		return btn, velocity, ok
+
+	case msg.GetNoteEnd(&channel, &key):
+		btn, ok := launchpad.ButtonForKey(int(key))
+		return btn, offVelocity, ok
+
+	case msg.GetControlChange(&channel, &controller, &value):
+		x := int(controller) - controllerTopButton0
+		if x >= 0 && x < launchpad.TopButtons {
+			return launchpad.NewTopButton(x), value, true
+		}
+	}
+	return launchpad.Button{}, 0, false
+}
+
+// SMF returns the show as a single-track Standard MIDI File
+// Each lit LED becomes a note lasting until the LED changes; changing to
+// another lit state ends the note and starts a new one at the same time.
+// Notes for LEDs still lit at the end of the show are left open, so the
+// last frame stays up when the file is played back
+func (s *Show) SMF() *smf.SMF {
+	var track smf.Track
+	track.Add(0, smf.MetaTempo(FileTempo))
+
+	lit := make(map[launchpad.Button]bool)
+	var last uint32
+	add := func(at time.Duration, msg midi.Message) {
+		ticks := max(FileResolution.Ticks(FileTempo, at), last)
+		track.Add(ticks-last, msg)
+		last = ticks
+	}
+
+	for _, cue := range s.Cues {
+		if cue.Button.IsTop {
+			add(cue.Time, midi.ControlChange(0, uint8(cue.Button.MIDIController()), cue.Velocity))
+			continue
+		}
+
+		key := uint8(cue.Button.MIDIKey())
+		if lit[cue.Button] {
+			add(cue.Time, midi.NoteOff(0, key))
+		}
+
+		state := cue.State()
+		lit[cue.Button] = state.Red != launchpad.BrightnessOff || state.Green != launchpad.BrightnessOff
+		if lit[cue.Button] {
+			add(cue.Time, midi.NoteOn(0, key, cue.Velocity))
+		}
+	}
+
+	track.Close(0)
+
+	file := smf.New()
+	file.TimeFormat = FileResolution
+	file.Add(track)
+	return file
+}
+
+// WriteTo writes the show as a Standard MIDI File
+func (s *Show) WriteTo(w io.Writer) (int64, error) {
+	return s.SMF().WriteTo(w)
+}
+
+// WriteFile writes the show to a Standard MIDI File
+func (s *Show) WriteFile(path string) error {
+	return s.SMF().WriteFile(path)
+}
@@ -0,0 +1,155 @@
+package show
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+// roundTrip writes a show as a Standard MIDI File and reads it back
+func roundTrip(t *testing.T, s *Show) *Show {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestSMFRoundTrip(t *testing.T) {
+	red := launchpad.LEDState{Red: launchpad.BrightnessFull}.Velocity()
+	amberFlash := launchpad.LEDState{Red: launchpad.BrightnessMedium, Green: launchpad.BrightnessMedium, Flash: true}.Velocity()
+	green := launchpad.LEDState{Green: launchpad.BrightnessLow}.Velocity()
+
+	grid := launchpad.NewGridButton(3, 4)
+	scene := launchpad.NewSceneButton(7)
+	top := launchpad.NewTopButton(1)
+
+	tests := []struct {
+		name string
+		cues []Cue
+		want []Cue // Same as cues when nil
+	}{
+		{"empty", nil, nil},
+		{
+			"on and off",
+			[]Cue{
+				{0, grid, red},
+				{250 * time.Millisecond, scene, amberFlash},
+				{time.Second, grid, offVelocity},
+				{1500 * time.Millisecond, scene, offVelocity},
+			},
+			nil,
+		},
+		{
+			"top buttons",
+			[]Cue{
+				{0, top, green},
+				{500 * time.Millisecond, top, offVelocity},
+			},
+			nil,
+		},
+		{
+			// A note cannot change velocity, so it ends and a new one starts
+			"color change",
+			[]Cue{
+				{0, grid, red},
+				{time.Second, grid, green},
+			},
+			[]Cue{
+				{0, grid, red},
+				{time.Second, grid, offVelocity},
+				{time.Second, grid, green},
+			},
+		},
+		{
+			// Unlit LEDs do not start notes
+			"off while unlit",
+			[]Cue{
+				{0, grid, offVelocity},
+				{time.Second, scene, red},
+			},
+			[]Cue{
+				{time.Second, scene, red},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want == nil {
+				want = tt.cues
+			}
+
+			got := roundTrip(t, &Show{Cues: tt.cues})
+			if !reflect.DeepEqual(got.Cues, want) {
+				t.Fatalf("round trip = %v, want %v", got.Cues, want)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	const resolution = smf.MetricTicks(960)
+
+	// Track 0 slows from 120 to 60 BPM after one beat
+	var tempo smf.Track
+	tempo.Add(0, smf.MetaTempo(120))
+	tempo.Add(960, smf.MetaTempo(60))
+	tempo.Add(960, midi.NoteOn(0, 0x34, 0x0F)) // grid[4,3] at 1.5s
+	tempo.Close(0)
+
+	// Track 1 is merged in time order; channels are ignored
+	var leds smf.Track
+	leds.Add(480, midi.NoteOn(5, 0x08, 0x3C))       // scene[0] at 0.25s
+	leds.Add(0, midi.ProgramChange(0, 3))           // Skipped
+	leds.Add(0, midi.NoteOn(0, 0x09, 0x0F))         // Column 9 does not exist
+	leds.Add(0, midi.ControlChange(0, 7, 100))      // Not a top button
+	leds.Add(480, midi.ControlChange(0, 105, 0x30)) // top[1] at 0.5s
+	leds.Add(480, midi.NoteOff(5, 0x08))            // scene[0] off at 1s, after the tempo change
+	leds.Close(0)
+
+	file := smf.New()
+	file.TimeFormat = resolution
+	file.Add(tempo)
+	file.Add(leds)
+
+	var buf bytes.Buffer
+	if _, err := file.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{250 * time.Millisecond, launchpad.NewSceneButton(0), 0x3C},
+		{500 * time.Millisecond, launchpad.NewTopButton(1), 0x30},
+		{time.Second, launchpad.NewSceneButton(0), offVelocity},
+		{1500 * time.Millisecond, launchpad.NewGridButton(4, 3), 0x0F},
+	}
+	if !reflect.DeepEqual(s.Cues, want) {
+		t.Fatalf("cues = %v, want %v", s.Cues, want)
+	}
+	if s.Duration() != 1500*time.Millisecond {
+		t.Fatalf("duration = %v, want 1.5s", s.Duration())
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("MThd garbage"))); err == nil {
+		t.Fatal("read garbage without an error")
+	}
+}
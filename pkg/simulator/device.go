@@ -0,0 +1,294 @@
+// Package simulator emulates a Launchpad Mini so golp apps can run without hardware.
+//
+// A Device interprets the MIDI the Launchpad would receive (LED notes and
+// controllers, rapid updates, buffer, flash, reset and test commands) and
+// sends button presses and device inquiry replies back as the Launchpad
+// would. Its In and Out ports plug straight into a Launchpad, and a Terminal
+// renders it with ANSI colors and turns mouse clicks and keys into presses:
+//
+//	sim := simulator.New()
+//	lp := launchpad.New()
+//	err := lp.OpenPorts(sim.In(), sim.Out())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	go simulator.NewTerminal(sim).Run()
+//
+// The golp-sim command instead publishes the simulator as a virtual MIDI
+// device named like a Launchpad, so existing apps find it with Open unchanged.
+package simulator
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// MIDI bytes of the Launchpad Mini protocol
+const (
+	statusNoteOn         = 0x90
+	statusControlChange  = 0xB0
+	statusNoteOnChannel3 = 0x92
+
+	controllerSystem     = 0
+	controllerTopButton0 = 104
+
+	systemReset      = 0
+	systemLayoutXY   = 1
+	systemLayoutDrum = 2
+	systemTestLow    = 125
+	systemTestFull   = 127
+
+	bufferBase      = 32
+	bufferFlagFlash = 8
+	bufferFlagCopy  = 16
+
+	velocityPressed = 127
+)
+
+// deviceInquiry is the universal SysEx device inquiry
+var deviceInquiry = []byte{0xF0, 0x7E, 0x7F, 0x06, 0x01, 0xF7}
+
+// identityReply answers device inquiries as a Launchpad Mini (family 0036)
+// with firmware 0000, which no hardware reports
+var identityReply = []byte{0xF0, 0x7E, 0x00, 0x06, 0x02, 0x00, 0x20, 0x29, 0x36, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF7}
+
+// Device is an emulated Launchpad Mini
+type Device struct {
+	mu       sync.Mutex
+	buffers  [2]launchpad.Frame
+	display  launchpad.BufferID
+	update   launchpad.BufferID
+	flash    bool
+	mapping  launchpad.MappingMode
+	rapid    int // Next rapid update slot
+	started  time.Time
+	send     map[int]func(msg []byte)
+	nextSend int
+	onChange []func()
+}
+
+// New creates a simulated device with all LEDs off
+func New() *Device {
+	return &Device{
+		started: time.Now(),
+		send:    make(map[int]func(msg []byte)),
+	}
+}
+
+// Receive interprets a MIDI message sent to the device
+func (d *Device) Receive(msg []byte) {
+	if bytes.Equal(msg, deviceInquiry) {
+		d.emit(identityReply)
+		return
+	}
+
+	if len(msg) < 3 {
+		return
+	}
+
+	d.mu.Lock()
+	changed := d.receive(msg[0], msg[1], msg[2])
+	handlers := make([]func(), len(d.onChange))
+	copy(handlers, d.onChange)
+	d.mu.Unlock()
+
+	if changed {
+		for _, fn := range handlers {
+			fn()
+		}
+	}
+}
+
+// receive applies a message and reports whether anything visible changed
+// (caller must hold the lock)
+func (d *Device) receive(status, data1, data2 byte) bool {
+	// Any other message ends a rapid update
+	if status != statusNoteOnChannel3 {
+		d.rapid = 0
+	}
+
+	switch status {
+	case statusNoteOn:
+		x, y := int(data1%16), int(data1/16)
+		btn := launchpad.NewGridButton(x, y)
+		if x == 8 {
+			btn = launchpad.NewSceneButton(y)
+		}
+		return d.write(launchpad.FrameIndex(btn), data2)
+
+	case statusNoteOnChannel3:
+		if d.rapid >= launchpad.FrameSize {
+			d.rapid = 0
+		}
+		d.write(d.rapid, data1)
+		d.write(d.rapid+1, data2)
+		d.rapid += 2
+		return true
+
+	case statusControlChange:
+		if data1 >= controllerTopButton0 && data1 < controllerTopButton0+launchpad.TopButtons {
+			btn := launchpad.NewTopButton(int(data1 - controllerTopButton0))
+			return d.write(launchpad.FrameIndex(btn), data2)
+		}
+		if data1 == controllerSystem {
+			d.system(data2)
+			return true
+		}
+	}
+	return false
+}
+
+// system applies a system command (caller must hold the lock)
+func (d *Device) system(data byte) {
+	switch {
+	case data == systemReset:
+		d.buffers = [2]launchpad.Frame{}
+		d.display, d.update = launchpad.Buffer0, launchpad.Buffer0
+		d.flash = false
+		d.mapping = launchpad.MappingXY
+
+	case data == systemLayoutXY:
+		d.mapping = launchpad.MappingXY
+
+	case data == systemLayoutDrum:
+		d.mapping = launchpad.MappingDrum
+
+	case data >= systemTestLow && data <= systemTestFull:
+		b := launchpad.Brightness(data - systemTestLow + 1)
+		for i := range d.buffers {
+			d.buffers[i].Fill(launchpad.LEDState{Red: b, Green: b})
+		}
+
+	case data >= bufferBase && data < bufferBase+32:
+		d.display = launchpad.BufferID(data & 1)
+		d.update = launchpad.BufferID((data >> 2) & 1)
+		d.flash = data&bufferFlagFlash != 0
+		if data&bufferFlagCopy != 0 {
+			d.buffers[d.update] = d.buffers[d.display]
+		}
+	}
+}
+
+// write applies an LED velocity to a frame index following the Copy and Clear
+// flags (caller must hold the lock)
+func (d *Device) write(i int, velocity byte) bool {
+	if i < 0 || i >= launchpad.FrameSize {
+		return false
+	}
+
+	state := launchpad.LEDStateFromVelocity(velocity)
+	mode := state.Mode
+	if state.Flash {
+		mode = launchpad.WriteClear
+	}
+	state.Mode = launchpad.WriteNormal
+
+	other := 1 - d.update
+	d.buffers[d.update][i] = state
+	switch mode {
+	case launchpad.WriteNormal, launchpad.WriteCopy:
+		d.buffers[other][i] = state
+	case launchpad.WriteClear:
+		d.buffers[other][i] = launchpad.LEDState{}
+	}
+	return true
+}
+
+// Frame returns the LEDs currently lit
+// While flashing is enabled the displayed buffer alternates with the other one
+// every FlashInterval, as on the hardware
+func (d *Device) Frame() launchpad.Frame {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	buffer := d.display
+	if d.flash && (time.Since(d.started)/FlashInterval)%2 == 1 {
+		buffer = 1 - buffer
+	}
+	return d.buffers[buffer]
+}
+
+// FlashInterval is how long each buffer is shown while flashing
+const FlashInterval = 250 * time.Millisecond
+
+// Flashing returns whether automatic flashing is enabled
+func (d *Device) Flashing() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.flash
+}
+
+// MappingMode returns the layout selected by the host
+func (d *Device) MappingMode() launchpad.MappingMode {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.mapping
+}
+
+// OnChange registers a callback for LED or buffer changes
+func (d *Device) OnChange(fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onChange = append(d.onChange, fn)
+}
+
+// OnSend registers a callback for MIDI the device sends (button presses)
+// The returned function unregisters it
+func (d *Device) OnSend(fn func(msg []byte)) (remove func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id := d.nextSend
+	d.nextSend++
+	d.send[id] = fn
+
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.send, id)
+	}
+}
+
+// Press sends a button press or release as the Launchpad would
+func (d *Device) Press(btn launchpad.Button, pressed bool) {
+	if !btn.Valid() {
+		return
+	}
+
+	var velocity byte
+	if pressed {
+		velocity = velocityPressed
+	}
+
+	var msg []byte
+	if btn.IsTop {
+		msg = []byte{statusControlChange, byte(btn.MIDIController()), velocity}
+	} else {
+		msg = []byte{statusNoteOn, byte(btn.MIDIKey()), velocity}
+	}
+	d.emit(msg)
+}
+
+// emit sends a message from the device to the OnSend callbacks
+func (d *Device) emit(msg []byte) {
+	d.mu.Lock()
+	send := make([]func([]byte), 0, len(d.send))
+	for _, fn := range d.send {
+		send = append(send, fn)
+	}
+	d.mu.Unlock()
+
+	for _, fn := range send {
+		fn(msg)
+	}
+}
+
+// Tap sends a press followed by a release after a short delay
+// Terminals report key presses but not releases, so keys tap buttons
+func (d *Device) Tap(btn launchpad.Button) {
+	d.Press(btn, true)
+	time.AfterFunc(100*time.Millisecond, func() { d.Press(btn, false) })
+}
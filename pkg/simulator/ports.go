@@ -0,0 +1,127 @@
+package simulator
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// PortName is the name of the simulator's ports
+// It contains "Launchpad" so port searches that match real devices match it too
+const PortName = "Launchpad Mini (golp simulator)"
+
+// In returns the port a host reads button presses from
+func (d *Device) In() drivers.In {
+	return &inPort{dev: d}
+}
+
+// Out returns the port a host sends LED messages to
+func (d *Device) Out() drivers.Out {
+	return &outPort{dev: d}
+}
+
+// inPort delivers the device's button messages to a listener
+type inPort struct {
+	mu     sync.Mutex
+	dev    *Device
+	open   bool
+	remove func()
+}
+
+func (p *inPort) Open() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.open = true
+	return nil
+}
+
+func (p *inPort) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.remove != nil {
+		p.remove()
+		p.remove = nil
+	}
+	p.open = false
+	return nil
+}
+
+func (p *inPort) IsOpen() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.open
+}
+
+func (p *inPort) Number() int             { return 0 }
+func (p *inPort) String() string          { return PortName }
+func (p *inPort) Underlying() interface{} { return p.dev }
+
+// Listen forwards button messages to onMsg until stopped
+func (p *inPort) Listen(onMsg func(msg []byte, milliseconds int32), config drivers.ListenConfig) (func(), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.open {
+		return nil, errors.New("port not open")
+	}
+	if p.remove != nil {
+		return nil, errors.New("port already listening")
+	}
+
+	start := time.Now()
+	p.remove = p.dev.OnSend(func(msg []byte) {
+		onMsg(msg, int32(time.Since(start).Milliseconds()))
+	})
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.remove != nil {
+			p.remove()
+			p.remove = nil
+		}
+	}, nil
+}
+
+// outPort feeds host messages to the device
+type outPort struct {
+	mu   sync.Mutex
+	dev  *Device
+	open bool
+}
+
+func (p *outPort) Open() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.open = true
+	return nil
+}
+
+func (p *outPort) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.open = false
+	return nil
+}
+
+func (p *outPort) IsOpen() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.open
+}
+
+func (p *outPort) Number() int             { return 0 }
+func (p *outPort) String() string          { return PortName }
+func (p *outPort) Underlying() interface{} { return p.dev }
+
+// Send delivers a message to the device
+func (p *outPort) Send(data []byte) error {
+	if !p.IsOpen() {
+		return errors.New("port not open")
+	}
+	p.dev.Receive(data)
+	return nil
+}
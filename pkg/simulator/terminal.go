@@ -0,0 +1,374 @@
+package simulator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Screen layout, in 1-based terminal coordinates
+const (
+	originRow = 3 // Line of the top button row
+	originCol = 3 // Column of the leftmost cell
+	cellWidth = 4 // Columns per cell
+	colPitch  = 5 // Columns from one cell to the next
+	rowPitch  = 2 // Lines from one row to the next
+	sceneGap  = 1 // Extra columns before the scene column
+)
+
+// Terminal renders a simulated device in an ANSI terminal
+//
+// Mouse clicks press and release buttons. Arrow keys (or h/j/k/l) move a
+// cursor, space taps the button under it, enter holds or releases it, and
+// q or Ctrl-C quits. Raw mode is set with stty, so Run needs a Unix terminal.
+type Terminal struct {
+	dev *Device
+	in  *os.File
+	out io.Writer
+
+	mu      sync.Mutex
+	cursorX int // 0-8, 8 is the scene column
+	cursorY int // -1 is the top row
+	held    map[launchpad.Button]bool
+	clicked *launchpad.Button // Button under the mouse while it is down
+	stop    chan struct{}
+	redraw  chan struct{}
+}
+
+// NewTerminal creates a terminal front-end on stdin and stdout
+func NewTerminal(dev *Device) *Terminal {
+	return &Terminal{
+		dev:    dev,
+		in:     os.Stdin,
+		out:    os.Stdout,
+		held:   make(map[launchpad.Button]bool),
+		stop:   make(chan struct{}),
+		redraw: make(chan struct{}, 1),
+	}
+}
+
+// Run takes over the terminal and blocks until the user quits or Stop is called
+func (t *Terminal) Run() error {
+	restore, err := t.setup()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	t.dev.OnChange(t.requestRedraw)
+
+	input := make(chan []byte)
+	go t.readInput(input)
+
+	ticker := time.NewTicker(FlashInterval / 2)
+	defer ticker.Stop()
+
+	var pending []byte
+	t.draw()
+	for {
+		select {
+		case <-t.stop:
+			return nil
+		case <-t.redraw:
+			t.draw()
+		case <-ticker.C:
+			if t.dev.Flashing() {
+				t.draw()
+			}
+		case data, ok := <-input:
+			if !ok {
+				return nil
+			}
+			pending = append(pending, data...)
+			var quit bool
+			pending, quit = t.handleInput(pending)
+			if quit {
+				return nil
+			}
+			t.draw()
+		}
+	}
+}
+
+// Stop ends Run
+func (t *Terminal) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+}
+
+// setup switches the terminal to raw mode with mouse reporting
+// The returned function restores it
+func (t *Terminal) setup() (func(), error) {
+	saved, err := t.stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal mode: %w", err)
+	}
+	if _, err := t.stty("raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("failed to set raw mode: %w", err)
+	}
+
+	// Alternate screen, hidden cursor, button mouse tracking in SGR encoding
+	fmt.Fprint(t.out, "\x1b[?1049h\x1b[?25l\x1b[?1000h\x1b[?1006h")
+
+	return func() {
+		fmt.Fprint(t.out, "\x1b[?1006l\x1b[?1000l\x1b[0m\x1b[?25h\x1b[?1049l")
+		t.stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+// stty runs stty against the input terminal
+func (t *Terminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.in
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// readInput forwards raw input until the terminal is closed
+func (t *Terminal) readInput(input chan<- []byte) {
+	defer close(input)
+
+	buf := make([]byte, 256)
+	for {
+		n, err := t.in.Read(buf)
+		if err != nil {
+			return
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+
+		select {
+		case input <- data:
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// requestRedraw schedules a redraw without blocking the device
+func (t *Terminal) requestRedraw() {
+	select {
+	case t.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// handleInput consumes complete key and mouse sequences and returns the
+// unconsumed tail and whether the user asked to quit
+func (t *Terminal) handleInput(data []byte) ([]byte, bool) {
+	for len(data) > 0 {
+		switch c := data[0]; {
+		case c == 'q' || c == 0x03:
+			return nil, true
+
+		case c == 0x1b:
+			n := t.handleEscape(data)
+			if n == 0 {
+				return data, false // Incomplete sequence
+			}
+			data = data[n:]
+
+		default:
+			t.handleKey(c)
+			data = data[1:]
+		}
+	}
+	return data, false
+}
+
+// handleEscape handles an escape sequence and returns the bytes consumed,
+// or 0 if the sequence is incomplete
+func (t *Terminal) handleEscape(data []byte) int {
+	if len(data) < 3 {
+		if len(data) == 1 {
+			return 1 // A lone escape key
+		}
+		return 0
+	}
+	if data[1] != '[' {
+		return 2
+	}
+
+	switch data[2] {
+	case 'A':
+		t.moveCursor(0, -1)
+		return 3
+	case 'B':
+		t.moveCursor(0, 1)
+		return 3
+	case 'C':
+		t.moveCursor(1, 0)
+		return 3
+	case 'D':
+		t.moveCursor(-1, 0)
+		return 3
+	case '<':
+		// SGR mouse report: ESC [ < button ; column ; line (M|m)
+		end := strings.IndexAny(string(data), "Mm")
+		if end < 0 {
+			return 0
+		}
+		t.handleMouse(string(data[3:end]), data[end] == 'M')
+		return end + 1
+	default:
+		return 3
+	}
+}
+
+// handleMouse presses or releases the button under a left click
+func (t *Terminal) handleMouse(report string, down bool) {
+	fields := strings.Split(report, ";")
+	if len(fields) != 3 {
+		return
+	}
+	code, _ := strconv.Atoi(fields[0])
+	col, _ := strconv.Atoi(fields[1])
+	line, _ := strconv.Atoi(fields[2])
+	if code&3 != 0 || code&32 != 0 {
+		return // Only plain left clicks, no drags
+	}
+
+	t.mu.Lock()
+	if !down {
+		clicked := t.clicked
+		t.clicked = nil
+		t.mu.Unlock()
+		if clicked != nil {
+			t.dev.Press(*clicked, false)
+		}
+		return
+	}
+
+	btn, ok := buttonAtCell(col, line)
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	t.clicked = &btn
+	t.cursorX, t.cursorY = btn.X, btn.Y
+	t.mu.Unlock()
+
+	t.dev.Press(btn, true)
+}
+
+// handleKey handles a single key press
+func (t *Terminal) handleKey(c byte) {
+	switch c {
+	case 'h':
+		t.moveCursor(-1, 0)
+	case 'j':
+		t.moveCursor(0, 1)
+	case 'k':
+		t.moveCursor(0, -1)
+	case 'l':
+		t.moveCursor(1, 0)
+	case ' ':
+		t.dev.Tap(t.cursorButton())
+	case '\r', '\n':
+		btn := t.cursorButton()
+		t.mu.Lock()
+		held := !t.held[btn]
+		t.held[btn] = held
+		t.mu.Unlock()
+		t.dev.Press(btn, held)
+	}
+}
+
+// moveCursor moves the cursor, skipping the empty corner above the scene column
+func (t *Terminal) moveCursor(dx, dy int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	x := min(max(t.cursorX+dx, 0), 8)
+	y := min(max(t.cursorY+dy, -1), 7)
+	if x == 8 && y == -1 {
+		return
+	}
+	t.cursorX, t.cursorY = x, y
+}
+
+// cursorButton returns the button under the cursor
+func (t *Terminal) cursorButton() launchpad.Button {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return launchpad.ButtonAt(t.cursorX, t.cursorY)
+}
+
+// draw renders the whole device
+func (t *Terminal) draw() {
+	frame := t.dev.Frame()
+	cursor := t.cursorButton()
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J\x1b[0m")
+	fmt.Fprintf(&b, "\x1b[1;%dH%s", originCol, PortName)
+	if t.dev.Flashing() {
+		b.WriteString("  [flash]")
+	}
+
+	for i, state := range frame {
+		btn := launchpad.FrameButton(i)
+		col, line := cellPosition(btn)
+		r, g, bl := ledColor(state)
+
+		fmt.Fprintf(&b, "\x1b[%d;%dH", line, col)
+		if btn.IsScene || btn.IsTop {
+			fmt.Fprintf(&b, "(\x1b[48;2;%d;%d;%dm  \x1b[0m)", r, g, bl)
+		} else {
+			fmt.Fprintf(&b, "\x1b[48;2;%d;%d;%dm%s\x1b[0m", r, g, bl, strings.Repeat(" ", cellWidth))
+		}
+
+		if btn == cursor {
+			fmt.Fprintf(&b, "\x1b[%d;%dH\x1b[1m[\x1b[%d;%dH]\x1b[0m", line, col-1, line, col+cellWidth)
+		}
+	}
+
+	help := "click or arrows/hjkl + space (tap) / enter (hold), q to quit"
+	fmt.Fprintf(&b, "\x1b[%d;%dH%s", originRow+rowPitch*9, originCol, help)
+
+	// Raw mode needs explicit carriage returns, so everything is positioned absolutely
+	io.WriteString(t.out, b.String())
+}
+
+// ledColor approximates an LED state as an RGB color
+func ledColor(state launchpad.LEDState) (r, g, b int) {
+	if state.Red == 0 && state.Green == 0 {
+		return 38, 38, 38
+	}
+	return 40 + int(state.Red)*71, 40 + int(state.Green)*71, 24
+}
+
+// cellPosition returns the terminal column and line of a button's cell
+func cellPosition(btn launchpad.Button) (col, line int) {
+	col = originCol + btn.X*colPitch
+	if btn.IsScene {
+		col += sceneGap
+	}
+	line = originRow + (btn.Y+1)*rowPitch
+	return col, line
+}
+
+// buttonAtCell returns the button drawn at a terminal position
+func buttonAtCell(col, line int) (launchpad.Button, bool) {
+	for i := 0; i < launchpad.FrameSize; i++ {
+		btn := launchpad.FrameButton(i)
+		c, l := cellPosition(btn)
+		if line == l && col >= c && col < c+cellWidth {
+			return btn, true
+		}
+	}
+	return launchpad.Button{}, false
+}
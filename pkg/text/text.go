@@ -0,0 +1,65 @@
+// Package text draws scrolling text on the Launchpad grid.
+//
+// Text is rendered with a 5x7 ASCII font into columns of pixels, which can be
+// drawn at any horizontal offset or scrolled across the grid:
+//
+//	err := text.Scroll(lp, "hello", launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull), 80*time.Millisecond)
+package text
+
+import (
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Columns renders a string as pixel columns, with one blank column between
+// characters. Bit 0 of each column is the top row; only 7 rows are used
+func Columns(s string) []uint8 {
+	var columns []uint8
+	for i, r := range []rune(s) {
+		if i > 0 {
+			columns = append(columns, 0)
+		}
+		g := glyph(r)
+		columns = append(columns, g[:]...)
+	}
+	return columns
+}
+
+// Draw draws columns on the grid of a frame starting at a column offset
+// Pixels are drawn on rows 1-7; an offset of GridWidth starts just off the right edge
+func Draw(frame *launchpad.Frame, columns []uint8, offset int, state launchpad.LEDState) {
+	for x := 0; x < launchpad.GridWidth; x++ {
+		i := x - offset
+		if i < 0 || i >= len(columns) {
+			continue
+		}
+		for row := 0; row < 7; row++ {
+			if columns[i]&(1<<row) != 0 {
+				frame.Set(launchpad.NewGridButton(x, row+1), state)
+			}
+		}
+	}
+}
+
+// Scroll scrolls a string once across the grid from right to left, moving one
+// column every delay. Scene and top button LEDs are turned off
+func Scroll(lp *launchpad.Launchpad, s string, state launchpad.LEDState, delay time.Duration) error {
+	columns := Columns(s)
+
+	screen := launchpad.NewFrameWriter(lp)
+	if err := screen.Draw(&launchpad.Frame{}); err != nil {
+		return err
+	}
+
+	for offset := launchpad.GridWidth; offset >= -len(columns); offset-- {
+		var frame launchpad.Frame
+		Draw(&frame, columns, offset, state)
+
+		if err := screen.Draw(&frame); err != nil {
+			return err
+		}
+		time.Sleep(delay)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package trace
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/inegm/golp/pkg/launchpad"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+// SMFTempo is the tempo of recorded files; at 960 ticks per quarter note one
+// tick is about half a millisecond
+const SMFTempo = 120
+
+// SMFRecorder collects traffic and writes it as a Standard MIDI File
+// Outgoing messages go to a track named "golp out" and incoming messages to
+// "golp in", timed from the first message
+type SMFRecorder struct {
+	mu      sync.Mutex
+	start   time.Time
+	started bool
+	tracks  [2]*trackRecorder
+}
+
+// trackRecorder accumulates the events of one direction
+type trackRecorder struct {
+	track smf.Track
+	last  uint32 // Absolute ticks of the last event
+}
+
+// NewSMFRecorder creates an empty recorder
+func NewSMFRecorder() *SMFRecorder {
+	return &SMFRecorder{
+		tracks: [2]*trackRecorder{{}, {}},
+	}
+}
+
+// Handle records a message; it can be passed to OnTraffic
+func (r *SMFRecorder) Handle(t launchpad.Traffic) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.started {
+		r.start = t.Time
+		r.started = true
+	}
+
+	tr := r.tracks[0]
+	if t.Direction == launchpad.Incoming {
+		tr = r.tracks[1]
+	}
+
+	ticks := smf.MetricTicks(960).Ticks(SMFTempo, t.Time.Sub(r.start))
+	if ticks < tr.last {
+		ticks = tr.last
+	}
+	tr.track.Add(ticks-tr.last, t.Data)
+	tr.last = ticks
+}
+
+// SMF returns the recording as a format 1 file
+func (r *SMFRecorder) SMF() *smf.SMF {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file := smf.NewSMF1()
+	file.TimeFormat = smf.MetricTicks(960)
+
+	for i, name := range []string{"golp out", "golp in"} {
+		var track smf.Track
+		track.Add(0, smf.MetaTrackSequenceName(name))
+		if i == 0 {
+			track.Add(0, smf.MetaTempo(SMFTempo))
+		}
+		track = append(track, r.tracks[i].track...)
+		track.Close(0)
+		file.Add(track)
+	}
+	return file
+}
+
+// WriteTo writes the recording as a Standard MIDI File
+func (r *SMFRecorder) WriteTo(w io.Writer) (int64, error) {
+	return r.SMF().WriteTo(w)
+}
+
+// WriteFile writes the recording to a Standard MIDI File
+func (r *SMFRecorder) WriteFile(path string) error {
+	return r.SMF().WriteFile(path)
+}
@@ -0,0 +1,79 @@
+// Package trace records raw Launchpad MIDI traffic for protocol-level debugging.
+//
+// Each function returns a launchpad.TrafficHandler that decodes messages into
+// readable operations and writes them to a log/slog logger, a text stream or
+// a Standard MIDI File:
+//
+//	lp.OnTraffic(trace.Slog(slog.Default()))
+//	lp.OnTraffic(trace.Text(os.Stderr))
+//
+//	rec := trace.NewSMFRecorder()
+//	lp.OnTraffic(rec.Handle)
+//	...
+//	err := rec.WriteFile("traffic.mid")
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// decoders keeps one Decoder per direction, since rapid updates are stateful
+type decoders struct {
+	mu  sync.Mutex
+	out launchpad.Decoder
+	in  launchpad.Decoder
+}
+
+// decode describes a message using the decoder for its direction
+func (d *decoders) decode(t launchpad.Traffic) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t.Direction == launchpad.Incoming {
+		return d.in.Decode(t)
+	}
+	return d.out.Decode(t)
+}
+
+// Slog logs every message at debug level with its direction, bytes and
+// decoded operation
+// Messages are decoded even while debug logging is disabled, so that rapid
+// updates in progress when it is enabled are still described correctly.
+func Slog(logger *slog.Logger) launchpad.TrafficHandler {
+	var d decoders
+	return func(t launchpad.Traffic) {
+		op := d.decode(t)
+
+		ctx := context.Background()
+		if !logger.Enabled(ctx, slog.LevelDebug) {
+			return
+		}
+		logger.LogAttrs(ctx, slog.LevelDebug, "midi",
+			slog.String("dir", t.Direction.String()),
+			slog.String("bytes", fmt.Sprintf("% X", t.Data)),
+			slog.String("op", op),
+		)
+	}
+}
+
+// Text writes one line per message:
+//
+//	15:04:05.000000 out 90 34 0F  grid[4,3] R3G0 copy|clear
+func Text(w io.Writer) launchpad.TrafficHandler {
+	var d decoders
+	var mu sync.Mutex
+	return func(t launchpad.Traffic) {
+		line := fmt.Sprintf("%s %-3s % X  %s\n",
+			t.Time.Format("15:04:05.000000"), t.Direction, t.Data, d.decode(t))
+
+		mu.Lock()
+		defer mu.Unlock()
+		io.WriteString(w, line)
+	}
+}
@@ -0,0 +1,41 @@
+package trace
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+func TestSlogDecodesWhileDisabled(t *testing.T) {
+	var level slog.LevelVar
+	level.Set(slog.LevelInfo)
+	var buf bytes.Buffer
+	handle := Slog(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: &level})))
+
+	// A rapid update of 40 messages, of which only the last ones are logged
+	var traffic []launchpad.Traffic
+	for i := 0; i < launchpad.FrameSize/2; i++ {
+		traffic = append(traffic, launchpad.Traffic{
+			Direction: launchpad.Outgoing,
+			Data:      []byte{0x92, byte(i % 4), byte(i % 4 * 16)},
+		})
+	}
+
+	var want launchpad.Decoder
+	for i, tr := range traffic {
+		if i == 30 {
+			level.Set(slog.LevelDebug)
+		}
+		handle(tr)
+		op := want.Decode(tr)
+		if i == 30 && !strings.Contains(buf.String(), "op=\""+op+"\"") {
+			t.Fatalf("first message logged as %q, want op %q", buf.String(), op)
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 10 {
+		t.Fatalf("logged %d messages, want 10", n)
+	}
+}
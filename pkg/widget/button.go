@@ -0,0 +1,166 @@
+package widget
+
+import (
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// ToggleEvent reports a change of a Toggle's state
+type ToggleEvent struct {
+	Toggle *Toggle // The widget that changed
+	On     bool    // New state
+}
+
+// Toggle is a single pad that flips between on and off on each press
+type Toggle struct {
+	mu       sync.Mutex
+	button   launchpad.Button
+	style    Style
+	on       bool
+	handlers []func(ToggleEvent)
+}
+
+// NewToggle creates a toggle on a button, initially off
+func NewToggle(btn launchpad.Button) *Toggle {
+	return &Toggle{button: btn, style: DefaultStyle}
+}
+
+// SetStyle sets the LED states for on and off
+func (t *Toggle) SetStyle(style Style) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.style = style
+}
+
+// OnChange registers a handler called when the toggle changes state
+func (t *Toggle) OnChange(handler func(ToggleEvent)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers = append(t.handlers, handler)
+}
+
+// On returns the toggle state
+func (t *Toggle) On() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.on
+}
+
+// SetOn sets the toggle state without calling change handlers
+func (t *Toggle) SetOn(on bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.on = on
+}
+
+// Buttons returns the toggle's button
+func (t *Toggle) Buttons() []launchpad.Button {
+	return []launchpad.Button{t.button}
+}
+
+// Handle flips the toggle on press
+func (t *Toggle) Handle(event launchpad.ButtonEvent) bool {
+	if !event.Pressed {
+		return false
+	}
+
+	t.mu.Lock()
+	t.on = !t.on
+	e := ToggleEvent{Toggle: t, On: t.on}
+	handlers := make([]func(ToggleEvent), len(t.handlers))
+	copy(handlers, t.handlers)
+	t.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+	return true
+}
+
+// Render draws the toggle
+func (t *Toggle) Render(frame *launchpad.Frame) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.on {
+		frame.Set(t.button, t.style.On)
+	} else {
+		frame.Set(t.button, t.style.Off)
+	}
+}
+
+// MomentaryEvent reports a Momentary being pressed or released
+type MomentaryEvent struct {
+	Momentary *Momentary // The widget that changed
+	Active    bool       // True while the pad is held
+}
+
+// Momentary is a single pad that is active only while held
+type Momentary struct {
+	mu       sync.Mutex
+	button   launchpad.Button
+	style    Style
+	active   bool
+	handlers []func(MomentaryEvent)
+}
+
+// NewMomentary creates a momentary pad on a button
+func NewMomentary(btn launchpad.Button) *Momentary {
+	return &Momentary{button: btn, style: DefaultStyle}
+}
+
+// SetStyle sets the LED states for held and released
+func (m *Momentary) SetStyle(style Style) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.style = style
+}
+
+// OnChange registers a handler called when the pad is pressed or released
+func (m *Momentary) OnChange(handler func(MomentaryEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, handler)
+}
+
+// Active returns whether the pad is held
+func (m *Momentary) Active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+// Buttons returns the pad's button
+func (m *Momentary) Buttons() []launchpad.Button {
+	return []launchpad.Button{m.button}
+}
+
+// Handle follows the button state
+func (m *Momentary) Handle(event launchpad.ButtonEvent) bool {
+	m.mu.Lock()
+	if m.active == event.Pressed {
+		m.mu.Unlock()
+		return false
+	}
+	m.active = event.Pressed
+	e := MomentaryEvent{Momentary: m, Active: m.active}
+	handlers := make([]func(MomentaryEvent), len(m.handlers))
+	copy(handlers, m.handlers)
+	m.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+	return true
+}
+
+// Render draws the pad
+func (m *Momentary) Render(frame *launchpad.Frame) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active {
+		frame.Set(m.button, m.style.On)
+	} else {
+		frame.Set(m.button, m.style.Off)
+	}
+}
@@ -0,0 +1,202 @@
+package widget
+
+import (
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Orientation is the direction of a Fader
+type Orientation int
+
+const (
+	Vertical   Orientation = iota // Grid column, minimum at the bottom
+	Horizontal                    // Grid row, minimum on the left
+)
+
+// fineSteps is the number of fine values per pad (one per brightness level)
+const fineSteps = 3
+
+// FaderEvent reports a change of a Fader's value
+type FaderEvent struct {
+	Fader *Fader // The widget that changed
+	Value int    // New value, from 0 to Fader.Max()
+}
+
+// Fader is an 8-pad slider along a grid column or row
+//
+// In coarse mode the value ranges from 0 to 8, one step per pad. In fine mode each
+// pad holds three steps shown as brightness levels, so the value ranges from 0 to 24;
+// pressing the topmost lit pad again lowers the value by one step.
+type Fader struct {
+	mu       sync.Mutex
+	buttons  []launchpad.Button // Ordered from minimum to maximum
+	style    Style
+	fine     bool
+	value    int
+	handlers []func(FaderEvent)
+}
+
+// NewFader creates a fader on grid column index (Vertical) or row index (Horizontal)
+func NewFader(orientation Orientation, index int) *Fader {
+	var buttons []launchpad.Button
+	if orientation == Vertical {
+		for y := launchpad.GridHeight - 1; y >= 0; y-- {
+			buttons = append(buttons, launchpad.NewGridButton(index, y))
+		}
+	} else {
+		for x := 0; x < launchpad.GridWidth; x++ {
+			buttons = append(buttons, launchpad.NewGridButton(x, index))
+		}
+	}
+	return &Fader{buttons: buttons, style: DefaultStyle}
+}
+
+// SetStyle sets the LED states for filled and empty pads
+func (f *Fader) SetStyle(style Style) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.style = style
+}
+
+// SetFine enables or disables fine mode, keeping the value at the same position
+func (f *Fader) SetFine(fine bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fine == f.fine {
+		return
+	}
+	if fine {
+		f.value *= fineSteps
+	} else {
+		f.value = (f.value + fineSteps - 1) / fineSteps
+	}
+	f.fine = fine
+}
+
+// Fine returns whether fine mode is enabled
+func (f *Fader) Fine() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fine
+}
+
+// Max returns the largest value of the fader
+func (f *Fader) Max() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.maxLocked()
+}
+
+// maxLocked returns the largest value (caller must hold the lock)
+func (f *Fader) maxLocked() int {
+	if f.fine {
+		return len(f.buttons) * fineSteps
+	}
+	return len(f.buttons)
+}
+
+// OnChange registers a handler called when the value changes
+func (f *Fader) OnChange(handler func(FaderEvent)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers = append(f.handlers, handler)
+}
+
+// Value returns the fader value
+func (f *Fader) Value() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.value
+}
+
+// SetValue sets the fader value, clamped to its range, without calling change handlers
+func (f *Fader) SetValue(value int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.value = max(0, min(value, f.maxLocked()))
+}
+
+// Buttons returns the fader's pads
+func (f *Fader) Buttons() []launchpad.Button {
+	return f.buttons
+}
+
+// Handle sets the value from the pressed pad
+func (f *Fader) Handle(event launchpad.ButtonEvent) bool {
+	if !event.Pressed {
+		return false
+	}
+
+	f.mu.Lock()
+	pad := -1
+	for i, btn := range f.buttons {
+		if btn == event.Button {
+			pad = i
+			break
+		}
+	}
+	if pad < 0 {
+		f.mu.Unlock()
+		return false
+	}
+
+	steps := 1
+	if f.fine {
+		steps = fineSteps
+	}
+	value := (pad + 1) * steps
+	if f.value > pad*steps && f.value <= value {
+		// Pressing the topmost lit pad steps down, reaching zero from the first pad
+		value = f.value - 1
+	}
+	if value == f.value {
+		f.mu.Unlock()
+		return false
+	}
+	f.value = value
+	e := FaderEvent{Fader: f, Value: value}
+	handlers := make([]func(FaderEvent), len(f.handlers))
+	copy(handlers, f.handlers)
+	f.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+	return true
+}
+
+// Render draws the fader, showing fine steps as brightness on the topmost pad
+func (f *Fader) Render(frame *launchpad.Frame) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	steps := 1
+	if f.fine {
+		steps = fineSteps
+	}
+	for i, btn := range f.buttons {
+		level := f.value - i*steps
+		switch {
+		case level <= 0:
+			frame.Set(btn, f.style.Off)
+		case level >= steps:
+			frame.Set(btn, f.style.On)
+		default:
+			frame.Set(btn, scale(f.style.On, level, steps))
+		}
+	}
+}
+
+// scale dims an LED state to level/steps of its brightness, keeping it visible
+func scale(state launchpad.LEDState, level, steps int) launchpad.LEDState {
+	dim := func(b launchpad.Brightness) launchpad.Brightness {
+		if b == launchpad.BrightnessOff {
+			return b
+		}
+		return max(launchpad.BrightnessLow, launchpad.Brightness(int(b)*level/steps))
+	}
+	state.Red = dim(state.Red)
+	state.Green = dim(state.Green)
+	return state
+}
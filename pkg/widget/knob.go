@@ -0,0 +1,135 @@
+package widget
+
+import (
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// KnobEvent reports a change of a Knob's value
+type KnobEvent struct {
+	Knob  *Knob // The widget that changed
+	Value int   // New value
+}
+
+// Knob is a bounded value adjusted with a decrement and an increment pad
+// Each pad's brightness shows how far the value is from that end of the range
+type Knob struct {
+	mu       sync.Mutex
+	dec      launchpad.Button
+	inc      launchpad.Button
+	style    Style
+	min, max int
+	step     int
+	value    int
+	handlers []func(KnobEvent)
+}
+
+// NewKnob creates a knob ranging from min to max, starting at min
+func NewKnob(dec, inc launchpad.Button, min, max int) *Knob {
+	if max < min {
+		min, max = max, min
+	}
+	return &Knob{
+		dec:   dec,
+		inc:   inc,
+		style: DefaultStyle,
+		min:   min,
+		max:   max,
+		step:  1,
+		value: min,
+	}
+}
+
+// SetStyle sets the LED states used at the far and near ends of the range
+func (k *Knob) SetStyle(style Style) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.style = style
+}
+
+// SetStep sets the amount added or removed per press (at least 1)
+func (k *Knob) SetStep(step int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.step = max(1, step)
+}
+
+// OnChange registers a handler called when the value changes
+func (k *Knob) OnChange(handler func(KnobEvent)) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.handlers = append(k.handlers, handler)
+}
+
+// Value returns the knob value
+func (k *Knob) Value() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.value
+}
+
+// SetValue sets the value, clamped to the range, without calling change handlers
+func (k *Knob) SetValue(value int) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.value = max(k.min, min(value, k.max))
+}
+
+// Buttons returns the decrement and increment pads
+func (k *Knob) Buttons() []launchpad.Button {
+	return []launchpad.Button{k.dec, k.inc}
+}
+
+// Handle steps the value down or up
+func (k *Knob) Handle(event launchpad.ButtonEvent) bool {
+	if !event.Pressed {
+		return false
+	}
+
+	k.mu.Lock()
+	value := k.value
+	switch event.Button {
+	case k.dec:
+		value = max(k.min, value-k.step)
+	case k.inc:
+		value = min(k.max, value+k.step)
+	}
+	if value == k.value {
+		k.mu.Unlock()
+		return false
+	}
+	k.value = value
+	e := KnobEvent{Knob: k, Value: value}
+	handlers := make([]func(KnobEvent), len(k.handlers))
+	copy(handlers, k.handlers)
+	k.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+	return true
+}
+
+// Render draws both pads
+func (k *Knob) Render(frame *launchpad.Frame) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	span := k.max - k.min
+	if span == 0 {
+		frame.Set(k.dec, k.style.Off)
+		frame.Set(k.inc, k.style.Off)
+		return
+	}
+	frame.Set(k.dec, k.padState(k.max-k.value, span))
+	frame.Set(k.inc, k.padState(k.value-k.min, span))
+}
+
+// padState returns the Off style at distance 0 and the On style scaled by distance/span otherwise
+func (k *Knob) padState(distance, span int) launchpad.LEDState {
+	if distance <= 0 {
+		return k.style.Off
+	}
+	return scale(k.style.On, distance, span)
+}
@@ -0,0 +1,133 @@
+package widget
+
+import (
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// RadioEvent reports a change of a RadioGroup's selection
+type RadioEvent struct {
+	Group    *RadioGroup // The widget that changed
+	Selected int         // Index of the selected button
+}
+
+// RadioGroup is a set of buttons of which exactly one is selected
+type RadioGroup struct {
+	mu       sync.Mutex
+	buttons  []launchpad.Button
+	style    Style
+	selected int
+	handlers []func(RadioEvent)
+}
+
+// NewRadioGroup creates a radio group over the given buttons with the first one selected
+func NewRadioGroup(buttons ...launchpad.Button) *RadioGroup {
+	return &RadioGroup{buttons: buttons, style: DefaultStyle}
+}
+
+// NewRadioRow creates a radio group across a grid row
+func NewRadioRow(y int) *RadioGroup {
+	buttons := make([]launchpad.Button, launchpad.GridWidth)
+	for x := range buttons {
+		buttons[x] = launchpad.NewGridButton(x, y)
+	}
+	return NewRadioGroup(buttons...)
+}
+
+// NewRadioSceneColumn creates a radio group across the scene buttons
+func NewRadioSceneColumn() *RadioGroup {
+	buttons := make([]launchpad.Button, launchpad.SceneButtons)
+	for y := range buttons {
+		buttons[y] = launchpad.NewSceneButton(y)
+	}
+	return NewRadioGroup(buttons...)
+}
+
+// NewRadioTopRow creates a radio group across the top buttons
+func NewRadioTopRow() *RadioGroup {
+	buttons := make([]launchpad.Button, launchpad.TopButtons)
+	for x := range buttons {
+		buttons[x] = launchpad.NewTopButton(x)
+	}
+	return NewRadioGroup(buttons...)
+}
+
+// SetStyle sets the LED states for the selected and unselected buttons
+func (r *RadioGroup) SetStyle(style Style) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.style = style
+}
+
+// OnChange registers a handler called when the selection changes
+func (r *RadioGroup) OnChange(handler func(RadioEvent)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, handler)
+}
+
+// Selected returns the index of the selected button
+func (r *RadioGroup) Selected() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.selected
+}
+
+// SetSelected selects a button by index without calling change handlers
+// Out of range indexes are ignored
+func (r *RadioGroup) SetSelected(index int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if index >= 0 && index < len(r.buttons) {
+		r.selected = index
+	}
+}
+
+// Buttons returns the group's buttons
+func (r *RadioGroup) Buttons() []launchpad.Button {
+	return r.buttons
+}
+
+// Handle selects the pressed button
+func (r *RadioGroup) Handle(event launchpad.ButtonEvent) bool {
+	if !event.Pressed {
+		return false
+	}
+
+	r.mu.Lock()
+	index := -1
+	for i, btn := range r.buttons {
+		if btn == event.Button {
+			index = i
+			break
+		}
+	}
+	if index < 0 || index == r.selected {
+		r.mu.Unlock()
+		return false
+	}
+	r.selected = index
+	e := RadioEvent{Group: r, Selected: index}
+	handlers := make([]func(RadioEvent), len(r.handlers))
+	copy(handlers, r.handlers)
+	r.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+	return true
+}
+
+// Render draws the group
+func (r *RadioGroup) Render(frame *launchpad.Frame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, btn := range r.buttons {
+		if i == r.selected {
+			frame.Set(btn, r.style.On)
+		} else {
+			frame.Set(btn, r.style.Off)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package widget provides reusable controls built on Launchpad buttons and LEDs.
+//
+// A Widget owns a set of buttons, renders itself into a frame and reports value
+// changes through typed events instead of raw button events. Widgets are placed
+// on a Surface, which routes button events to the widget owning the button and
+// redraws the device when a widget changes:
+//
+//	surface := widget.NewSurface()
+//	mute := widget.NewToggle(launchpad.NewSceneButton(0))
+//	mute.OnChange(func(e widget.ToggleEvent) { fmt.Println("mute:", e.On) })
+//	surface.Add(mute)
+//
+//	volume := widget.NewFader(widget.Vertical, 0)
+//	volume.OnChange(func(e widget.FaderEvent) { fmt.Println("volume:", e.Value) })
+//	surface.Add(volume)
+//
+//	surface.Attach(lp)
+package widget
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// Widget is a control that owns a region of buttons
+type Widget interface {
+	// Buttons returns the buttons owned by the widget
+	Buttons() []launchpad.Button
+
+	// Handle processes an event for one of the widget's buttons
+	// and returns true if the widget needs to be redrawn
+	Handle(event launchpad.ButtonEvent) bool
+
+	// Render draws the widget into a frame
+	Render(frame *launchpad.Frame)
+}
+
+// Style holds the LED states a widget draws with
+type Style struct {
+	On  launchpad.LEDState // Active, selected or filled
+	Off launchpad.LEDState // Inactive, unselected or empty
+}
+
+// DefaultStyle is the style given to new widgets
+var DefaultStyle = Style{
+	On:  launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessFull),
+	Off: launchpad.NewLEDState(launchpad.ColorGreen, launchpad.BrightnessLow),
+}
+
+// Surface routes button events to widgets and draws them
+type Surface struct {
+	mu      sync.Mutex
+	widgets []Widget
+	owners  map[launchpad.Button]Widget
+	screen  *launchpad.FrameWriter // Draws on the attached Launchpad, nil until Attach
+}
+
+// NewSurface creates an empty surface
+func NewSurface() *Surface {
+	return &Surface{
+		owners: make(map[launchpad.Button]Widget),
+	}
+}
+
+// Add places a widget on the surface
+// Returns an error if the widget uses an invalid button or one owned by another widget
+func (s *Surface) Add(w Widget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, btn := range w.Buttons() {
+		if !btn.Valid() {
+			return fmt.Errorf("invalid button: %v", btn)
+		}
+		if _, taken := s.owners[btn]; taken {
+			return fmt.Errorf("button already owned by another widget: %v", btn)
+		}
+	}
+
+	for _, btn := range w.Buttons() {
+		s.owners[btn] = w
+	}
+	s.widgets = append(s.widgets, w)
+	return nil
+}
+
+// Remove takes a widget off the surface
+func (s *Surface) Remove(w Widget) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, widget := range s.widgets {
+		if widget == w {
+			s.widgets = append(s.widgets[:i], s.widgets[i+1:]...)
+			break
+		}
+	}
+	for _, btn := range w.Buttons() {
+		if s.owners[btn] == w {
+			delete(s.owners, btn)
+		}
+	}
+}
+
+// Handle routes a button event to the widget owning the button
+// It can be registered directly with Launchpad.OnButton or a page's OnButton
+func (s *Surface) Handle(event launchpad.ButtonEvent) {
+	s.mu.Lock()
+	w, ok := s.owners[event.Button]
+	s.mu.Unlock()
+
+	if ok && w.Handle(event) {
+		s.Draw()
+	}
+}
+
+// Render draws every widget into a frame
+func (s *Surface) Render(frame *launchpad.Frame) {
+	s.mu.Lock()
+	widgets := make([]Widget, len(s.widgets))
+	copy(widgets, s.widgets)
+	s.mu.Unlock()
+
+	for _, w := range widgets {
+		w.Render(frame)
+	}
+}
+
+// Attach registers the surface for a Launchpad's button events and draws it
+// Widgets changed by button presses are redrawn automatically; call Draw after
+// changing widget values from code
+func (s *Surface) Attach(lp *launchpad.Launchpad) error {
+	s.mu.Lock()
+	s.screen = launchpad.NewFrameWriter(lp)
+	s.mu.Unlock()
+
+	lp.OnButton(s.Handle)
+	return s.Draw()
+}
+
+// Draw sends the surface to the attached Launchpad, if any
+// Only LEDs that changed since the previous draw are sent
+func (s *Surface) Draw() error {
+	var frame launchpad.Frame
+	s.Render(&frame)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.screen == nil {
+		return nil
+	}
+	return s.screen.Draw(&frame)
+}
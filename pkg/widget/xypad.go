@@ -0,0 +1,126 @@
+package widget
+
+import (
+	"sync"
+
+	"github.com/inegm/golp/pkg/launchpad"
+)
+
+// XYEvent reports a change of an XYPad's position
+type XYEvent struct {
+	Pad *XYPad // The widget that changed
+	X   int    // Column within the pad, from 0 at the left
+	Y   int    // Row within the pad, from 0 at the bottom
+}
+
+// XYPad is a rectangular area of the grid selecting a two-dimensional position
+// The selected pad is drawn with the On style and its row and column with the Off style
+type XYPad struct {
+	mu       sync.Mutex
+	left     int
+	top      int
+	width    int
+	height   int
+	style    Style
+	x, y     int
+	handlers []func(XYEvent)
+}
+
+// NewXYPad creates an XY pad covering a grid rectangle
+// The rectangle is clipped to the grid
+func NewXYPad(left, top, width, height int) *XYPad {
+	left = max(0, min(left, launchpad.GridWidth-1))
+	top = max(0, min(top, launchpad.GridHeight-1))
+	return &XYPad{
+		left:   left,
+		top:    top,
+		width:  max(1, min(width, launchpad.GridWidth-left)),
+		height: max(1, min(height, launchpad.GridHeight-top)),
+		style:  DefaultStyle,
+	}
+}
+
+// SetStyle sets the LED states for the selected pad and its crosshair
+func (p *XYPad) SetStyle(style Style) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.style = style
+}
+
+// OnChange registers a handler called when the position changes
+func (p *XYPad) OnChange(handler func(XYEvent)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+// Position returns the selected position
+func (p *XYPad) Position() (x, y int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.x, p.y
+}
+
+// SetPosition sets the position, clamped to the pad, without calling change handlers
+func (p *XYPad) SetPosition(x, y int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.x = max(0, min(x, p.width-1))
+	p.y = max(0, min(y, p.height-1))
+}
+
+// Buttons returns the grid buttons covered by the pad
+func (p *XYPad) Buttons() []launchpad.Button {
+	var buttons []launchpad.Button
+	for y := p.top; y < p.top+p.height; y++ {
+		for x := p.left; x < p.left+p.width; x++ {
+			buttons = append(buttons, launchpad.NewGridButton(x, y))
+		}
+	}
+	return buttons
+}
+
+// Handle moves the position to the pressed pad
+func (p *XYPad) Handle(event launchpad.ButtonEvent) bool {
+	if !event.Pressed {
+		return false
+	}
+
+	p.mu.Lock()
+	x := event.Button.X - p.left
+	y := p.top + p.height - 1 - event.Button.Y
+	if x == p.x && y == p.y {
+		p.mu.Unlock()
+		return false
+	}
+	p.x, p.y = x, y
+	e := XYEvent{Pad: p, X: x, Y: y}
+	handlers := make([]func(XYEvent), len(p.handlers))
+	copy(handlers, p.handlers)
+	p.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+	return true
+}
+
+// Render draws the selected pad and its crosshair
+func (p *XYPad) Render(frame *launchpad.Frame) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for row := 0; row < p.height; row++ {
+		for col := 0; col < p.width; col++ {
+			btn := launchpad.NewGridButton(p.left+col, p.top+p.height-1-row)
+			switch {
+			case col == p.x && row == p.y:
+				frame.Set(btn, p.style.On)
+			case col == p.x || row == p.y:
+				frame.Set(btn, p.style.Off)
+			default:
+				frame.Set(btn, launchpad.LEDState{})
+			}
+		}
+	}
+}